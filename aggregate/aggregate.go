@@ -0,0 +1,388 @@
+/*
+Package aggregate groups and totals time entries locally, as an offline
+alternative to Toggl's summary reports.
+
+Entries are grouped by any combination of time period, workspace, project,
+client, task, tag and description, in the order the dimensions are given:
+
+	agg := aggregate.New(&account, aggregate.Options{
+		GroupBy:  []aggregate.Dimension{aggregate.Project, aggregate.Description},
+		Rounding: workspace.RoundingRule(),
+	})
+	for _, entry := range entries {
+		agg.Add(entry)
+	}
+	report := agg.Result().SummaryReport()
+*/
+package aggregate
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Dimension is a property time entries can be grouped by.
+type Dimension string
+
+// Grouping dimensions.
+const (
+	Day         Dimension = "day"
+	Week        Dimension = "week"
+	Month       Dimension = "month"
+	Workspace   Dimension = "workspace"
+	Project     Dimension = "project"
+	Client      Dimension = "client"
+	Task        Dimension = "task"
+	Tag         Dimension = "tag"
+	Description Dimension = "description"
+)
+
+// Titles of groups for entries that don't have a value for a dimension.
+var missingTitles = map[Dimension]string{
+	Workspace:   "(no workspace)",
+	Project:     "(no project)",
+	Client:      "(no client)",
+	Task:        "(no task)",
+	Tag:         "(no tag)",
+	Description: "(no description)",
+}
+
+// Options controls how entries are aggregated.
+type Options struct {
+	// GroupBy lists the dimensions to group by, outermost first.
+	GroupBy []Dimension
+	// Calendar determines the days, weeks and months entries fall in.
+	// Entries crossing a day boundary are split when grouping by time.
+	Calendar toggl.Calendar
+	// Rounding is applied to every total.
+	Rounding toggl.Rounding
+}
+
+// Group is a set of entries sharing the same value for a dimension.
+type Group struct {
+	Dimension Dimension
+	// Key identifies the group: an ID for workspaces, projects, clients and
+	// tasks, a date for time periods, and the name for tags and descriptions.
+	// It's empty for entries without a value.
+	Key   string
+	Title string
+	Totals
+	Groups []*Group
+
+	// Time periods are ordered chronologically rather than by title
+	start time.Time
+	index map[string]*Group
+	total toggl.Accumulator
+	bill  toggl.Accumulator
+}
+
+// Totals are the durations and number of entries in a group.
+type Totals struct {
+	Total    time.Duration
+	Billable time.Duration
+	Count    int
+}
+
+// Result is the outcome of an aggregation.
+type Result struct {
+	Totals
+	Groups []*Group
+}
+
+// Aggregator accumulates time entries into groups.
+type Aggregator struct {
+	options Options
+	root    Group
+	now     time.Time
+
+	workspaces map[int]toggl.Workspace
+	projects   map[int]toggl.Project
+	clients    map[int]toggl.Client
+	tasks      map[int]toggl.Task
+}
+
+// New creates an aggregator. The account is used to look up the names of
+// workspaces, projects, clients and tasks; it may be nil, in which case groups
+// are titled with IDs.
+func New(account *toggl.Account, options Options) *Aggregator {
+	a := &Aggregator{
+		options:    options,
+		now:        time.Now(),
+		workspaces: make(map[int]toggl.Workspace),
+		projects:   make(map[int]toggl.Project),
+		clients:    make(map[int]toggl.Client),
+		tasks:      make(map[int]toggl.Task),
+	}
+	a.root.total.Rule = options.Rounding
+	a.root.bill.Rule = options.Rounding
+
+	if account != nil {
+		for _, w := range account.Data.Workspaces {
+			a.workspaces[w.ID] = w
+		}
+		for _, p := range account.Data.Projects {
+			a.projects[p.ID] = p
+		}
+		for _, c := range account.Data.Clients {
+			a.clients[c.ID] = c
+		}
+		for _, t := range account.Data.Tasks {
+			a.tasks[t.ID] = t
+		}
+	}
+	return a
+}
+
+// Aggregate groups a list of entries in one step.
+func Aggregate(account *toggl.Account, entries []toggl.TimeEntry, options Options) Result {
+	a := New(account, options)
+	for _, entry := range entries {
+		a.Add(entry)
+	}
+	return a.Result()
+}
+
+// Add adds a time entry to the aggregation. Deleted entries and entries
+// without a start time are ignored. Running entries count up to the time the
+// aggregator was created. An entry with several tags is counted once in each
+// tag's group, so tag groups can add up to more than their parent.
+func (a *Aggregator) Add(entry toggl.TimeEntry) {
+	if entry.Start == nil || entry.ServerDeletedAt != nil {
+		return
+	}
+
+	parts := []toggl.TimeEntry{entry}
+	if a.groupsByTime() {
+		parts = a.options.Calendar.Split(entry)
+	}
+
+	// Parts of a split entry count as one entry in the groups they share
+	counted := make(map[*Group]bool)
+	for _, part := range parts {
+		a.add(&a.root, part, a.options.GroupBy, counted)
+	}
+}
+
+// Result returns the totals and groups of the entries added so far. Groups are
+// ordered chronologically for time periods and by title otherwise.
+func (a *Aggregator) Result() Result {
+	a.finish(&a.root)
+	return Result{Totals: a.root.Totals, Groups: a.root.Groups}
+}
+
+// SummaryReport converts the result into the structure returned by Toggl's
+// summary report API, using the first two grouping dimensions. Times are in
+// milliseconds.
+func (r *Result) SummaryReport() toggl.SummaryReport {
+	report := toggl.SummaryReport{
+		TotalGrand:    milliseconds(r.Total),
+		TotalBillable: milliseconds(r.Billable),
+	}
+
+	for _, group := range r.Groups {
+		id, _ := strconv.Atoi(group.Key)
+		data := toggl.SummaryReportGroup{
+			ID:   id,
+			Time: milliseconds(group.Total),
+		}
+		switch group.Dimension {
+		case Client:
+			data.Title.Client = group.Title
+		default:
+			data.Title.Project = group.Title
+		}
+
+		for _, item := range group.Groups {
+			data.Items = append(data.Items, toggl.SummaryReportItem{
+				Title: map[string]string{itemTitleKey(item.Dimension): item.Title},
+				Time:  milliseconds(item.Total),
+			})
+		}
+		report.Data = append(report.Data, data)
+	}
+
+	return report
+}
+
+// support /////////////////////////////////////////////////////////////
+
+func (a *Aggregator) add(group *Group, entry toggl.TimeEntry, dims []Dimension, counted map[*Group]bool) {
+	duration := a.duration(&entry)
+	group.total.Add(duration)
+	if entry.Billable != 0 {
+		group.bill.Add(duration)
+	}
+	if !counted[group] {
+		group.Count++
+		counted[group] = true
+	}
+
+	if len(dims) == 0 {
+		return
+	}
+
+	for _, child := range a.children(group, entry, dims[0]) {
+		a.add(child, entry, dims[1:], counted)
+	}
+}
+
+// children returns the groups an entry belongs to for a dimension, creating
+// them as needed.
+func (a *Aggregator) children(parent *Group, entry toggl.TimeEntry, dim Dimension) (groups []*Group) {
+	for _, key := range a.keys(entry, dim) {
+		if parent.index == nil {
+			parent.index = make(map[string]*Group)
+		}
+		child, ok := parent.index[key]
+		if !ok {
+			child = &Group{Dimension: dim, Key: key, Title: a.title(entry, dim, key)}
+			child.total.Rule = a.options.Rounding
+			child.bill.Rule = a.options.Rounding
+			if start := a.periodStart(entry, dim); !start.IsZero() {
+				child.start = start
+			}
+			parent.index[key] = child
+			parent.Groups = append(parent.Groups, child)
+		}
+		groups = append(groups, child)
+	}
+	return
+}
+
+// keys returns the keys of the groups an entry belongs to for a dimension.
+func (a *Aggregator) keys(entry toggl.TimeEntry, dim Dimension) []string {
+	id := func(n int) []string {
+		if n == 0 {
+			return []string{""}
+		}
+		return []string{strconv.Itoa(n)}
+	}
+
+	switch dim {
+	case Day:
+		return []string{a.periodStart(entry, dim).Format("2006-01-02")}
+	case Week:
+		return []string{a.periodStart(entry, dim).Format("2006-01-02")}
+	case Month:
+		return []string{a.periodStart(entry, dim).Format("2006-01")}
+	case Workspace:
+		return id(a.workspaceID(entry))
+	case Project:
+		return id(entry.Pid)
+	case Client:
+		return id(a.projects[entry.Pid].Cid)
+	case Task:
+		return id(entry.Tid)
+	case Tag:
+		if len(entry.Tags) == 0 {
+			return []string{""}
+		}
+		return entry.Tags
+	case Description:
+		return []string{entry.Description}
+	}
+	return []string{""}
+}
+
+// title returns the display name of a group.
+func (a *Aggregator) title(entry toggl.TimeEntry, dim Dimension, key string) string {
+	if key == "" {
+		return missingTitles[dim]
+	}
+
+	switch dim {
+	case Week:
+		return "Week of " + key
+	case Workspace:
+		if w, ok := a.workspaces[a.workspaceID(entry)]; ok {
+			return w.Name
+		}
+	case Project:
+		if p, ok := a.projects[entry.Pid]; ok {
+			return p.Name
+		}
+	case Client:
+		if c, ok := a.clients[a.projects[entry.Pid].Cid]; ok {
+			return c.Name
+		}
+	case Task:
+		if t, ok := a.tasks[entry.Tid]; ok {
+			return t.Name
+		}
+	}
+	return key
+}
+
+func (a *Aggregator) periodStart(entry toggl.TimeEntry, dim Dimension) time.Time {
+	switch dim {
+	case Day:
+		return a.options.Calendar.DayStart(*entry.Start)
+	case Week:
+		return a.options.Calendar.WeekStart(*entry.Start)
+	case Month:
+		return a.options.Calendar.MonthStart(*entry.Start)
+	}
+	return time.Time{}
+}
+
+func (a *Aggregator) workspaceID(entry toggl.TimeEntry) int {
+	if entry.Wid == 0 {
+		return a.projects[entry.Pid].Wid
+	}
+	return entry.Wid
+}
+
+func (a *Aggregator) groupsByTime() bool {
+	for _, dim := range a.options.GroupBy {
+		if dim == Day || dim == Week || dim == Month {
+			return true
+		}
+	}
+	return false
+}
+
+// duration returns the time covered by an entry, counting running entries up
+// to the time the aggregator was created.
+func (a *Aggregator) duration(entry *toggl.TimeEntry) time.Duration {
+	if entry.IsRunning() {
+		return a.now.Sub(*entry.Start)
+	}
+	return time.Duration(entry.Duration) * time.Second
+}
+
+// finish computes the totals of a group and its children and sorts them.
+func (a *Aggregator) finish(group *Group) {
+	group.Total = group.total.Total()
+	group.Billable = group.bill.Total()
+
+	for _, child := range group.Groups {
+		a.finish(child)
+	}
+	sort.SliceStable(group.Groups, func(i, j int) bool {
+		gi, gj := group.Groups[i], group.Groups[j]
+		if !gi.start.IsZero() || !gj.start.IsZero() {
+			return gi.start.Before(gj.start)
+		}
+		// Groups without a value go last
+		if (gi.Key == "") != (gj.Key == "") {
+			return gj.Key == ""
+		}
+		return gi.Title < gj.Title
+	})
+}
+
+// itemTitleKey returns the key Toggl uses for a dimension in the titles of
+// summary report items.
+func itemTitleKey(dim Dimension) string {
+	if dim == Description {
+		return "time_entry"
+	}
+	return string(dim)
+}
+
+func milliseconds(d time.Duration) int {
+	return int(d / time.Millisecond)
+}
@@ -0,0 +1,141 @@
+package aggregate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+func testAccount() *toggl.Account {
+	var a toggl.Account
+	a.Data.Workspaces = []toggl.Workspace{{ID: 1, Name: "Work"}}
+	a.Data.Clients = []toggl.Client{{ID: 10, Wid: 1, Name: "Acme"}}
+	a.Data.Projects = []toggl.Project{
+		{ID: 100, Wid: 1, Cid: 10, Name: "Website"},
+		{ID: 101, Wid: 1, Name: "Internal"},
+	}
+	return &a
+}
+
+// testEntries returns entries on Monday October 12 2026 and the days after,
+// in UTC.
+func testEntries() []toggl.TimeEntry {
+	entry := func(day, hour, minutes, pid int, description string, billable bool, tags ...string) toggl.TimeEntry {
+		start := time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC)
+		stop := start.Add(time.Duration(minutes) * time.Minute)
+		e := toggl.TimeEntry{Wid: 1, Pid: pid, Description: description, Start: &start, Stop: &stop, Duration: int64(minutes * 60), Tags: tags}
+		if billable {
+			e.Billable = 1
+		}
+		return e
+	}
+	deleted := entry(12, 8, 60, 100, "Design", true)
+	deleted.ServerDeletedAt = deleted.Start
+	return []toggl.TimeEntry{
+		entry(12, 9, 50, 100, "Design", true, "ui"),
+		entry(12, 11, 25, 100, "Review", true, "ui", "review"),
+		entry(13, 9, 10, 101, "Email", false),
+		// Crosses midnight, so it's split when grouping by day
+		entry(13, 23, 120, 100, "Deploy", true),
+		entry(14, 14, 5, 0, "", false),
+		deleted,
+	}
+}
+
+// tree lists the groups of a result with their totals, one per line and
+// indented by depth.
+func tree(r Result) []string {
+	lines := []string{fmt.Sprintf("%v billable %v in %d", r.Total, r.Billable, r.Count)}
+	var walk func(groups []*Group, depth int)
+	walk = func(groups []*Group, depth int) {
+		for _, g := range groups {
+			lines = append(lines, fmt.Sprintf("%s%s %q: %v billable %v in %d", strings.Repeat("  ", depth), g.Title, g.Key, g.Total, g.Billable, g.Count))
+			walk(g.Groups, depth+1)
+		}
+	}
+	walk(r.Groups, 0)
+	return lines
+}
+
+func TestAggregate(t *testing.T) {
+	calendar := toggl.Calendar{Location: time.UTC, BeginningOfWeek: time.Monday}
+	tests := []struct {
+		name    string
+		options Options
+		want    []string
+	}{
+		{"totals", Options{}, []string{"3h30m0s billable 3h15m0s in 5"}},
+		{"client and project", Options{GroupBy: []Dimension{Client, Project}}, []string{
+			"3h30m0s billable 3h15m0s in 5",
+			`Acme "10": 3h15m0s billable 3h15m0s in 3`,
+			`  Website "100": 3h15m0s billable 3h15m0s in 3`,
+			`(no client) "": 15m0s billable 0s in 2`,
+			`  Internal "101": 10m0s billable 0s in 1`,
+			`  (no project) "": 5m0s billable 0s in 1`,
+		}},
+		// Entries with several tags count in each
+		{"tag", Options{GroupBy: []Dimension{Tag}}, []string{
+			"3h30m0s billable 3h15m0s in 5",
+			`review "review": 25m0s billable 25m0s in 1`,
+			`ui "ui": 1h15m0s billable 1h15m0s in 2`,
+			`(no tag) "": 2h15m0s billable 2h0m0s in 3`,
+		}},
+		{"day and description", Options{GroupBy: []Dimension{Day, Description}, Calendar: calendar}, []string{
+			"3h30m0s billable 3h15m0s in 5",
+			`2026-10-12 "2026-10-12": 1h15m0s billable 1h15m0s in 2`,
+			`  Design "Design": 50m0s billable 50m0s in 1`,
+			`  Review "Review": 25m0s billable 25m0s in 1`,
+			`2026-10-13 "2026-10-13": 1h10m0s billable 1h0m0s in 2`,
+			`  Deploy "Deploy": 1h0m0s billable 1h0m0s in 1`,
+			`  Email "Email": 10m0s billable 0s in 1`,
+			`2026-10-14 "2026-10-14": 1h5m0s billable 1h0m0s in 2`,
+			`  Deploy "Deploy": 1h0m0s billable 1h0m0s in 1`,
+			`  (no description) "": 5m0s billable 0s in 1`,
+		}},
+		{"week", Options{GroupBy: []Dimension{Week, Workspace}, Calendar: calendar}, []string{
+			"3h30m0s billable 3h15m0s in 5",
+			`Week of 2026-10-12 "2026-10-12": 3h30m0s billable 3h15m0s in 5`,
+			`  Work "1": 3h30m0s billable 3h15m0s in 5`,
+		}},
+		// Every entry rounded up to 15 minutes, or only the totals
+		{"rounded entries", Options{GroupBy: []Dimension{Project}, Rounding: toggl.Rounding{Mode: toggl.RoundUp, Minutes: 15, PerEntry: true}}, []string{
+			"4h0m0s billable 3h30m0s in 5",
+			`Internal "101": 15m0s billable 0s in 1`,
+			`Website "100": 3h30m0s billable 3h30m0s in 3`,
+			`(no project) "": 15m0s billable 0s in 1`,
+		}},
+		{"rounded totals", Options{GroupBy: []Dimension{Project}, Rounding: toggl.Rounding{Mode: toggl.RoundUp, Minutes: 15}}, []string{
+			"3h30m0s billable 3h15m0s in 5",
+			`Internal "101": 15m0s billable 0s in 1`,
+			`Website "100": 3h15m0s billable 3h15m0s in 3`,
+			`(no project) "": 15m0s billable 0s in 1`,
+		}},
+	}
+
+	for _, test := range tests {
+		got := tree(Aggregate(testAccount(), testEntries(), test.options))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+		}
+	}
+}
+
+func TestSummaryReport(t *testing.T) {
+	result := Aggregate(nil, testEntries(), Options{GroupBy: []Dimension{Project, Description}})
+	report := result.SummaryReport()
+	if report.TotalGrand != 210*60*1000 || report.TotalBillable != 195*60*1000 || len(report.Data) != 3 {
+		t.Fatalf("report %+v", report)
+	}
+	// Without an account, projects are titled with their IDs
+	website := report.Data[0]
+	if website.ID != 100 || website.Title.Project != "100" || website.Time != 195*60*1000 || len(website.Items) != 3 {
+		t.Errorf("project %+v", website)
+	}
+	if item := website.Items[0]; item.Title["time_entry"] != "Deploy" || item.Time != 120*60*1000 {
+		t.Errorf("item %+v", item)
+	}
+}
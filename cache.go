@@ -0,0 +1,367 @@
+package toggl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Kinds of cached account entities, used with Cache.Invalidate.
+const (
+	CacheWorkspaces  = "workspaces"
+	CacheClients     = "clients"
+	CacheProjects    = "projects"
+	CacheTasks       = "tasks"
+	CacheTags        = "tags"
+	CacheTimeEntries = "time_entries"
+)
+
+// DefaultCacheTTL is the time cached account data is served without being
+// refreshed when a Cache is created with a zero TTL.
+const DefaultCacheTTL = 5 * time.Minute
+
+// Cache is a local, file-backed store of a user's account data. A session
+// using a cache serves account lookups from it while the data is fresh,
+// refreshes it incrementally once it has expired or been invalidated by a
+// mutation, and falls back to the cached data when the Toggl API can't be
+// reached.
+type Cache struct {
+	path  string
+	ttl   time.Duration
+	mu    sync.Mutex
+	state *cacheState
+}
+
+// cacheState is the persisted form of a Cache.
+type cacheState struct {
+	Account Account         `json:"account"`
+	Updated time.Time       `json:"updated"`
+	Stale   map[string]bool `json:"stale,omitempty"`
+}
+
+// NewCache creates a cache stored in the file at path. Cached data is served
+// for up to ttl before being refreshed.
+func NewCache(path string, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Cache{path: path, ttl: ttl}
+}
+
+// DefaultCachePath returns the path of the account cache in the user's cache
+// directory.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-toggl", "account.json"), nil
+}
+
+// UseCache makes a session serve account data from the given cache. Passing
+// nil disables caching.
+func (session *Session) UseCache(cache *Cache) {
+	session.cache = cache
+}
+
+// Invalidate marks the given kinds of entities as stale, so that the next
+// read refreshes them from the server. Invalidating without any kinds marks
+// the whole cache as stale.
+func (c *Cache) Invalidate(kinds ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.load()
+	if len(kinds) == 0 {
+		state.Updated = time.Time{}
+	}
+	for _, kind := range kinds {
+		if state.Stale == nil {
+			state.Stale = make(map[string]bool)
+		}
+		state.Stale[kind] = true
+	}
+
+	if err := c.save(); err != nil {
+		dlog.Printf("Error saving cache: %v", err)
+	}
+}
+
+// Clear removes all cached data.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = &cacheState{}
+	err := os.Remove(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Updated returns the time the cached data was last refreshed from the server.
+func (c *Cache) Updated() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.load().Updated
+}
+
+// Cached returns the cached account data, however old it is, without
+// contacting the server. It returns false if nothing has been cached.
+func (c *Cache) Cached() (Account, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := c.load()
+	return state.Account, state.Account.Data.ID != 0
+}
+
+// account returns the cached account for a session, refreshing it first if
+// it has expired or been invalidated.
+func (c *Cache) account(session *Session) (Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.load()
+	cached := state.Account.Data.ID != 0 && state.Account.Data.APIToken == session.APIToken
+	if cached && len(state.Stale) == 0 && time.Since(state.Updated) < c.ttl {
+		return state.Account, nil
+	}
+
+	since := 0
+	if cached {
+		since = state.Account.Since
+	}
+
+	fresh, err := session.fetchAccount(since)
+	if err != nil {
+		if _, ok := err.(*url.Error); ok && cached {
+			dlog.Printf("Serving cached account data: %v", err)
+			return state.Account, nil
+		}
+		return Account{}, err
+	}
+
+	if since > 0 {
+		state.Account = mergeAccount(state.Account, fresh)
+	} else {
+		state.Account = fresh
+	}
+	state.Updated = time.Now()
+	state.Stale = nil
+
+	if err = c.save(); err != nil {
+		dlog.Printf("Error saving cache: %v", err)
+	}
+
+	return state.Account, nil
+}
+
+// load returns the cache state, reading it from disk the first time it's
+// needed. A missing or unreadable cache file results in an empty state.
+func (c *Cache) load() *cacheState {
+	if c.state != nil {
+		return c.state
+	}
+
+	c.state = &cacheState{}
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			dlog.Printf("Error reading cache: %v", err)
+		}
+		return c.state
+	}
+
+	if err = json.Unmarshal(data, c.state); err != nil {
+		dlog.Printf("Ignoring invalid cache: %v", err)
+		c.state = &cacheState{}
+	}
+	return c.state
+}
+
+// save writes the cache state to disk.
+func (c *Cache) save() error {
+	data, err := json.Marshal(c.state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data)
+}
+
+// writeFileAtomic writes data to a private file at path, replacing any
+// existing file atomically so that readers never see a partial write.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// invalidate marks entities of the given kinds as stale in the session's
+// cache, if it has one.
+func (session *Session) invalidate(kinds ...string) {
+	if session.cache != nil && len(kinds) > 0 {
+		session.cache.Invalidate(kinds...)
+	}
+}
+
+// mergeAccount applies an incremental account fetch to a cached account.
+// Entities in the update replace cached entities with the same ID, and
+// entities marked as deleted on the server are removed. The cached account's
+// slices are never modified in place.
+func mergeAccount(cached, update Account) Account {
+	merged := update
+	data, old := &merged.Data, &cached.Data
+
+	data.Workspaces = make([]Workspace, 0, len(old.Workspaces))
+	for _, w := range old.Workspaces {
+		if _, ok := findWorkspace(update.Data.Workspaces, w.ID); !ok {
+			data.Workspaces = append(data.Workspaces, w)
+		}
+	}
+	for _, w := range update.Data.Workspaces {
+		if w.ServerDeletedAt == nil {
+			data.Workspaces = append(data.Workspaces, w)
+		}
+	}
+
+	data.Clients = make([]Client, 0, len(old.Clients))
+	for _, c := range old.Clients {
+		if _, ok := findClient(update.Data.Clients, c.ID); !ok {
+			data.Clients = append(data.Clients, c)
+		}
+	}
+	for _, c := range update.Data.Clients {
+		if c.ServerDeletedAt == nil {
+			data.Clients = append(data.Clients, c)
+		}
+	}
+
+	data.Projects = make([]Project, 0, len(old.Projects))
+	for _, p := range old.Projects {
+		if _, ok := findProject(update.Data.Projects, p.ID); !ok {
+			data.Projects = append(data.Projects, p)
+		}
+	}
+	for _, p := range update.Data.Projects {
+		if p.ServerDeletedAt == nil {
+			data.Projects = append(data.Projects, p)
+		}
+	}
+
+	data.Tasks = make([]Task, 0, len(old.Tasks))
+	for _, t := range old.Tasks {
+		if _, ok := findTask(update.Data.Tasks, t.ID); !ok {
+			data.Tasks = append(data.Tasks, t)
+		}
+	}
+	for _, t := range update.Data.Tasks {
+		if t.ServerDeletedAt == nil {
+			data.Tasks = append(data.Tasks, t)
+		}
+	}
+
+	data.Tags = make([]Tag, 0, len(old.Tags))
+	for _, t := range old.Tags {
+		if _, ok := findTag(update.Data.Tags, t.ID); !ok {
+			data.Tags = append(data.Tags, t)
+		}
+	}
+	for _, t := range update.Data.Tags {
+		if t.ServerDeletedAt == nil {
+			data.Tags = append(data.Tags, t)
+		}
+	}
+
+	data.TimeEntries = make([]TimeEntry, 0, len(old.TimeEntries))
+	for _, e := range old.TimeEntries {
+		if _, ok := findTimeEntry(update.Data.TimeEntries, e.ID); !ok {
+			data.TimeEntries = append(data.TimeEntries, e)
+		}
+	}
+	for _, e := range update.Data.TimeEntries {
+		if e.ServerDeletedAt == nil {
+			data.TimeEntries = append(data.TimeEntries, e)
+		}
+	}
+
+	return merged
+}
+
+func findWorkspace(workspaces []Workspace, id int) (Workspace, bool) {
+	for _, w := range workspaces {
+		if w.ID == id {
+			return w, true
+		}
+	}
+	return Workspace{}, false
+}
+
+func findClient(clients []Client, id int) (Client, bool) {
+	for _, c := range clients {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Client{}, false
+}
+
+func findProject(projects []Project, id int) (Project, bool) {
+	for _, p := range projects {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Project{}, false
+}
+
+func findTask(tasks []Task, id int) (Task, bool) {
+	for _, t := range tasks {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Task{}, false
+}
+
+func findTag(tags []Tag, id int) (Tag, bool) {
+	for _, t := range tags {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Tag{}, false
+}
+
+func findTimeEntry(entries []TimeEntry, id int) (TimeEntry, bool) {
+	for _, e := range entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return TimeEntry{}, false
+}
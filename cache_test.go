@@ -0,0 +1,213 @@
+package toggl
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	responses := map[string]string{
+		"":    `{"since": 100, "data": {"id": 1, "api_token": "token", "projects": [{"id": 10, "name": "Website"}]}}`,
+		"100": `{"since": 200, "data": {"id": 1, "api_token": "token", "projects": [{"id": 11, "name": "App"}]}}`,
+		"200": `{"since": 300, "data": {"id": 1, "api_token": "token"}}`,
+	}
+	var requested []string
+	var failure error
+	saved := client
+	defer func() { client = saved }()
+	client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if failure != nil {
+			return nil, failure
+		}
+		since := req.URL.Query().Get("since")
+		requested = append(requested, since)
+		return &http.Response{StatusCode: 200, Status: "200 OK", Body: ioutil.NopCloser(bytes.NewReader([]byte(responses[since])))}, nil
+	})}
+	DisableLog()
+
+	path := filepath.Join(t.TempDir(), "cache", "account.json")
+	session := OpenSession("token")
+	session.UseCache(NewCache(path, time.Hour))
+	projects := func() (names []string) {
+		account, err := session.GetAccount()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range account.Data.Projects {
+			names = append(names, p.Name)
+		}
+		return
+	}
+	check := func(step string, got []string, want ...string) {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: projects %q, want %q", step, got, want)
+		}
+	}
+
+	check("first read", projects(), "Website")
+	check("fresh read", projects(), "Website")
+	if want := []string{""}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested %q while fresh, want %q", requested, want)
+	}
+
+	// Another cache on the same file reads it rather than the server
+	session.UseCache(NewCache(path, time.Hour))
+	check("reloaded read", projects(), "Website")
+	if len(requested) != 1 {
+		t.Errorf("requested %q after reloading", requested)
+	}
+
+	// Once expired, only the changes are requested
+	session.cache.state.Updated = time.Now().Add(-2 * time.Hour)
+	check("expired read", projects(), "Website", "App")
+	session.cache.Invalidate(CacheProjects)
+	check("invalidated read", projects(), "Website", "App")
+	if want := []string{"", "100", "200"}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested %q, want %q", requested, want)
+	}
+
+	// The API can't be reached, so the cached data is served however old
+	session.cache.Invalidate()
+	failure = errors.New("network is down")
+	check("offline read", projects(), "Website", "App")
+
+	// Errors other than the network's aren't hidden
+	failure = nil
+	responses["300"] = `{"since": "soon"}`
+	if _, err := session.GetAccount(); err == nil {
+		t.Error("invalid response served from the cache")
+	}
+
+	// Nothing is cached for another user
+	failure = errors.New("network is down")
+	other := OpenSession("other")
+	other.UseCache(session.cache)
+	if _, err := other.GetAccount(); err == nil {
+		t.Error("another user's account served from the cache")
+	}
+
+	if err := session.cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("cache file after Clear: %v", err)
+	}
+	if _, ok := session.cache.Cached(); ok {
+		t.Error("account cached after Clear")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "account.json")
+	if err := ioutil.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(path); err != nil || string(data) != "new" {
+		t.Errorf("read %q, %v", data, err)
+	}
+	// The file is replaced rather than rewritten, so it's private
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file mode %v, %v", info.Mode(), err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("%d files left behind", len(files))
+	}
+
+	// A write that can't replace the file, here a directory, leaves nothing
+	// behind
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "child"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(blocked, []byte("new")); err == nil {
+		t.Error("replaced a directory")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 2 {
+		t.Errorf("%d files left behind after a failed write", len(files))
+	}
+}
+
+func TestMergeAccount(t *testing.T) {
+	deleted := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	var cached, update Account
+	cached.Since = 100
+	cached.Data.Projects = []Project{{ID: 10, Name: "Website"}, {ID: 11, Name: "Old"}, {ID: 12, Name: "App"}}
+	cached.Data.Tags = []Tag{{ID: 20, Name: "bug"}}
+	update.Since = 200
+	update.Data.Projects = []Project{{ID: 12, Name: "Mobile app"}, {ID: 11, Name: "Old", ServerDeletedAt: &deleted}, {ID: 13, Name: "API"}}
+	update.Data.TimeEntries = []TimeEntry{{ID: 30, Description: "Design"}}
+
+	merged := mergeAccount(cached, update)
+	var names []string
+	for _, p := range merged.Data.Projects {
+		names = append(names, p.Name)
+	}
+	if want := []string{"Website", "Mobile app", "API"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("merged projects %q, want %q", names, want)
+	}
+	if merged.Since != 200 || len(merged.Data.Tags) != 1 || len(merged.Data.TimeEntries) != 1 {
+		t.Errorf("merged %+v", merged)
+	}
+	if cached.Data.Projects[2].Name != "App" || len(cached.Data.Projects) != 3 {
+		t.Errorf("merging changed the cached projects to %+v", cached.Data.Projects)
+	}
+}
+
+func TestDeleteInvalidates(t *testing.T) {
+	status := 500
+	saved := client
+	defer func() { client = saved }()
+	client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+	})}
+	DisableLog()
+
+	session := OpenSession("token")
+	session.UseCache(NewCache(filepath.Join(t.TempDir(), "account.json"), time.Hour))
+	stale := func() []string {
+		var kinds []string
+		for _, kind := range []string{CacheProjects, CacheTasks, CacheTags, CacheTimeEntries} {
+			if session.cache.load().Stale[kind] {
+				kinds = append(kinds, kind)
+			}
+		}
+		return kinds
+	}
+
+	// A failed request leaves the cache as it was
+	if _, err := session.DeleteProject(Project{ID: 1}); err == nil {
+		t.Error("deleted a project despite the error")
+	}
+	if _, err := session.DeleteTag(Tag{ID: 1}); err == nil {
+		t.Error("deleted a tag despite the error")
+	}
+	if kinds := stale(); len(kinds) != 0 {
+		t.Errorf("%q stale after failed deletes", kinds)
+	}
+
+	status = 200
+	if _, err := session.DeleteProject(Project{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{CacheProjects, CacheTasks}; !reflect.DeepEqual(stale(), want) {
+		t.Errorf("%q stale after deleting a project, want %q", stale(), want)
+	}
+	if _, err := session.DeleteTag(Tag{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{CacheProjects, CacheTasks, CacheTags, CacheTimeEntries}; !reflect.DeepEqual(stale(), want) {
+		t.Errorf("%q stale after deleting a tag, want %q", stale(), want)
+	}
+}
@@ -0,0 +1,175 @@
+package toggl
+
+import (
+	"strings"
+	"time"
+)
+
+// Calendar describes how a user's time is divided into days and weeks, so
+// that entries can be grouped the same way the Toggl web UI groups them.
+type Calendar struct {
+	// Location is the time zone days are computed in. A nil Location means
+	// the local time zone.
+	Location *time.Location
+	// BeginningOfWeek is the first day of the week.
+	BeginningOfWeek time.Weekday
+	// DayBoundary is the time of day at which a new day begins, for example
+	// 4 * time.Hour for users who count work until 4 AM as part of the
+	// previous day. It must be less than 24 hours.
+	DayBoundary time.Duration
+}
+
+// Location returns the time zone configured in a user's Toggl profile. If the
+// account has no time zone, the local time zone is returned.
+func (a *Account) Location() (*time.Location, error) {
+	if a.Data.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(a.Data.Timezone)
+}
+
+// Calendar returns a calendar that uses the time zone and first day of the
+// week configured in a user's Toggl profile.
+func (a *Account) Calendar() (Calendar, error) {
+	loc, err := a.Location()
+	if err != nil {
+		return Calendar{}, err
+	}
+	return Calendar{Location: loc, BeginningOfWeek: time.Weekday(a.Data.BeginningOfWeek % 7)}, nil
+}
+
+// SetLocation sets the time zone the session uses to decide which day an
+// entry belongs to. By default the session uses the time zone from the user's
+// profile once the account has been retrieved, and the local time zone before
+// that.
+func (session *Session) SetLocation(loc *time.Location) {
+	session.location = loc
+}
+
+// Location returns the time zone the session uses to decide which day an
+// entry belongs to.
+func (session *Session) Location() *time.Location {
+	if session.location == nil {
+		return time.Local
+	}
+	return session.location
+}
+
+// DayStart returns the start of the day containing t.
+func (c Calendar) DayStart(t time.Time) time.Time {
+	t = t.In(c.loc())
+	y, m, d := t.Date()
+	start := c.dayAt(y, m, d)
+	if t.Before(start) {
+		start = c.dayAt(y, m, d-1)
+	}
+	return start
+}
+
+// NextDay returns the start of the day after the one containing t.
+func (c Calendar) NextDay(t time.Time) time.Time {
+	y, m, d := c.DayStart(t).Date()
+	return c.dayAt(y, m, d+1)
+}
+
+// WeekStart returns the start of the week containing t.
+func (c Calendar) WeekStart(t time.Time) time.Time {
+	day := c.DayStart(t)
+	y, m, d := day.Date()
+	offset := (int(day.Weekday()) - int(c.BeginningOfWeek) + 7) % 7
+	return c.dayAt(y, m, d-offset)
+}
+
+// MonthStart returns the start of the month containing t.
+func (c Calendar) MonthStart(t time.Time) time.Time {
+	y, m, _ := c.DayStart(t).Date()
+	return c.dayAt(y, m, 1)
+}
+
+// SameDay returns true if a and b fall on the same day.
+func (c Calendar) SameDay(a, b time.Time) bool {
+	return c.DayStart(a).Equal(c.DayStart(b))
+}
+
+// ParseDate parses today, yesterday, a weekday within the last week or a date
+// like 2006-01-02 into the start of that day. It returns false if word isn't
+// a date.
+func (c Calendar) ParseDate(word string, now time.Time) (time.Time, bool) {
+	today := c.DayStart(now)
+	switch word = strings.ToLower(word); word {
+	case "":
+		return time.Time{}, false
+	case "today":
+		return today, true
+	case "yesterday":
+		y, m, d := today.Date()
+		return c.dayAt(y, m, d-1), true
+	}
+
+	if weekday, ok := weekdays[word]; ok {
+		y, m, d := today.Date()
+		offset := (int(today.Weekday()) - int(weekday) + 7) % 7
+		return c.dayAt(y, m, d-offset), true
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", word, c.loc()); err == nil {
+		return c.dayAt(t.Date()), true
+	}
+	return time.Time{}, false
+}
+
+// Split splits a time entry that crosses one or more day boundaries into one
+// entry per day. The first entry keeps the original entry's ID; the others
+// have no ID, so they can be created as new entries. A running entry is
+// split up to the current time, and its last part remains running.
+func (c Calendar) Split(e TimeEntry) []TimeEntry {
+	if e.Start == nil {
+		return []TimeEntry{e}
+	}
+
+	now := time.Now()
+	start, end := bounds(&e, now)
+	var parts []TimeEntry
+
+	for {
+		part := e.Copy()
+		if len(parts) > 0 {
+			part.ID = 0
+		}
+
+		next := c.NextDay(start)
+		if !end.After(next) {
+			setBounds(&part, start.In(c.loc()), end.In(c.loc()), now)
+			return append(parts, part)
+		}
+
+		// Only the last part of a running entry keeps running
+		part.Duration = 0
+		setBounds(&part, start.In(c.loc()), next, now)
+		parts = append(parts, part)
+		start = next
+	}
+}
+
+// Normalize splits the given entries at day boundaries and converts their
+// times to the calendar's time zone.
+func (c Calendar) Normalize(entries []TimeEntry) []TimeEntry {
+	normalized := make([]TimeEntry, 0, len(entries))
+	for _, e := range entries {
+		normalized = append(normalized, c.Split(e)...)
+	}
+	return normalized
+}
+
+func (c Calendar) loc() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+	return c.Location
+}
+
+// dayAt returns the start of the given day. Dates are normalized the same way
+// time.Date normalizes them.
+func (c Calendar) dayAt(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, int(c.DayBoundary/time.Second), 0, c.loc())
+}
@@ -0,0 +1,121 @@
+package toggl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarDays(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		boundary  time.Duration
+		t         string
+		start     string
+		next      string
+		length    time.Duration
+		weekStart string
+	}{
+		// An ordinary Wednesday
+		{0, "2026-10-14T16:00:00-04:00", "2026-10-14T00:00:00-04:00", "2026-10-15T00:00:00-04:00", 24 * time.Hour, "2026-10-12T00:00:00-04:00"},
+		// Clocks go forward on March 8 and back on November 1
+		{0, "2026-03-08T12:00:00-04:00", "2026-03-08T00:00:00-05:00", "2026-03-09T00:00:00-04:00", 23 * time.Hour, "2026-03-02T00:00:00-05:00"},
+		{0, "2026-11-01T12:00:00-05:00", "2026-11-01T00:00:00-04:00", "2026-11-02T00:00:00-05:00", 25 * time.Hour, "2026-10-26T00:00:00-04:00"},
+		// With days starting at 4 AM, 2 AM belongs to the day before
+		{4 * time.Hour, "2026-10-14T02:00:00-04:00", "2026-10-13T04:00:00-04:00", "2026-10-14T04:00:00-04:00", 24 * time.Hour, "2026-10-12T04:00:00-04:00"},
+		{4 * time.Hour, "2026-10-14T04:00:00-04:00", "2026-10-14T04:00:00-04:00", "2026-10-15T04:00:00-04:00", 24 * time.Hour, "2026-10-12T04:00:00-04:00"},
+		// 1 AM on a Monday still belongs to Sunday, and so to the week before
+		{4 * time.Hour, "2026-10-19T01:00:00-04:00", "2026-10-18T04:00:00-04:00", "2026-10-19T04:00:00-04:00", 24 * time.Hour, "2026-10-12T04:00:00-04:00"},
+		{4 * time.Hour, "2026-03-08T03:30:00-04:00", "2026-03-07T04:00:00-05:00", "2026-03-08T04:00:00-04:00", 23 * time.Hour, "2026-03-02T04:00:00-05:00"},
+		{4 * time.Hour, "2026-11-01T03:30:00-05:00", "2026-10-31T04:00:00-04:00", "2026-11-01T04:00:00-05:00", 25 * time.Hour, "2026-10-26T04:00:00-04:00"},
+	}
+
+	for _, test := range tests {
+		c := Calendar{Location: newYork, BeginningOfWeek: time.Monday, DayBoundary: test.boundary}
+		tm := at(test.t)
+		start, next := c.DayStart(tm), c.NextDay(tm)
+		if !start.Equal(at(test.start)) || !next.Equal(at(test.next)) {
+			t.Errorf("day of %s with a %v boundary: %v to %v, want %s to %s", test.t, test.boundary, start, next, test.start, test.next)
+		}
+		if got := next.Sub(start); got != test.length {
+			t.Errorf("day of %s with a %v boundary lasts %v, want %v", test.t, test.boundary, got, test.length)
+		}
+		if got := c.WeekStart(tm); !got.Equal(at(test.weekStart)) {
+			t.Errorf("week of %s with a %v boundary starts %v, want %s", test.t, test.boundary, got, test.weekStart)
+		}
+		if !c.SameDay(tm, start) || c.SameDay(tm, next) {
+			t.Errorf("%s isn't on the same day as %v only", test.t, start)
+		}
+	}
+}
+
+func TestCalendarWeekStart(t *testing.T) {
+	// Sunday October 18 2026, as the week's first and last day
+	sunday := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		beginning time.Weekday
+		want      int
+	}{
+		{time.Sunday, 18},
+		{time.Monday, 12},
+		{time.Saturday, 17},
+		{time.Wednesday, 14},
+	}
+	for _, test := range tests {
+		c := Calendar{Location: time.UTC, BeginningOfWeek: test.beginning}
+		want := time.Date(2026, 10, test.want, 0, 0, 0, 0, time.UTC)
+		if got := c.WeekStart(sunday); !got.Equal(want) {
+			t.Errorf("week starting on %s: %v, want %v", test.beginning, got, want)
+		}
+	}
+
+	var a Account
+	a.Data.Timezone = "UTC"
+	a.Data.BeginningOfWeek = 1
+	if c, err := a.Calendar(); err != nil || c.BeginningOfWeek != time.Monday || c.Location != time.UTC {
+		t.Errorf("Account.Calendar = %+v, %v", c, err)
+	}
+}
+
+func TestCalendarSplit(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	c := Calendar{Location: newYork, DayBoundary: 4 * time.Hour}
+	start := time.Date(2026, 3, 7, 22, 0, 0, 0, newYork)
+	stop := time.Date(2026, 3, 9, 6, 0, 0, 0, newYork)
+	entry := TimeEntry{ID: 1, Description: "Migration", Start: &start, Stop: &stop, Duration: int64(stop.Sub(start) / time.Second)}
+
+	parts := c.Split(entry)
+	// The day from 4 AM on the 7th loses the hour clocks go forward at 2 AM,
+	// so 5 hours of it are left from 10 PM, then 24 hours and 2
+	want := []time.Duration{5 * time.Hour, 24 * time.Hour, 2 * time.Hour}
+	if len(parts) != len(want) {
+		t.Fatalf("split into %d parts, want %d", len(parts), len(want))
+	}
+	for i, part := range parts {
+		if got := time.Duration(part.Duration) * time.Second; got != want[i] || part.Stop.Sub(*part.Start) != want[i] {
+			t.Errorf("part %d lasts %v, want %v", i, got, want[i])
+		}
+		if (part.ID == 1) != (i == 0) {
+			t.Errorf("part %d has ID %d", i, part.ID)
+		}
+		if i > 0 && !part.Start.Equal(*parts[i-1].Stop) {
+			t.Errorf("part %d starts at %v, after a gap", i, part.Start)
+		}
+	}
+	if !parts[2].Stop.Equal(stop) {
+		t.Errorf("last part stops at %v, want %v", parts[2].Stop, stop)
+	}
+}
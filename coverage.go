@@ -0,0 +1,90 @@
+package toggl
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Coverage is a set of named intervals, such as the time entries of a day,
+// that tells how much of a time range they cover. Overlapping intervals are
+// counted once.
+type Coverage struct {
+	intervals []coverageInterval
+}
+
+type coverageInterval struct {
+	start, end time.Time
+	name       string
+}
+
+// NewCoverage returns the coverage of time entries, named "entry ID".
+// Deleted entries and entries without a start time are left out, and
+// running entries are treated as ending now.
+func NewCoverage(entries []TimeEntry, now time.Time) *Coverage {
+	c := &Coverage{}
+	for i := range entries {
+		e := &entries[i]
+		if e.ServerDeletedAt != nil || e.Start == nil {
+			continue
+		}
+		start, end := bounds(e, now)
+		c.Add(start, end, fmt.Sprintf("entry %d", e.ID))
+	}
+	return c
+}
+
+// Add adds a named interval.
+func (c *Coverage) Add(start, end time.Time, name string) {
+	c.intervals = append(c.intervals, coverageInterval{start, end, name})
+}
+
+// Covered returns how much of a time range the intervals cover.
+func (c *Coverage) Covered(start, end time.Time) time.Duration {
+	total, _ := c.cover(start, end)
+	return total
+}
+
+// CoveredBy returns the names of the intervals overlapping a time range, if
+// together they cover at least part of it, from 0 to 1. It returns nil if
+// they cover less.
+func (c *Coverage) CoveredBy(start, end time.Time, part float64) []string {
+	total, names := c.cover(start, end)
+	if len(names) == 0 || float64(total) < part*float64(end.Sub(start)) {
+		return nil
+	}
+	return names
+}
+
+// cover returns the length of the union of the intervals within a time
+// range, and the names of those overlapping it, in the order they start.
+func (c *Coverage) cover(start, end time.Time) (time.Duration, []string) {
+	var overlaps []coverageInterval
+	for _, i := range c.intervals {
+		if i.start.Before(end) && i.end.After(start) {
+			overlaps = append(overlaps, i)
+		}
+	}
+	sort.SliceStable(overlaps, func(a, b int) bool {
+		return overlaps[a].start.Before(overlaps[b].start)
+	})
+
+	var total time.Duration
+	var names []string
+	at := start
+	for _, i := range overlaps {
+		names = append(names, i.name)
+		from, to := i.start, i.end
+		if from.Before(at) {
+			from = at
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			total += to.Sub(from)
+			at = to
+		}
+	}
+	return total, names
+}
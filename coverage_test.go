@@ -0,0 +1,58 @@
+package toggl
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoverage(t *testing.T) {
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", "2026-10-12 "+clock)
+		return t
+	}
+	entry := func(id int, from, to string) TimeEntry {
+		start, stop := at(from), at(to)
+		return TimeEntry{ID: id, Start: &start, Stop: &stop, Duration: int64(stop.Sub(start) / time.Second)}
+	}
+	deleted := entry(4, "12:00", "13:00")
+	deleted.ServerDeletedAt = deleted.Start
+	running := TimeEntry{ID: 5, Start: timePtr(at("15:00")), Duration: -at("15:00").Unix()}
+
+	// Entries 1 and 2 overlap, covering 10:00 to 10:40 together
+	c := NewCoverage([]TimeEntry{
+		entry(1, "10:00", "10:30"),
+		entry(2, "10:10", "10:40"),
+		entry(3, "11:50", "12:20"),
+		deleted,
+		running,
+		{ID: 6},
+	}, at("16:00"))
+	c.Add(at("13:00"), at("13:30"), "the suggestion")
+
+	tests := []struct {
+		from, to string
+		covered  time.Duration
+		names    string
+	}{
+		{"10:00", "11:00", 40 * time.Minute, "entry 1, entry 2"},
+		{"10:00", "11:30", 40 * time.Minute, ""},
+		{"10:20", "10:30", 10 * time.Minute, "entry 1, entry 2"},
+		{"12:00", "13:00", 20 * time.Minute, ""},
+		{"12:00", "13:20", 40 * time.Minute, "entry 3, the suggestion"},
+		{"12:10", "13:40", 40 * time.Minute, ""},
+		{"13:00", "14:00", 30 * time.Minute, "the suggestion"},
+		{"15:30", "17:00", 30 * time.Minute, ""},
+		{"15:30", "16:30", 30 * time.Minute, "entry 5"},
+		{"08:00", "09:00", 0, ""},
+	}
+	for _, test := range tests {
+		from, to := at(test.from), at(test.to)
+		if got := c.Covered(from, to); got != test.covered {
+			t.Errorf("Covered(%s, %s) = %v, want %v", test.from, test.to, got, test.covered)
+		}
+		if got := strings.Join(c.CoveredBy(from, to, 0.5), ", "); got != test.names {
+			t.Errorf("CoveredBy(%s, %s) = %q, want %q", test.from, test.to, got, test.names)
+		}
+	}
+}
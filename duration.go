@@ -0,0 +1,161 @@
+package toggl
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DurationStyle selects how durations are formatted.
+type DurationStyle int
+
+// Duration styles.
+const (
+	// DurationClock formats durations as hours and minutes, e.g. "1:05".
+	DurationClock DurationStyle = iota
+	// DurationClockSeconds formats durations the way Toggl's timer does,
+	// e.g. "1:05:09".
+	DurationClockSeconds
+	// DurationDecimal formats durations as decimal hours, e.g. "1.09".
+	DurationDecimal
+	// DurationUnits formats durations with unit suffixes, e.g. "1h 5m".
+	DurationUnits
+)
+
+// durationUnits maps the unit names accepted by ParseDuration to the
+// suffixes understood by time.ParseDuration. Longer names come first so they
+// are replaced before their prefixes.
+var durationUnits = []struct{ name, suffix string }{
+	{"hours", "h"}, {"hour", "h"}, {"hrs", "h"}, {"hr", "h"},
+	{"minutes", "m"}, {"minute", "m"}, {"mins", "m"}, {"min", "m"},
+	{"seconds", "s"}, {"second", "s"}, {"secs", "s"}, {"sec", "s"},
+}
+
+// ParseDuration parses a duration typed by a user. It accepts clock notation
+// ("1:30", "1:30:15"), durations with units ("1h30m", "1h 30m", "90m",
+// "1.5h", "45 min") and bare numbers, which are taken as hours ("1.5").
+// Negative durations and those too long for a time.Duration are errors.
+func ParseDuration(s string) (time.Duration, error) {
+	input := strings.ToLower(strings.TrimSpace(s))
+	if input == "" {
+		return 0, fmt.Errorf("Empty duration")
+	}
+
+	if strings.Contains(input, ":") {
+		return parseClockDuration(input)
+	}
+
+	if n, err := strconv.ParseFloat(input, 64); err == nil || isRangeError(err) {
+		switch {
+		case n < 0:
+			return 0, fmt.Errorf("Negative duration %q", s)
+		case math.IsNaN(n):
+			return 0, fmt.Errorf("Invalid duration %q", s)
+		case n*float64(time.Hour) >= math.MaxInt64:
+			return 0, fmt.Errorf("Duration %q is too long", s)
+		}
+		return time.Duration(n * float64(time.Hour)).Round(time.Second), nil
+	}
+
+	for _, unit := range durationUnits {
+		input = strings.Replace(input, unit.name, unit.suffix, -1)
+	}
+	input = strings.Replace(input, " ", "", -1)
+
+	d, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid duration %q", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("Negative duration %q", s)
+	}
+	return d, nil
+}
+
+// parseClockDuration parses "h:mm" and "h:mm:ss" durations.
+func parseClockDuration(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("Invalid duration %q", s)
+	}
+
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if isRangeError(err) || (err == nil && int64(n) > int64(math.MaxInt64-d)/int64(units[i])) {
+			return 0, fmt.Errorf("Duration %q is too long", s)
+		}
+		if err != nil || n < 0 || (i > 0 && (n > 59 || len(part) != 2)) {
+			return 0, fmt.Errorf("Invalid duration %q", s)
+		}
+		d += time.Duration(n) * units[i]
+	}
+	return d, nil
+}
+
+// isRangeError returns true if a number couldn't be parsed because it's too
+// large.
+func isRangeError(err error) bool {
+	e, ok := err.(*strconv.NumError)
+	return ok && e.Err == strconv.ErrRange
+}
+
+// FormatDuration formats a duration in the given style. Durations are
+// truncated to whole seconds, or whole minutes for DurationClock.
+func FormatDuration(d time.Duration, style DurationStyle) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	d = d.Truncate(time.Second)
+	hours := int64(d / time.Hour)
+	minutes := int64(d/time.Minute) % 60
+	seconds := int64(d/time.Second) % 60
+
+	switch style {
+	case DurationClockSeconds:
+		return fmt.Sprintf("%s%d:%02d:%02d", sign, hours, minutes, seconds)
+	case DurationDecimal:
+		return fmt.Sprintf("%s%.2f", sign, d.Hours())
+	case DurationUnits:
+		var parts []string
+		if hours > 0 {
+			parts = append(parts, fmt.Sprintf("%dh", hours))
+		}
+		if minutes > 0 || (hours > 0 && seconds > 0) {
+			parts = append(parts, fmt.Sprintf("%dm", minutes))
+		}
+		if seconds > 0 && hours == 0 {
+			parts = append(parts, fmt.Sprintf("%ds", seconds))
+		}
+		if len(parts) == 0 {
+			return "0m"
+		}
+		return sign + strings.Join(parts, " ")
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, hours, minutes)
+}
+
+// LiveDuration returns the time tracked by an entry. For a running entry the
+// duration is computed from Toggl's convention of storing the negated start
+// time as the duration, so it keeps growing as time passes.
+func (e *TimeEntry) LiveDuration() time.Duration {
+	if e.IsRunning() {
+		if e.Start != nil {
+			return time.Since(*e.Start).Truncate(time.Second)
+		}
+		return time.Duration(time.Now().Unix()+e.Duration) * time.Second
+	}
+	return time.Duration(e.Duration) * time.Second
+}
+
+// FormatDuration formats the time tracked by an entry in the given style,
+// including the time elapsed so far for a running entry.
+func (e *TimeEntry) FormatDuration(style DurationStyle) string {
+	return FormatDuration(e.LiveDuration(), style)
+}
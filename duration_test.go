@@ -0,0 +1,109 @@
+package toggl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	h, m, s := time.Hour, time.Minute, time.Second
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"1:30", h + 30*m},
+		{"1:30:15", h + 30*m + 15*s},
+		{"0:05", 5 * m},
+		{"100:00", 100 * h},
+		{"1h30m", h + 30*m},
+		{"1h 30m", h + 30*m},
+		{"90m", 90 * m},
+		{"1.5h", h + 30*m},
+		{"45 min", 45 * m},
+		{"2 hours 5 minutes", 2*h + 5*m},
+		{"10s", 10 * s},
+		{" 1.5 ", h + 30*m},
+		{"0", 0},
+		{"0.001", 4 * s},
+		{"2562047:47:16", 2562047*h + 47*m + 16*s},
+	}
+	for _, test := range tests {
+		if got, err := ParseDuration(test.in); err != nil || got != test.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", test.in, got, err, test.want)
+		}
+	}
+
+	for _, in := range []string{
+		"", "abc", "1:5", "1:60", "1:30:15:00", "1:-5", "h1",
+		// Negative
+		"-1", "-1h", "-1:30", "-1e400",
+		// Too long to represent
+		"1e300", "inf", "3000000", "2562048:00", "2562047:47:17", "99999999999999999999:00", "9999999999h",
+		"nan",
+	} {
+		if got, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	d := time.Hour + 5*time.Minute + 9*time.Second + 500*time.Millisecond
+	tests := []struct {
+		d     time.Duration
+		style DurationStyle
+		want  string
+	}{
+		{d, DurationClock, "1:05"},
+		{d, DurationClockSeconds, "1:05:09"},
+		{d, DurationDecimal, "1.09"},
+		{d, DurationUnits, "1h 5m"},
+		{5*time.Minute + 9*time.Second, DurationUnits, "5m 9s"},
+		{2 * time.Hour, DurationUnits, "2h"},
+		{0, DurationUnits, "0m"},
+		{0, DurationClock, "0:00"},
+		{100 * time.Hour, DurationClockSeconds, "100:00:00"},
+		{-d, DurationClock, "-1:05"},
+		{-d, DurationClockSeconds, "-1:05:09"},
+		{-d, DurationDecimal, "-1.09"},
+		{-d, DurationUnits, "-1h 5m"},
+	}
+	for _, test := range tests {
+		if got := FormatDuration(test.d, test.style); got != test.want {
+			t.Errorf("FormatDuration(%v, %d) = %s, want %s", test.d, test.style, got, test.want)
+		}
+	}
+}
+
+func TestDurationRoundTrip(t *testing.T) {
+	durations := []time.Duration{
+		0,
+		59 * time.Second,
+		time.Minute,
+		time.Hour + 5*time.Minute + 9*time.Second,
+		23*time.Hour + 59*time.Minute + 59*time.Second,
+		1000 * time.Hour,
+	}
+	tests := []struct {
+		style DurationStyle
+		// precision is what the style keeps of a duration
+		precision time.Duration
+	}{
+		{DurationClock, time.Minute},
+		{DurationClockSeconds, time.Second},
+		{DurationUnits, time.Second},
+	}
+	for _, test := range tests {
+		for _, d := range durations {
+			want := d.Truncate(test.precision)
+			// Units drop the seconds of durations of an hour or more
+			if test.style == DurationUnits && d >= time.Hour {
+				want = d.Truncate(time.Minute)
+			}
+			s := FormatDuration(d, test.style)
+			if got, err := ParseDuration(s); err != nil || got != want {
+				t.Errorf("%v formatted in style %d as %s parses as %v, %v, want %v", d, test.style, s, got, err, want)
+			}
+		}
+	}
+}
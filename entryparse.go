@@ -0,0 +1,435 @@
+package toggl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// AmbiguityError is returned when a name in a quick entry matches more than
+// one project or tag, so that the user can be asked to be more specific.
+type AmbiguityError struct {
+	// Kind is "project" or "tag".
+	Kind    string
+	Name    string
+	Matches []string
+}
+
+// Error lists the candidates the name matched.
+func (e *AmbiguityError) Error() string {
+	return fmt.Sprintf("%s %q is ambiguous: %s", e.Kind, e.Name, strings.Join(e.Matches, ", "))
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday,
+	"wednesday": time.Wednesday, "thursday": time.Thursday,
+	"friday": time.Friday, "saturday": time.Saturday,
+}
+
+// ParseEntry parses a quick entry such as
+//
+//	yesterday 14:00-15:30 Fix login bug @Acme/Website #bug #billable
+//
+// into a time entry. The entry starts with an optional date ("today",
+// "yesterday", a weekday name for the most recent such day, or 2006-01-02)
+// followed by an optional time: a range ("14:00-15:30"), a start time with an
+// optional duration ("14:00 1h30m"), a start time alone for a running entry,
+// or a duration alone for an entry ending now. Without a time the entry
+// starts running now.
+//
+// The remaining words form the description, except that "@Project" or
+// "@Client/Project" selects a project, "#tag" adds a tag and "#billable"
+// marks the entry as billable. Names containing spaces can be quoted, as in
+// @"Acme Corp/New website", and so can a tag named billable, as #"billable".
+// A word starting with a backslash is taken literally, and \" is a quote
+// rather than the start or end of a quoted section. Projects and tags are
+// matched case-insensitively against the account; an *AmbiguityError is
+// returned when a name matches several of them, or when a project name only
+// matches projects fuzzily, so that the user can pick one rather than have it
+// guessed. Tags not in the account are kept as given, since Toggl creates them
+// on use.
+// Dates and times are interpreted in the account's time zone.
+func (a *Account) ParseEntry(input string, now time.Time) (TimeEntry, error) {
+	var entry TimeEntry
+
+	calendar, err := a.Calendar()
+	if err != nil {
+		return entry, err
+	}
+	loc := calendar.loc()
+	now = now.In(loc)
+
+	words, err := splitEntryWords(input)
+	if err != nil {
+		return entry, err
+	}
+
+	// Leading date and time
+	day, dated := calendar.ParseDate(peekWord(words), now)
+	if dated {
+		words = words[1:]
+	} else {
+		day = calendar.DayStart(now)
+	}
+
+	var start, stop time.Time
+	var running, timed bool
+	if word := peekWord(words); word != "" {
+		if from, to, ok, err := parseEntryRange(word, day); err != nil {
+			return entry, err
+		} else if ok {
+			start, stop, timed = from, to, true
+			running = to.IsZero()
+			words = words[1:]
+		} else if d, ok := parseEntryDuration(word); ok {
+			if dated && !calendar.SameDay(day, now) {
+				return entry, fmt.Errorf("A duration on another day needs a start time")
+			}
+			stop = now
+			start, timed = now.Add(-d), true
+			words = words[1:]
+		}
+	}
+	if timed && running {
+		if d, ok := parseEntryDuration(peekWord(words)); ok {
+			stop, running = start.Add(d), false
+			words = words[1:]
+		}
+	}
+	if !timed {
+		if dated && !calendar.SameDay(day, now) {
+			return entry, fmt.Errorf("An entry on another day needs a start time")
+		}
+		start, running = now, true
+	}
+
+	// Description, project and tags
+	var description []string
+	var projectName string
+	var tags []string
+	for _, word := range words {
+		switch {
+		case word.literal:
+			description = append(description, word.text)
+		case strings.HasPrefix(word.text, "@"):
+			if projectName != "" {
+				return entry, fmt.Errorf("More than one project given")
+			}
+			projectName = word.text[1:]
+		case strings.HasPrefix(word.text, "#"):
+			if strings.EqualFold(word.text, "#billable") && !word.quoted {
+				entry.Billable = 1
+			} else {
+				tags = append(tags, word.text[1:])
+			}
+		default:
+			description = append(description, word.text)
+		}
+	}
+	entry.Description = strings.Join(description, " ")
+
+	if len(a.Data.Workspaces) == 1 {
+		entry.Wid = a.Data.Workspaces[0].ID
+	}
+	if projectName != "" {
+		project, err := a.findProject(projectName, fuzzyReport)
+		if err != nil {
+			return entry, err
+		}
+		entry.Pid = project.ID
+		entry.Wid = project.Wid
+	}
+	if err := a.AddTags(&entry, tags); err != nil {
+		return entry, err
+	}
+
+	entry.Start = &start
+	if running {
+		entry.Duration = -start.Unix()
+	} else {
+		if !stop.After(start) {
+			return entry, fmt.Errorf("Entry must end after it starts")
+		}
+		entry.Stop = &stop
+		entry.Duration = int64(stop.Sub(start) / time.Second)
+	}
+	return entry, nil
+}
+
+// FormatEntry formats a time entry in the syntax accepted by ParseEntry, so
+// that it parses back into an equivalent entry.
+func (a *Account) FormatEntry(entry TimeEntry, now time.Time) string {
+	var words []string
+
+	calendar, err := a.Calendar()
+	if err != nil {
+		calendar = Calendar{}
+	}
+	loc := calendar.loc()
+
+	if entry.Start != nil {
+		start := entry.Start.In(loc)
+		day := calendar.DayStart(start)
+		today := calendar.DayStart(now)
+		switch {
+		case day.Equal(today):
+			words = append(words, "today")
+		case day.Equal(calendar.DayStart(today.Add(-time.Hour))):
+			words = append(words, "yesterday")
+		default:
+			words = append(words, day.Format("2006-01-02"))
+		}
+
+		if entry.IsRunning() || (entry.Stop == nil && entry.Duration <= 0) {
+			words = append(words, start.Format("15:04"))
+		} else if entry.Stop == nil {
+			// Entries with only a duration
+			d := time.Duration(entry.Duration) * time.Second
+			words = append(words, start.Format("15:04"), formatEntryDuration(d))
+		} else {
+			stop := entry.Stop.In(loc)
+			if start.Second() == 0 && stop.Second() == 0 && stop.Sub(start) < 24*time.Hour {
+				words = append(words, start.Format("15:04")+"-"+stop.Format("15:04"))
+			} else {
+				words = append(words, start.Format("15:04"), formatEntryDuration(stop.Sub(start)))
+			}
+		}
+	}
+
+	for i, word := range strings.Fields(entry.Description) {
+		word = strings.Replace(word, `"`, `\"`, -1)
+		if strings.ContainsAny(word[:1], `@#\`) || (i == 0 && isEntryTimeWord(word, calendar, now)) {
+			word = `\` + word
+		}
+		words = append(words, word)
+	}
+
+	if entry.Pid != 0 {
+		for _, p := range a.Data.Projects {
+			if p.ID != entry.Pid {
+				continue
+			}
+			words = append(words, "@"+QuoteEntryName(a.ProjectTitle(p)))
+		}
+	}
+	for _, tag := range entry.Tags {
+		if strings.EqualFold(tag, "billable") {
+			// Quoted so that it isn't read as the billable flag
+			words = append(words, `#"`+tag+`"`)
+			continue
+		}
+		words = append(words, "#"+QuoteEntryName(tag))
+	}
+	if entry.Billable != 0 {
+		words = append(words, "#billable")
+	}
+
+	return strings.Join(words, " ")
+}
+
+// support /////////////////////////////////////////////////////////////
+
+// entryWord is a word of a quick entry. Literal words were escaped with a
+// backslash and are always part of the description. Quoted words had a
+// quoted section.
+type entryWord struct {
+	text    string
+	literal bool
+	quoted  bool
+}
+
+func peekWord(words []entryWord) string {
+	if len(words) == 0 || words[0].literal {
+		return ""
+	}
+	return words[0].text
+}
+
+// splitEntryWords splits a quick entry into words, keeping quoted sections
+// together and removing the quotes. A backslash before a quote escapes it.
+func splitEntryWords(input string) (words []entryWord, err error) {
+	var current strings.Builder
+	var quoted, inWord, hadQuotes bool
+
+	flush := func() {
+		if inWord {
+			text := current.String()
+			if strings.HasPrefix(text, `\`) {
+				words = append(words, entryWord{text: text[1:], literal: true, quoted: hadQuotes})
+			} else {
+				words = append(words, entryWord{text: text, quoted: hadQuotes})
+			}
+		}
+		current.Reset()
+		inWord, hadQuotes = false, false
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes) && runes[i+1] == '"':
+			current.WriteRune('"')
+			inWord = true
+			i++
+		case r == '"':
+			quoted = !quoted
+			inWord, hadQuotes = true, true
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("Unterminated quote in %q", input)
+	}
+	flush()
+	return words, nil
+}
+
+// QuoteEntryName quotes a project or tag name for a quick entry if it
+// contains spaces or quotes, as in @"Acme Corp/Website", escaping its quotes.
+func QuoteEntryName(name string) string {
+	if strings.IndexFunc(name, unicode.IsSpace) == -1 && !strings.Contains(name, `"`) {
+		return name
+	}
+	return `"` + strings.Replace(name, `"`, `\"`, -1) + `"`
+}
+
+// parseEntryRange parses "15:04-15:04", "15:04-" or "15:04" on the given day.
+// A missing end means the entry is running; an end before the start means the
+// entry ends on the next day.
+func parseEntryRange(word string, day time.Time) (start, stop time.Time, ok bool, err error) {
+	from, to := word, ""
+	ranged := false
+	if i := strings.Index(word, "-"); i > 0 {
+		from, to, ranged = word[:i], word[i+1:], true
+	}
+
+	h, m, valid := parseClock(from)
+	if !valid {
+		return start, stop, false, nil
+	}
+	y, mo, d := day.Date()
+	start = time.Date(y, mo, d, h, m, 0, 0, day.Location())
+
+	if !ranged || to == "" {
+		return start, stop, true, nil
+	}
+	h, m, valid = parseClock(to)
+	if !valid {
+		return start, stop, false, fmt.Errorf("Invalid time range %q", word)
+	}
+	stop = time.Date(y, mo, d, h, m, 0, 0, day.Location())
+	if !stop.After(start) {
+		stop = time.Date(y, mo, d+1, h, m, 0, 0, day.Location())
+	}
+	return start, stop, true, nil
+}
+
+// parseClock parses a time of day like "9:05" or "14:00".
+func parseClock(s string) (hour, minute int, ok bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || len(parts[1]) != 2 {
+		return 0, 0, false
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, false
+	}
+	minute, err = strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// parseEntryDuration parses a duration word. Only durations with units are
+// accepted, so that numbers and times in descriptions aren't mistaken for
+// durations.
+func parseEntryDuration(word string) (time.Duration, bool) {
+	if word == "" || !unicode.IsDigit(rune(word[0])) || strings.Contains(word, ":") ||
+		strings.IndexFunc(word, unicode.IsLetter) == -1 {
+		return 0, false
+	}
+	d, err := ParseDuration(word)
+	if err != nil || d == 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// formatEntryDuration formats a duration as a single word that
+// parseEntryDuration parses back exactly, such as "1h30m" or "25h0m15s".
+func formatEntryDuration(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	var word string
+	if h := d / time.Hour; h > 0 {
+		word = fmt.Sprintf("%dh", h)
+	}
+	if m := d / time.Minute % 60; m > 0 || (word != "" && d%time.Minute != 0) {
+		word += fmt.Sprintf("%dm", m)
+	}
+	if s := d / time.Second % 60; s > 0 || word == "" {
+		word += fmt.Sprintf("%ds", s)
+	}
+	return word
+}
+
+// isEntryTimeWord returns true if a word would be taken as a date, time or
+// duration at the start of a quick entry.
+func isEntryTimeWord(word string, calendar Calendar, now time.Time) bool {
+	if _, ok := calendar.ParseDate(word, now); ok {
+		return true
+	}
+	if _, _, ok, err := parseEntryRange(word, now); ok || err != nil {
+		return true
+	}
+	_, ok := parseEntryDuration(word)
+	return ok
+}
+
+// ResolveTag returns the account's spelling of a tag, matching it exactly or
+// ignoring case. A non-zero wid limits the search to the tags of a
+// workspace. Unknown tags are returned unchanged, since Toggl creates them on
+// use, and an *AmbiguityError is returned if the name matches several tags
+// that differ only in case.
+func (a *Account) ResolveTag(name string, wid int) (string, error) {
+	var matches []string
+	for _, t := range a.Data.Tags {
+		if t.ServerDeletedAt != nil || (wid != 0 && t.Wid != wid) {
+			continue
+		}
+		if t.Name == name {
+			return t.Name, nil
+		}
+		if strings.EqualFold(t.Name, name) && indexOfTag(t.Name, matches) == -1 {
+			matches = append(matches, t.Name)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return name, nil
+	case 1:
+		return matches[0], nil
+	}
+	return "", &AmbiguityError{Kind: "tag", Name: name, Matches: matches}
+}
+
+// AddTags adds tags to an entry, spelled as by ResolveTag in the entry's
+// workspace.
+func (a *Account) AddTags(entry *TimeEntry, names []string) error {
+	for _, name := range names {
+		tag, err := a.ResolveTag(name, entry.Wid)
+		if err != nil {
+			return err
+		}
+		entry.AddTag(tag)
+	}
+	return nil
+}
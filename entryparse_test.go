@@ -0,0 +1,194 @@
+package toggl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testAccount returns an account in UTC with one workspace, a client and a
+// few projects and tags.
+func testAccount() *Account {
+	var a Account
+	a.Data.Timezone = "UTC"
+	a.Data.Workspaces = []Workspace{{ID: 1, Name: "Work"}}
+	a.Data.Clients = []Client{{Wid: 1, ID: 10, Name: "Acme Corp"}}
+	a.Data.Projects = []Project{
+		{Wid: 1, ID: 100, Cid: 10, Name: "Website", Active: true},
+		{Wid: 1, ID: 101, Name: "Internal", Active: true},
+		{Wid: 1, ID: 102, Name: `Say "Hi"`, Active: true},
+	}
+	a.Data.Tags = []Tag{
+		{Wid: 1, ID: 1000, Name: "bug"},
+		{Wid: 1, ID: 1001, Name: "code review"},
+		{Wid: 1, ID: 1002, Name: "billable"},
+	}
+	return &a
+}
+
+func TestParseEntry(t *testing.T) {
+	a := testAccount()
+	now := time.Date(2026, 10, 14, 16, 0, 0, 0, time.UTC) // a Wednesday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 10, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		in          string
+		start, stop time.Time // zero stop for a running entry
+		description string
+		pid         int
+		tags        []string
+		billable    bool
+	}{
+		{"Writing", now, time.Time{}, "Writing", 0, nil, false},
+		{"14:00-15:30 Fix bug @Internal #bug", at(14, 14, 0), at(14, 15, 30), "Fix bug", 101, []string{"bug"}, false},
+		{"yesterday 9:00 2h Review", at(13, 9, 0), at(13, 11, 0), "Review", 0, nil, false},
+		{"monday 23:00-01:00 Deploy", at(12, 23, 0), at(13, 1, 0), "Deploy", 0, nil, false},
+		{"1h30m Call", now.Add(-90 * time.Minute), now, "Call", 0, nil, false},
+		{"10:00 Standup", at(14, 10, 0), time.Time{}, "Standup", 0, nil, false},
+		{`10:00-11:00 Design @"Acme Corp/Website" #"code review" #billable`, at(14, 10, 0), at(14, 11, 0), "Design", 100, []string{"code review"}, true},
+		{`10:00-11:00 Invoices #"billable"`, at(14, 10, 0), at(14, 11, 0), "Invoices", 0, []string{"billable"}, false},
+		{`10:00-11:00 Invoices #BILLABLE`, at(14, 10, 0), at(14, 11, 0), "Invoices", 0, nil, true},
+		{`10:00-11:00 Buy 5\" screen`, at(14, 10, 0), at(14, 11, 0), `Buy 5" screen`, 0, nil, false},
+		{`10:00-11:00 \@home \#1 \\x`, at(14, 10, 0), at(14, 11, 0), `@home #1 \x`, 0, nil, false},
+		{`\10:00 meeting`, now, time.Time{}, "10:00 meeting", 0, nil, false},
+		{"10:00-11:00 Bugs #BUG #new", at(14, 10, 0), at(14, 11, 0), "Bugs", 0, []string{"bug", "new"}, false},
+	}
+
+	for _, test := range tests {
+		entry, err := a.ParseEntry(test.in, now)
+		if err != nil {
+			t.Errorf("ParseEntry(%q): %v", test.in, err)
+			continue
+		}
+		if entry.Start == nil || !entry.Start.Equal(test.start) {
+			t.Errorf("ParseEntry(%q) starts at %v, want %v", test.in, entry.Start, test.start)
+		}
+		if test.stop.IsZero() {
+			if !entry.IsRunning() || entry.Stop != nil {
+				t.Errorf("ParseEntry(%q) isn't running", test.in)
+			}
+		} else if entry.Stop == nil || !entry.Stop.Equal(test.stop) || entry.Duration != int64(test.stop.Sub(test.start)/time.Second) {
+			t.Errorf("ParseEntry(%q) stops at %v after %ds, want %v", test.in, entry.Stop, entry.Duration, test.stop)
+		}
+		if entry.Description != test.description || entry.Pid != test.pid || !reflect.DeepEqual(entry.Tags, test.tags) || (entry.Billable != 0) != test.billable {
+			t.Errorf("ParseEntry(%q) = %q @%d %q billable %v, want %q @%d %q billable %v", test.in,
+				entry.Description, entry.Pid, entry.Tags, entry.Billable != 0,
+				test.description, test.pid, test.tags, test.billable)
+		}
+		if entry.Wid != 1 {
+			t.Errorf("ParseEntry(%q) is in workspace %d", test.in, entry.Wid)
+		}
+	}
+}
+
+func TestParseEntryErrors(t *testing.T) {
+	a := testAccount()
+	now := time.Date(2026, 10, 14, 16, 0, 0, 0, time.UTC)
+	for _, in := range []string{
+		`Buy 5" screen`,
+		"10:00-1x:00 Typo",
+		"yesterday Review",
+		"yesterday 2h Review",
+		"Design @Website @Internal",
+		"Design @Nowhere",
+		"Design @Acme",
+	} {
+		if entry, err := a.ParseEntry(in, now); err == nil {
+			t.Errorf("ParseEntry(%q) = %+v, want an error", in, entry)
+		}
+	}
+
+	_, err := a.ParseEntry("Design @Acme", now)
+	if _, ok := err.(*AmbiguityError); !ok {
+		t.Errorf("ParseEntry with a fuzzy project = %v, want an *AmbiguityError", err)
+	}
+}
+
+func TestFormatEntry(t *testing.T) {
+	a := testAccount()
+	now := time.Date(2026, 10, 14, 16, 0, 0, 0, time.UTC)
+	at := func(day, hour, min int) *time.Time {
+		t := time.Date(2026, 10, day, hour, min, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		entry TimeEntry
+		want  string
+	}{
+		{
+			TimeEntry{Wid: 1, Pid: 100, Description: "Design", Start: at(14, 10, 0), Stop: at(14, 11, 0), Duration: 3600, Tags: []string{"code review"}, Billable: 1},
+			`today 10:00-11:00 Design @"Acme Corp/Website" #"code review" #billable`,
+		},
+		{
+			TimeEntry{Wid: 1, Description: "Invoices", Start: at(13, 10, 0), Stop: at(13, 11, 0), Duration: 3600, Tags: []string{"billable"}},
+			`yesterday 10:00-11:00 Invoices #"billable"`,
+		},
+		{
+			TimeEntry{Wid: 1, Description: `Buy 5" screen`, Start: at(1, 10, 0), Stop: at(1, 11, 0), Duration: 3600},
+			`2026-10-01 10:00-11:00 Buy 5\" screen`,
+		},
+		{
+			TimeEntry{Wid: 1, Description: "Reading", Start: at(14, 9, 0), Duration: 5400, DurOnly: true},
+			`today 09:00 1h30m Reading`,
+		},
+		{
+			TimeEntry{Wid: 1, Pid: 102, Description: "10:00 sync", Start: at(14, 15, 0), Duration: -at(14, 15, 0).Unix()},
+			`today 15:00 \10:00 sync @"Say \"Hi\""`,
+		},
+	}
+	for _, test := range tests {
+		if got := a.FormatEntry(test.entry, now); got != test.want {
+			t.Errorf("FormatEntry(%+v) = %s, want %s", test.entry, got, test.want)
+		}
+	}
+}
+
+func TestFormatEntryRoundTrip(t *testing.T) {
+	a := testAccount()
+	now := time.Date(2026, 10, 14, 16, 0, 0, 0, time.UTC)
+	at := func(day, hour, min int) *time.Time {
+		t := time.Date(2026, 10, day, hour, min, 0, 0, time.UTC)
+		return &t
+	}
+	stopped := func(start, stop *time.Time) TimeEntry {
+		return TimeEntry{Wid: 1, Start: start, Stop: stop, Duration: int64(stop.Sub(*start) / time.Second)}
+	}
+
+	var entries []TimeEntry
+	add := func(e TimeEntry, description string, pid int, tags []string, billable float32) {
+		e.Description, e.Pid, e.Tags, e.Billable = description, pid, tags, billable
+		entries = append(entries, e)
+	}
+	add(stopped(at(14, 10, 0), at(14, 11, 30)), "Design review", 100, []string{"bug", "code review"}, 1)
+	add(stopped(at(13, 22, 0), at(14, 1, 0)), "Deploy", 101, nil, 0)
+	add(stopped(at(2, 9, 0), at(3, 10, 15)), "Migration", 0, nil, 0)
+	add(TimeEntry{Wid: 1, Start: at(1, 8, 0), Stop: timePtr(at(1, 8, 0).Add(30*time.Hour + 15*time.Second)), Duration: 30*3600 + 15}, "Backup", 0, nil, 0)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), `Buy 5" screen`, 0, nil, 0)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), `Fix "login" page`, 0, nil, 0)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), `Quote \"escaped\" \x "`, 0, nil, 0)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), "@home #1 \\path", 0, nil, 0)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), "yesterday's notes", 0, nil, 0)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), "1h call", 0, nil, 0)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), "Invoices", 0, []string{"billable"}, 0)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), "Invoices", 0, []string{"billable"}, 1)
+	add(stopped(at(14, 10, 0), at(14, 11, 0)), "Greeting", 102, []string{`say "hi"`}, 0)
+	add(TimeEntry{Wid: 1, Start: at(14, 9, 0), Duration: 5400, DurOnly: true}, "Reading", 0, nil, 0)
+	add(TimeEntry{Wid: 1, Start: at(14, 15, 0), Duration: -at(14, 15, 0).Unix()}, "Writing", 101, []string{"bug"}, 0)
+
+	for _, entry := range entries {
+		formatted := a.FormatEntry(entry, now)
+		parsed, err := a.ParseEntry(formatted, now)
+		if err != nil {
+			t.Errorf("%+v formatted as %s: %v", entry, formatted, err)
+			continue
+		}
+		if !parsed.Start.Equal(*entry.Start) || parsed.Duration != entry.Duration || parsed.IsRunning() != entry.IsRunning() ||
+			parsed.Description != entry.Description || parsed.Pid != entry.Pid || parsed.Wid != entry.Wid ||
+			!reflect.DeepEqual(parsed.Tags, entry.Tags) || parsed.Billable != entry.Billable {
+			t.Errorf("%+v formatted as %s parses as %+v", entry, formatted, parsed)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Package export writes time entries in the formats of other tools, so tracked
+time can be seen alongside meetings or in plain-text accounting: iCalendar,
+with an event for every entry, Timewarrior data files, and the timeclock
+files of ledger and hledger.
+
+Entries are converted from TimeEntries, with names from an account, or from
+detailed report entries, and streamed to an io.Writer:
+
+	w, err := export.NewWriter(export.ICal, out, export.Options{})
+	for _, entry := range export.FromTimeEntries(&account, entries) {
+		w.Write(entry)
+	}
+	err = w.Close()
+*/
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Formats
+const (
+	ICal        = "ical"
+	Timewarrior = "timewarrior"
+	Timeclock   = "timeclock"
+)
+
+// Formats lists the formats entries can be written in.
+var Formats = []string{ICal, Timewarrior, Timeclock}
+
+// Entry is a time entry to export, with names rather than Toggl IDs.
+type Entry struct {
+	ID          int
+	Description string
+	Client      string
+	Project     string
+	Task        string
+	Tags        []string
+	Billable    bool
+	Start       time.Time
+	// Stop is zero for a running entry.
+	Stop time.Time
+	// Updated is when the entry last changed, if known.
+	Updated time.Time
+}
+
+// IsRunning returns true if the entry hasn't stopped.
+func (e Entry) IsRunning() bool {
+	return e.Stop.IsZero()
+}
+
+// ProjectTitle returns the entry's project name, prefixed with its client's
+// name and a slash if it has a client.
+func (e Entry) ProjectTitle() string {
+	if e.Client != "" && e.Project != "" {
+		return e.Client + "/" + e.Project
+	}
+	return e.Project
+}
+
+// FromTimeEntries converts time entries, taking the names of their projects,
+// clients and tasks from an account. Deleted entries are left out.
+func FromTimeEntries(account *toggl.Account, entries []toggl.TimeEntry) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		if e.ServerDeletedAt != nil || e.Start == nil {
+			continue
+		}
+		entry := Entry{
+			ID:          e.ID,
+			Description: e.Description,
+			Tags:        e.Tags,
+			Billable:    e.Billable != 0,
+			Start:       *e.Start,
+			Stop:        e.StopTime(),
+		}
+		if entry.Stop.IsZero() && !e.IsRunning() {
+			entry.Stop = entry.Start.Add(time.Duration(e.Duration) * time.Second)
+		}
+		if e.At != nil {
+			entry.Updated = *e.At
+		}
+
+		for _, p := range account.Data.Projects {
+			if p.ID == e.Pid {
+				entry.Project = p.Name
+				for _, c := range account.Data.Clients {
+					if c.ID == p.Cid {
+						entry.Client = c.Name
+					}
+				}
+			}
+		}
+		for _, t := range account.Data.Tasks {
+			if t.ID == e.Tid && e.Tid != 0 {
+				entry.Task = t.Name
+			}
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// FromDetailed converts the entries of a detailed report, which carry their
+// own names.
+func FromDetailed(entries []toggl.DetailedTimeEntry) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		if e.ServerDeletedAt != nil || e.Start == nil {
+			continue
+		}
+		entry := Entry{
+			ID:          e.ID,
+			Description: e.Description,
+			Client:      e.Client,
+			Project:     e.Project,
+			Task:        e.Task,
+			Tags:        e.Tags,
+			Billable:    e.IsBillable,
+			Start:       *e.Start,
+		}
+		if e.End != nil {
+			entry.Stop = *e.End
+		}
+		if e.Updated != nil {
+			entry.Updated = *e.Updated
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// Options controls how entries are written.
+type Options struct {
+	// Location is the time zone of formats with local times, such as
+	// timeclock. Nil means the local time zone.
+	Location *time.Location
+	// CalendarName names an iCalendar calendar.
+	CalendarName string
+	// Account is the timeclock account of entries without a project. It
+	// defaults to "unassigned".
+	Account string
+}
+
+// Writer writes entries in a format. Close finishes the output, but
+// doesn't close the underlying io.Writer.
+type Writer interface {
+	Write(entry Entry) error
+	Close() error
+}
+
+// NewWriter returns a Writer for one of Formats.
+func NewWriter(format string, w io.Writer, options Options) (Writer, error) {
+	switch format {
+	case ICal:
+		return NewICalWriter(w, options), nil
+	case Timewarrior:
+		return NewTimewarriorWriter(w), nil
+	case Timeclock:
+		return NewTimeclockWriter(w, options), nil
+	}
+	return nil, fmt.Errorf("Unknown export format %q", format)
+}
+
+// WriteAll writes entries in a format.
+func WriteAll(format string, w io.Writer, entries []Entry, options Options) error {
+	out, err := NewWriter(format, w, options)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := out.Write(entry); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+func at(hour, min int) time.Time {
+	return time.Date(2026, 10, 12, hour, min, 0, 0, time.UTC)
+}
+
+func testEntries() []Entry {
+	return []Entry{
+		{ID: 1, Description: "Fix login", Client: "Acme", Project: "Website", Tags: []string{"bug", "code review"}, Billable: true, Start: at(9, 0), Stop: at(10, 30), Updated: at(11, 0)},
+		{ID: 2, Description: "Say \"hi\"; now, then\nbye", Project: "Internal: ops", Task: "Meetings", Start: at(11, 0), Stop: at(11, 15)},
+		{Start: at(12, 0), Stop: at(12, 45)},
+		{ID: 4, Description: "Writing", Start: at(13, 0)},
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{ICal, "BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"PRODID:-//go-toggl//export//EN\r\n" +
+			"CALSCALE:GREGORIAN\r\n" +
+			"X-WR-CALNAME:Work\\, tracked\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:1@toggl.com\r\n" +
+			"DTSTAMP:20261012T110000Z\r\n" +
+			"DTSTART:20261012T090000Z\r\n" +
+			"DTEND:20261012T103000Z\r\n" +
+			"SUMMARY:Fix login\r\n" +
+			"CATEGORIES:Acme/Website,bug,code review\r\n" +
+			// Folded at 75 bytes
+			"DESCRIPTION:Project: Acme/Website\\nTags: bug\\, code review\\nDuration: 1:30\\\r\n" +
+			" nBillable\r\n" +
+			"TRANSP:TRANSPARENT\r\n" +
+			"END:VEVENT\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:2@toggl.com\r\n" +
+			"DTSTAMP:20261012T111500Z\r\n" +
+			"DTSTART:20261012T110000Z\r\n" +
+			"DTEND:20261012T111500Z\r\n" +
+			"SUMMARY:Say \"hi\"\\; now\\, then\\nbye\r\n" +
+			"CATEGORIES:Internal: ops\r\n" +
+			"DESCRIPTION:Project: Internal: ops\\nTask: Meetings\\nDuration: 0:15\r\n" +
+			"TRANSP:TRANSPARENT\r\n" +
+			"END:VEVENT\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:20261012T120000Z-811c9dc5@toggl.com\r\n" +
+			"DTSTAMP:20261012T124500Z\r\n" +
+			"DTSTART:20261012T120000Z\r\n" +
+			"DTEND:20261012T124500Z\r\n" +
+			"SUMMARY:(no description)\r\n" +
+			"DESCRIPTION:Duration: 0:45\r\n" +
+			"TRANSP:TRANSPARENT\r\n" +
+			"END:VEVENT\r\n" +
+			"END:VCALENDAR\r\n",
+		},
+		{Timewarrior, "inc 20261012T090000Z - 20261012T103000Z # Acme/Website bug \"code review\" # \"Fix login\"\n" +
+			"inc 20261012T110000Z - 20261012T111500Z # \"Internal: ops\" # \"Say \\\"hi\\\"; now, then bye\"\n" +
+			"inc 20261012T120000Z - 20261012T124500Z\n" +
+			"inc 20261012T130000Z # # \"Writing\"\n",
+		},
+		{Timeclock, "i 2026/10/12 09:00:00 Acme:Website  Fix login\n" +
+			"o 2026/10/12 10:30:00\n" +
+			"i 2026/10/12 11:00:00 Internal- ops  Say \"hi\"; now, then bye\n" +
+			"o 2026/10/12 11:15:00\n" +
+			"i 2026/10/12 12:00:00 unassigned\n" +
+			"o 2026/10/12 12:45:00\n" +
+			"i 2026/10/12 13:00:00 unassigned  Writing\n",
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WriteAll(test.format, &buf, testEntries(), Options{Location: time.UTC, CalendarName: "Work, tracked"}); err != nil {
+			t.Errorf("%s: %v", test.format, err)
+			continue
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.format, got, test.want)
+		}
+	}
+
+	if err := WriteAll("csv", &bytes.Buffer{}, testEntries(), Options{}); err == nil {
+		t.Error("wrote an unknown format")
+	}
+}
+
+func TestTimeclockLocation(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	var buf bytes.Buffer
+	entry := Entry{Project: "Website", Start: at(9, 0), Stop: at(10, 0)}
+	if err := WriteAll(Timeclock, &buf, []Entry{entry}, Options{Location: newYork, Account: "misc"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "i 2026/10/12 05:00:00 Website\no 2026/10/12 06:00:00\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestICalFolding(t *testing.T) {
+	var buf bytes.Buffer
+	description := strings.Repeat("Réunion café ", 12)
+	entry := Entry{ID: 1, Description: description, Start: at(9, 0), Stop: at(10, 0)}
+	if err := WriteAll(ICal, &buf, []Entry{entry}, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line of %d bytes: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a character: %q", line)
+		}
+	}
+	unfolded := strings.Replace(buf.String(), "\r\n ", "", -1)
+	if !strings.Contains(unfolded, "SUMMARY:"+description+"\r\n") {
+		t.Errorf("unfolded calendar doesn't contain the summary:\n%s", unfolded)
+	}
+}
+
+func TestFromTimeEntries(t *testing.T) {
+	var account toggl.Account
+	account.Data.Clients = []toggl.Client{{ID: 10, Name: "Acme"}}
+	account.Data.Projects = []toggl.Project{{ID: 100, Cid: 10, Name: "Website"}, {ID: 101, Name: "Internal"}}
+	account.Data.Tasks = []toggl.Task{{ID: 1000, Pid: 100, Name: "Login"}}
+
+	start, stop, updated := at(9, 0), at(10, 0), at(10, 5)
+	entries := []toggl.TimeEntry{
+		{ID: 1, Pid: 100, Tid: 1000, Description: "Fix", Start: &start, Stop: &stop, Duration: 3600, Billable: 1, Tags: []string{"bug"}, At: &updated},
+		{ID: 2, Pid: 101, Description: "Read", Start: &start, Duration: 1800, DurOnly: true},
+		{ID: 3, Description: "Write", Start: &start, Duration: -start.Unix()},
+		{ID: 4, Description: "Gone", Start: &start, Stop: &stop, Duration: 3600, ServerDeletedAt: &updated},
+		{ID: 5, Description: "No start"},
+	}
+	want := []Entry{
+		{ID: 1, Description: "Fix", Client: "Acme", Project: "Website", Task: "Login", Tags: []string{"bug"}, Billable: true, Start: start, Stop: stop, Updated: updated},
+		{ID: 2, Description: "Read", Project: "Internal", Start: start, Stop: at(9, 30)},
+		{ID: 3, Description: "Write", Start: start},
+	}
+	if got := FromTimeEntries(&account, entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromTimeEntries = %+v, want %+v", got, want)
+	}
+
+	detailed := []toggl.DetailedTimeEntry{
+		{ID: 1, Description: "Fix", Client: "Acme", Project: "Website", Task: "Login", IsBillable: true, Start: &start, End: &stop, Updated: &updated},
+		{ID: 4, Description: "Gone", Start: &start, End: &stop, ServerDeletedAt: &updated},
+	}
+	want = []Entry{{ID: 1, Description: "Fix", Client: "Acme", Project: "Website", Task: "Login", Billable: true, Start: start, Stop: stop, Updated: updated}}
+	if got := FromDetailed(detailed); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromDetailed = %+v, want %+v", got, want)
+	}
+}
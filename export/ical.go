@@ -0,0 +1,159 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// icalLayout is the layout of UTC date-times in iCalendar.
+const icalLayout = "20060102T150405Z"
+
+// ICalWriter writes entries as an iCalendar (RFC 5545) calendar with an
+// event for every entry. An event's categories are the entry's project and
+// tags. Events are transparent, so calendars don't show tracked time as
+// busy. Running entries are left out, as they have no end yet.
+type ICalWriter struct {
+	w       *bufio.Writer
+	options Options
+	started bool
+	err     error
+}
+
+// NewICalWriter returns a writer of an iCalendar calendar.
+func NewICalWriter(w io.Writer, options Options) *ICalWriter {
+	return &ICalWriter{w: bufio.NewWriter(w), options: options}
+}
+
+// Write writes an entry's event, starting the calendar with the first.
+func (c *ICalWriter) Write(e Entry) error {
+	c.start()
+	if e.IsRunning() {
+		return c.err
+	}
+
+	stamp := e.Updated
+	if stamp.IsZero() {
+		stamp = e.Stop
+	}
+	c.line("BEGIN:VEVENT")
+	c.line("UID:" + uid(e))
+	c.line("DTSTAMP:" + icalTime(stamp))
+	c.line("DTSTART:" + icalTime(e.Start))
+	c.line("DTEND:" + icalTime(e.Stop))
+
+	summary := e.Description
+	if summary == "" {
+		summary = e.ProjectTitle()
+	}
+	if summary == "" {
+		summary = "(no description)"
+	}
+	c.line("SUMMARY:" + escapeText(summary))
+
+	var categories, details []string
+	if project := e.ProjectTitle(); project != "" {
+		categories = append(categories, escapeText(project))
+		details = append(details, "Project: "+project)
+	}
+	if e.Task != "" {
+		details = append(details, "Task: "+e.Task)
+	}
+	for _, tag := range e.Tags {
+		categories = append(categories, escapeText(tag))
+	}
+	if len(e.Tags) > 0 {
+		details = append(details, "Tags: "+strings.Join(e.Tags, ", "))
+	}
+	details = append(details, "Duration: "+toggl.FormatDuration(e.Stop.Sub(e.Start), toggl.DurationClock))
+	if e.Billable {
+		details = append(details, "Billable")
+	}
+	if len(categories) > 0 {
+		c.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+	c.line("DESCRIPTION:" + escapeText(strings.Join(details, "\n")))
+	c.line("TRANSP:TRANSPARENT")
+	c.line("END:VEVENT")
+	return c.err
+}
+
+// Close ends the calendar.
+func (c *ICalWriter) Close() error {
+	c.start()
+	c.line("END:VCALENDAR")
+	if c.err != nil {
+		return c.err
+	}
+	return c.w.Flush()
+}
+
+// start writes the calendar's header, once.
+func (c *ICalWriter) start() {
+	if c.started {
+		return
+	}
+	c.started = true
+	c.line("BEGIN:VCALENDAR")
+	c.line("VERSION:2.0")
+	c.line("PRODID:-//go-toggl//export//EN")
+	c.line("CALSCALE:GREGORIAN")
+	if c.options.CalendarName != "" {
+		c.line("X-WR-CALNAME:" + escapeText(c.options.CalendarName))
+	}
+}
+
+// line writes a content line, folded into lines of at most 75 bytes.
+func (c *ICalWriter) line(s string) {
+	if c.err != nil {
+		return
+	}
+	limit := 75
+	for len(s) > limit {
+		// Don't split UTF-8 sequences
+		i := limit
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+		c.write(s[:i] + "\r\n ")
+		s = s[i:]
+		// Continuation lines start with a space
+		limit = 74
+	}
+	c.write(s + "\r\n")
+}
+
+func (c *ICalWriter) write(s string) {
+	if c.err == nil {
+		_, c.err = c.w.WriteString(s)
+	}
+}
+
+// uid returns an identifier of an entry's event that stays the same when
+// the entry is exported again.
+func uid(e Entry) string {
+	if e.ID != 0 {
+		return fmt.Sprintf("%d@toggl.com", e.ID)
+	}
+	h := fnv.New32a()
+	io.WriteString(h, e.Description)
+	return fmt.Sprintf("%s-%x@toggl.com", icalTime(e.Start), h.Sum32())
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// icalTime formats a time as an iCalendar UTC date-time.
+func icalTime(t time.Time) string {
+	return t.UTC().Format(icalLayout)
+}
@@ -0,0 +1,95 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// timeclockLayout is the layout of timeclock times, which are local.
+const timeclockLayout = "2006/01/02 15:04:05"
+
+// DefaultAccount is the timeclock account of entries without a project.
+const DefaultAccount = "unassigned"
+
+// TimeclockWriter writes entries in the timeclock format read by ledger and
+// hledger, as a clock-in and a clock-out line for every entry:
+//
+//	i 2026/10/12 09:00:00 Acme:Website  Fix login
+//	o 2026/10/12 10:30:00
+//
+// An entry's account is its client and project, separated by a colon. A
+// running entry is clocked in but not out. ledger expects entries in order
+// and not overlapping.
+type TimeclockWriter struct {
+	w       *bufio.Writer
+	options Options
+}
+
+// NewTimeclockWriter returns a writer of a timeclock file.
+func NewTimeclockWriter(w io.Writer, options Options) *TimeclockWriter {
+	if options.Location == nil {
+		options.Location = time.Local
+	}
+	if options.Account == "" {
+		options.Account = DefaultAccount
+	}
+	return &TimeclockWriter{w: bufio.NewWriter(w), options: options}
+}
+
+// Write writes an entry's clock-in and clock-out.
+func (t *TimeclockWriter) Write(e Entry) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "i %s %s", e.Start.In(t.options.Location).Format(timeclockLayout), t.account(e))
+	if description := oneLine(e.Description); description != "" {
+		// Two spaces end the account, which can contain single spaces
+		b.WriteString("  ")
+		b.WriteString(description)
+	}
+	b.WriteString("\n")
+	if !e.IsRunning() {
+		fmt.Fprintf(&b, "o %s\n", e.Stop.In(t.options.Location).Format(timeclockLayout))
+	}
+
+	_, err := t.w.WriteString(b.String())
+	return err
+}
+
+// Close flushes the entries written.
+func (t *TimeclockWriter) Close() error {
+	return t.w.Flush()
+}
+
+// account returns the account of an entry.
+func (t *TimeclockWriter) account(e Entry) string {
+	if e.Project == "" {
+		return t.options.Account
+	}
+	if e.Client == "" {
+		return accountName(e.Project)
+	}
+	return accountName(e.Client) + ":" + accountName(e.Project)
+}
+
+// accountName makes a name usable as a part of an account, whose parts are
+// separated by colons and which ends at two spaces.
+func accountName(s string) string {
+	s = strings.Replace(oneLine(s), ":", "-", -1)
+	for strings.Contains(s, "  ") {
+		s = strings.Replace(s, "  ", " ", -1)
+	}
+	return s
+}
+
+// oneLine replaces the line breaks and tabs of a string with spaces.
+func oneLine(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch r {
+		case '\n', '\r', '\t':
+			return ' '
+		}
+		return r
+	}, s))
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// timewarriorLayout is the layout of Timewarrior's UTC timestamps.
+const timewarriorLayout = "20060102T150405Z"
+
+// TimewarriorWriter writes entries as the intervals of a Timewarrior data
+// file, one to a line:
+//
+//	inc 20261012T090000Z - 20261012T103000Z # Acme/Website bug # "Fix login"
+//
+// Timewarrior only has tags, so an interval's tags are the entry's project
+// followed by its tags, and its annotation is the entry's description. A
+// running entry is an open interval. Timewarrior keeps a data file for each
+// month, such as ~/.timewarrior/data/2026-10.data, with its intervals in
+// order.
+type TimewarriorWriter struct {
+	w *bufio.Writer
+}
+
+// NewTimewarriorWriter returns a writer of Timewarrior intervals.
+func NewTimewarriorWriter(w io.Writer) *TimewarriorWriter {
+	return &TimewarriorWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes an entry's interval.
+func (t *TimewarriorWriter) Write(e Entry) error {
+	var b strings.Builder
+	b.WriteString("inc ")
+	b.WriteString(e.Start.UTC().Format(timewarriorLayout))
+	if !e.IsRunning() {
+		b.WriteString(" - ")
+		b.WriteString(e.Stop.UTC().Format(timewarriorLayout))
+	}
+
+	var tags []string
+	if project := e.ProjectTitle(); project != "" {
+		tags = append(tags, project)
+	}
+	tags = append(tags, e.Tags...)
+	if len(tags) > 0 || e.Description != "" {
+		b.WriteString(" #")
+		for _, tag := range tags {
+			b.WriteString(" ")
+			b.WriteString(quoteIfNeeded(tag))
+		}
+	}
+	if e.Description != "" {
+		b.WriteString(" # ")
+		b.WriteString(quote(e.Description))
+	}
+	b.WriteString("\n")
+
+	_, err := t.w.WriteString(b.String())
+	return err
+}
+
+// Close flushes the intervals written.
+func (t *TimewarriorWriter) Close() error {
+	return t.w.Flush()
+}
+
+// quoteIfNeeded quotes a tag that isn't a single plain word.
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\"#\\") {
+		return quote(s)
+	}
+	return s
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ")
+
+func quote(s string) string {
+	return `"` + quoteEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,374 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Column is a column of tabular output.
+type Column struct {
+	Name string
+	// Width is the maximum width of the column in tables. Zero means no
+	// limit.
+	Width int
+	// Value returns the cell for a value. It's called with the value, not a
+	// pointer to it.
+	Value func(item interface{}, options *Options) string
+}
+
+// columnSet is the set of columns registered for a type.
+type columnSet struct {
+	columns  []Column
+	defaults []string
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[reflect.Type]columnSet)
+)
+
+// Register sets the columns of the type of sample, replacing any columns it
+// had. Defaults names the columns written when none are selected; if it's
+// empty, all columns are written.
+func Register(sample interface{}, defaults []string, columns ...Column) {
+	t := reflect.TypeOf(sample)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if len(defaults) == 0 {
+		for _, c := range columns {
+			defaults = append(defaults, c.Name)
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[t] = columnSet{columns: columns, defaults: defaults}
+}
+
+// ColumnNames returns the names of the columns available for a value.
+func ColumnNames(sample interface{}) []string {
+	columns, _ := columnsOf(sample)
+	var names []string
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// columnsOf returns the columns and default column names for a value. Types
+// without registered columns get one column per JSON field.
+func columnsOf(sample interface{}) ([]Column, []string) {
+	registryMu.RLock()
+	set, ok := registry[reflect.TypeOf(sample)]
+	registryMu.RUnlock()
+	if ok {
+		return set.columns, set.defaults
+	}
+
+	ordered, err := toOrdered(sample)
+	r, isRecord := ordered.(record)
+	if err != nil || !isRecord {
+		return []Column{{Name: "value", Value: func(item interface{}, o *Options) string {
+			return scalarString(item)
+		}}}, []string{"value"}
+	}
+
+	var columns []Column
+	var names []string
+	for _, f := range r {
+		key := f.key
+		columns = append(columns, Column{Name: key, Value: func(item interface{}, o *Options) string {
+			v, _ := toOrdered(item)
+			if r, ok := v.(record); ok {
+				return scalarString(r.get(key))
+			}
+			return ""
+		}})
+		names = append(names, key)
+	}
+	return columns, names
+}
+
+// scalarString formats a value decoded from JSON as a cell. Objects and
+// arrays of objects are written as JSON; arrays of scalars are joined.
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		var parts []string
+		for _, e := range v {
+			switch e.(type) {
+			case record, []interface{}:
+				data, _ := json.Marshal(v)
+				return string(data)
+			}
+			parts = append(parts, scalarString(e))
+		}
+		return strings.Join(parts, ", ")
+	case record:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// Cell helpers ////////////////////////////////////////////////////////
+
+func (o *Options) loc() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+	return o.Location
+}
+
+func (o *Options) time(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.In(o.loc()).Format("2006-01-02 15:04")
+}
+
+func (o *Options) duration(d time.Duration) string {
+	return toggl.FormatDuration(d, o.Durations)
+}
+
+func (o *Options) workspaceName(wid int) string {
+	if wid == 0 {
+		return ""
+	}
+	if o.Account != nil {
+		for _, w := range o.Account.Data.Workspaces {
+			if w.ID == wid {
+				return w.Name
+			}
+		}
+	}
+	return strconv.Itoa(wid)
+}
+
+func (o *Options) projectName(pid int) string {
+	if pid == 0 {
+		return ""
+	}
+	if o.Account != nil {
+		for _, p := range o.Account.Data.Projects {
+			if p.ID == pid {
+				return p.Name
+			}
+		}
+	}
+	return strconv.Itoa(pid)
+}
+
+func (o *Options) projectClient(pid int) string {
+	if pid == 0 || o.Account == nil {
+		return ""
+	}
+	for _, p := range o.Account.Data.Projects {
+		if p.ID == pid {
+			return o.clientName(p.Cid)
+		}
+	}
+	return ""
+}
+
+func (o *Options) clientName(cid int) string {
+	if cid == 0 {
+		return ""
+	}
+	if o.Account != nil {
+		for _, c := range o.Account.Data.Clients {
+			if c.ID == cid {
+				return c.Name
+			}
+		}
+	}
+	return strconv.Itoa(cid)
+}
+
+func (o *Options) taskName(tid int) string {
+	if tid == 0 {
+		return ""
+	}
+	if o.Account != nil {
+		for _, t := range o.Account.Data.Tasks {
+			if t.ID == tid {
+				return t.Name
+			}
+		}
+	}
+	return strconv.Itoa(tid)
+}
+
+func id(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return ""
+}
+
+func money(amount float64) string {
+	if amount == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Predefined columns //////////////////////////////////////////////////
+
+func init() {
+	Register(toggl.TimeEntry{},
+		[]string{"id", "start", "stop", "duration", "project", "description", "tags"},
+		entryColumn("id", func(e toggl.TimeEntry, o *Options) string { return id(e.ID) }),
+		entryColumn("workspace", func(e toggl.TimeEntry, o *Options) string { return o.workspaceName(e.Wid) }),
+		entryColumn("project", func(e toggl.TimeEntry, o *Options) string { return o.projectName(e.Pid) }),
+		entryColumn("client", func(e toggl.TimeEntry, o *Options) string { return o.projectClient(e.Pid) }),
+		entryColumn("task", func(e toggl.TimeEntry, o *Options) string { return o.taskName(e.Tid) }),
+		entryColumn("description", func(e toggl.TimeEntry, o *Options) string { return e.Description }),
+		entryColumn("start", func(e toggl.TimeEntry, o *Options) string { return o.time(e.Start) }),
+		entryColumn("stop", func(e toggl.TimeEntry, o *Options) string {
+			if e.IsRunning() {
+				return "running"
+			}
+			return o.time(e.Stop)
+		}),
+		entryColumn("duration", func(e toggl.TimeEntry, o *Options) string { return o.duration(e.LiveDuration()) }),
+		entryColumn("tags", func(e toggl.TimeEntry, o *Options) string { return strings.Join(e.Tags, ", ") }),
+		entryColumn("billable", func(e toggl.TimeEntry, o *Options) string { return yesNo(e.Billable != 0) }),
+		entryColumn("running", func(e toggl.TimeEntry, o *Options) string { return yesNo(e.IsRunning()) }),
+	)
+
+	Register(toggl.DetailedTimeEntry{},
+		[]string{"id", "start", "end", "duration", "user", "client", "project", "description", "tags"},
+		detailedColumn("id", func(e toggl.DetailedTimeEntry, o *Options) string { return id(e.ID) }),
+		detailedColumn("user", func(e toggl.DetailedTimeEntry, o *Options) string { return e.User }),
+		detailedColumn("client", func(e toggl.DetailedTimeEntry, o *Options) string { return e.Client }),
+		detailedColumn("project", func(e toggl.DetailedTimeEntry, o *Options) string { return e.Project }),
+		detailedColumn("task", func(e toggl.DetailedTimeEntry, o *Options) string { return e.Task }),
+		detailedColumn("description", func(e toggl.DetailedTimeEntry, o *Options) string { return e.Description }),
+		detailedColumn("start", func(e toggl.DetailedTimeEntry, o *Options) string { return o.time(e.Start) }),
+		detailedColumn("end", func(e toggl.DetailedTimeEntry, o *Options) string { return o.time(e.End) }),
+		detailedColumn("duration", func(e toggl.DetailedTimeEntry, o *Options) string {
+			return o.duration(time.Duration(e.Duration) * time.Millisecond)
+		}),
+		detailedColumn("tags", func(e toggl.DetailedTimeEntry, o *Options) string { return strings.Join(e.Tags, ", ") }),
+		detailedColumn("billable", func(e toggl.DetailedTimeEntry, o *Options) string { return yesNo(e.IsBillable) }),
+	)
+
+	Register(toggl.Workspace{},
+		[]string{"id", "name", "premium"},
+		Column{Name: "id", Value: func(item interface{}, o *Options) string { return id(item.(toggl.Workspace).ID) }},
+		Column{Name: "name", Value: func(item interface{}, o *Options) string { return item.(toggl.Workspace).Name }},
+		Column{Name: "premium", Value: func(item interface{}, o *Options) string { return yesNo(item.(toggl.Workspace).Premium) }},
+		Column{Name: "rate", Value: func(item interface{}, o *Options) string {
+			return money(item.(toggl.Workspace).DefaultHourlyRate)
+		}},
+		Column{Name: "currency", Value: func(item interface{}, o *Options) string { return item.(toggl.Workspace).DefaultCurrency }},
+	)
+
+	Register(toggl.Project{},
+		[]string{"id", "name", "client", "workspace"},
+		Column{Name: "id", Value: func(item interface{}, o *Options) string { return id(item.(toggl.Project).ID) }},
+		Column{Name: "name", Value: func(item interface{}, o *Options) string { return item.(toggl.Project).Name }},
+		Column{Name: "client", Value: func(item interface{}, o *Options) string { return o.clientName(item.(toggl.Project).Cid) }},
+		Column{Name: "workspace", Value: func(item interface{}, o *Options) string {
+			return o.workspaceName(item.(toggl.Project).Wid)
+		}},
+		Column{Name: "active", Value: func(item interface{}, o *Options) string {
+			p := item.(toggl.Project)
+			return yesNo(p.IsActive())
+		}},
+		Column{Name: "billable", Value: func(item interface{}, o *Options) string { return yesNo(item.(toggl.Project).Billable != 0) }},
+		Column{Name: "rate", Value: func(item interface{}, o *Options) string { return money(item.(toggl.Project).Rate) }},
+		Column{Name: "currency", Value: func(item interface{}, o *Options) string { return item.(toggl.Project).Currency }},
+	)
+
+	Register(toggl.Client{},
+		[]string{"id", "name", "workspace"},
+		Column{Name: "id", Value: func(item interface{}, o *Options) string { return id(item.(toggl.Client).ID) }},
+		Column{Name: "name", Value: func(item interface{}, o *Options) string { return item.(toggl.Client).Name }},
+		Column{Name: "workspace", Value: func(item interface{}, o *Options) string {
+			return o.workspaceName(item.(toggl.Client).Wid)
+		}},
+		Column{Name: "notes", Value: func(item interface{}, o *Options) string { return item.(toggl.Client).Notes }},
+	)
+
+	Register(toggl.Tag{},
+		[]string{"id", "name", "workspace"},
+		Column{Name: "id", Value: func(item interface{}, o *Options) string { return id(item.(toggl.Tag).ID) }},
+		Column{Name: "name", Value: func(item interface{}, o *Options) string { return item.(toggl.Tag).Name }},
+		Column{Name: "workspace", Value: func(item interface{}, o *Options) string {
+			return o.workspaceName(item.(toggl.Tag).Wid)
+		}},
+	)
+
+	Register(toggl.SummaryReportGroup{},
+		[]string{"project", "client", "time"},
+		Column{Name: "id", Value: func(item interface{}, o *Options) string { return id(item.(toggl.SummaryReportGroup).ID) }},
+		Column{Name: "project", Value: func(item interface{}, o *Options) string {
+			return item.(toggl.SummaryReportGroup).Title.Project
+		}},
+		Column{Name: "client", Value: func(item interface{}, o *Options) string {
+			return item.(toggl.SummaryReportGroup).Title.Client
+		}},
+		Column{Name: "time", Value: func(item interface{}, o *Options) string {
+			return o.duration(time.Duration(item.(toggl.SummaryReportGroup).Time) * time.Millisecond)
+		}},
+	)
+
+	Register(ReportRow{},
+		[]string{"group", "total", "billable", "count"},
+		reportColumn("group", func(r ReportRow, o *Options) string { return strings.Repeat("  ", r.Level) + r.Title }),
+		reportColumn("title", func(r ReportRow, o *Options) string { return r.Title }),
+		reportColumn("level", func(r ReportRow, o *Options) string { return strconv.Itoa(r.Level) }),
+		reportColumn("dimension", func(r ReportRow, o *Options) string { return string(r.Dimension) }),
+		reportColumn("key", func(r ReportRow, o *Options) string { return r.Key }),
+		reportColumn("total", func(r ReportRow, o *Options) string {
+			return o.duration(time.Duration(r.Total) * time.Second)
+		}),
+		reportColumn("billable", func(r ReportRow, o *Options) string {
+			return o.duration(time.Duration(r.Billable) * time.Second)
+		}),
+		reportColumn("count", func(r ReportRow, o *Options) string { return strconv.Itoa(r.Count) }),
+	)
+}
+
+func entryColumn(name string, value func(toggl.TimeEntry, *Options) string) Column {
+	return Column{Name: name, Value: func(item interface{}, o *Options) string {
+		return value(item.(toggl.TimeEntry), o)
+	}}
+}
+
+func detailedColumn(name string, value func(toggl.DetailedTimeEntry, *Options) string) Column {
+	return Column{Name: name, Value: func(item interface{}, o *Options) string {
+		return value(item.(toggl.DetailedTimeEntry), o)
+	}}
+}
+
+func reportColumn(name string, value func(ReportRow, *Options) string) Column {
+	return Column{Name: name, Value: func(item interface{}, o *Options) string {
+		return value(item.(ReportRow), o)
+	}}
+}
@@ -0,0 +1,392 @@
+/*
+Package format writes Toggl data as aligned tables, CSV, TSV, JSON, NDJSON,
+YAML or the output of Go templates.
+
+Values are formatted through columns. Time entries, detailed report entries,
+workspaces, projects, clients, tags and report rows have predefined columns
+that resolve IDs to names using an account; other types get one column per
+JSON field. Columns can be selected and limited in width:
+
+	err := format.Write(os.Stdout, entries, format.Options{
+		Format:  format.Table,
+		Columns: []string{"start", "duration", "project", "description:40"},
+		Account: &account,
+	})
+
+A format containing "{{" is a text/template executed once per value, as in
+"{{.Description}} {{duration .}}".
+*/
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Output formats.
+const (
+	Table  = "table"
+	CSV    = "csv"
+	TSV    = "tsv"
+	JSON   = "json"
+	NDJSON = "ndjson"
+	YAML   = "yaml"
+)
+
+// Formats lists the names of the output formats.
+var Formats = []string{Table, CSV, TSV, JSON, NDJSON, YAML}
+
+// Options controls how values are written.
+type Options struct {
+	// Format is the name of an output format, or a template. It defaults to
+	// Table.
+	Format string
+	// Columns selects the columns to write, by name, optionally followed by
+	// a colon and the maximum width of the column in tables, as in
+	// "description:30". By default a type's default columns are written to
+	// tables, CSV and TSV, and whole values to JSON, NDJSON and YAML.
+	Columns []string
+	// NoHeader omits the header row of tables, CSV and TSV.
+	NoHeader bool
+	// Account is used to look up the names of workspaces, projects, clients
+	// and tasks. Without it IDs are written instead.
+	Account *toggl.Account
+	// Location is the time zone times are written in. It defaults to the
+	// local time zone.
+	Location *time.Location
+	// Durations is the style durations are written in.
+	Durations toggl.DurationStyle
+}
+
+// IsTemplate returns true if a format is a template rather than a format name.
+func IsTemplate(format string) bool {
+	return strings.Contains(format, "{{")
+}
+
+// Valid returns an error if a format is neither a known format name nor a
+// valid template.
+func Valid(format string) error {
+	if format == "" || IsTemplate(format) {
+		_, err := template.New("format").Funcs(funcs(&Options{})).Parse(format)
+		return err
+	}
+	for _, name := range Formats {
+		if format == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown format %q; use one of %s or a template", format, strings.Join(Formats, ", "))
+}
+
+// Write writes values in the format given by the options. Values may be a
+// slice, whose elements are written as rows, or a single value. A nil value
+// is written as null in JSON and YAML and not at all otherwise.
+func Write(w io.Writer, values interface{}, options Options) error {
+	if options.Format == "" {
+		options.Format = Table
+	}
+	if err := Valid(options.Format); err != nil {
+		return err
+	}
+
+	items, single := elements(values)
+	if IsTemplate(options.Format) {
+		return writeTemplate(w, items, &options)
+	}
+
+	switch options.Format {
+	case JSON, NDJSON, YAML:
+		var out []interface{}
+		for _, item := range items {
+			v, err := options.structured(item)
+			if err != nil {
+				return err
+			}
+			out = append(out, v)
+		}
+		return writeStructured(w, out, single, values == nil, options.Format)
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+	columns, err := options.columns(items[0])
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		for _, column := range columns {
+			rows[i] = append(rows[i], column.Value(item, &options))
+		}
+	}
+
+	switch options.Format {
+	case CSV:
+		return writeCSV(w, columns, rows, ',', options.NoHeader)
+	case TSV:
+		return writeCSV(w, columns, rows, '\t', options.NoHeader)
+	}
+	return writeTable(w, columns, rows, options.NoHeader)
+}
+
+// support /////////////////////////////////////////////////////////////
+
+// elements returns the values to write, dereferencing pointers.
+func elements(values interface{}) (items []interface{}, single bool) {
+	if values == nil {
+		return nil, true
+	}
+
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []interface{}{indirect(v).Interface()}, true
+	}
+	for i := 0; i < v.Len(); i++ {
+		elem := indirect(v.Index(i))
+		if elem.IsValid() {
+			items = append(items, elem.Interface())
+		}
+	}
+	return items, false
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// columns returns the selected columns for a value.
+func (o *Options) columns(sample interface{}) ([]Column, error) {
+	available, defaults := columnsOf(sample)
+	specs := o.Columns
+	if len(specs) == 0 {
+		specs = defaults
+	}
+
+	var columns []Column
+	for _, spec := range specs {
+		name, width := spec, 0
+		if i := strings.LastIndex(spec, ":"); i != -1 {
+			n, err := strconv.Atoi(spec[i+1:])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("Invalid column width in %q", spec)
+			}
+			name, width = spec[:i], n
+		}
+
+		found := false
+		for _, c := range available {
+			if c.Name == name {
+				if width != 0 {
+					c.Width = width
+				}
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var names []string
+			for _, c := range available {
+				names = append(names, c.Name)
+			}
+			return nil, fmt.Errorf("Unknown column %q; available columns are %s", name, strings.Join(names, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// structured returns a value to encode as JSON or YAML: the value itself, or
+// an object of the selected columns.
+func (o *Options) structured(item interface{}) (interface{}, error) {
+	if len(o.Columns) == 0 {
+		return toOrdered(item)
+	}
+	columns, err := o.columns(item)
+	if err != nil {
+		return nil, err
+	}
+	var r record
+	for _, c := range columns {
+		r = append(r, field{key: c.Name, value: c.Value(item, o)})
+	}
+	return r, nil
+}
+
+func writeStructured(w io.Writer, values []interface{}, single, null bool, format string) error {
+	var v interface{} = values
+	if null {
+		v = nil
+	} else if single && len(values) == 1 {
+		v = values[0]
+	} else if values == nil {
+		v = []interface{}{}
+	}
+
+	switch format {
+	case NDJSON:
+		enc := json.NewEncoder(w)
+		for _, value := range values {
+			if err := enc.Encode(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case YAML:
+		_, err := io.WriteString(w, encodeYAML(v))
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeCSV(w io.Writer, columns []Column, rows [][]string, comma rune, noHeader bool) error {
+	out := csv.NewWriter(w)
+	out.Comma = comma
+	if !noHeader {
+		var header []string
+		for _, c := range columns {
+			header = append(header, c.Name)
+		}
+		out.Write(header)
+	}
+	for _, row := range rows {
+		out.Write(row)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func writeTable(w io.Writer, columns []Column, rows [][]string, noHeader bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if !noHeader {
+		var header []string
+		for _, c := range columns {
+			header = append(header, strings.ToUpper(c.Name))
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = truncate(cleanCell(cell), columns[i].Width)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// cleanCell replaces characters that would break table alignment.
+func cleanCell(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// truncate shortens a string to a number of characters, marking the cut with
+// an ellipsis. A width of zero means no limit.
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	if width == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func writeTemplate(w io.Writer, items []interface{}, o *Options) error {
+	text := o.Format
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	tmpl, err := template.New("format").Funcs(funcs(o)).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, item := range items {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, item); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// funcs returns the functions available to templates.
+func funcs(o *Options) template.FuncMap {
+	return template.FuncMap{
+		"duration": func(v interface{}) (string, error) {
+			d, err := toDuration(v)
+			return o.duration(d), err
+		},
+		"time": func(v interface{}) string {
+			switch t := v.(type) {
+			case time.Time:
+				return o.time(&t)
+			case *time.Time:
+				return o.time(t)
+			}
+			return fmt.Sprint(v)
+		},
+		"workspace": o.workspaceName,
+		"project":   o.projectName,
+		"client":    o.clientName,
+		"task":      o.taskName,
+		"join":      strings.Join,
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+}
+
+// toDuration converts the argument of the duration template function. Entries
+// give their live duration and integers are taken as seconds.
+func toDuration(v interface{}) (time.Duration, error) {
+	switch d := v.(type) {
+	case toggl.TimeEntry:
+		return d.LiveDuration(), nil
+	case *toggl.TimeEntry:
+		return d.LiveDuration(), nil
+	case toggl.DetailedTimeEntry:
+		return time.Duration(d.Duration) * time.Millisecond, nil
+	case time.Duration:
+		return d, nil
+	case int:
+		return time.Duration(d) * time.Second, nil
+	case int64:
+		return time.Duration(d) * time.Second, nil
+	}
+	return 0, fmt.Errorf("Can't format %T as a duration", v)
+}
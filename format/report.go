@@ -0,0 +1,47 @@
+package format
+
+import (
+	"time"
+
+	"github.com/Jberlinsky/go-toggl/aggregate"
+)
+
+// ReportRow is a group of an aggregated report, flattened so that reports can
+// be written as tables and CSV. Durations are in seconds.
+type ReportRow struct {
+	// Level is the depth of the group, 0 for top level groups.
+	Level     int                 `json:"level"`
+	Dimension aggregate.Dimension `json:"dimension,omitempty"`
+	Key       string              `json:"key,omitempty"`
+	Title     string              `json:"title"`
+	Total     int64               `json:"total"`
+	Billable  int64               `json:"billable"`
+	Count     int                 `json:"count"`
+}
+
+// ReportRows flattens the groups of an aggregation, parents before their
+// children, and ends with a row for the overall totals.
+func ReportRows(result aggregate.Result) []ReportRow {
+	var rows []ReportRow
+	var add func(groups []*aggregate.Group, level int)
+	add = func(groups []*aggregate.Group, level int) {
+		for _, g := range groups {
+			rows = append(rows, reportRow(level, g.Dimension, g.Key, g.Title, g.Totals))
+			add(g.Groups, level+1)
+		}
+	}
+	add(result.Groups, 0)
+	return append(rows, reportRow(0, "", "", "Total", result.Totals))
+}
+
+func reportRow(level int, dim aggregate.Dimension, key, title string, totals aggregate.Totals) ReportRow {
+	return ReportRow{
+		Level:     level,
+		Dimension: dim,
+		Key:       key,
+		Title:     title,
+		Total:     int64(totals.Total / time.Second),
+		Billable:  int64(totals.Billable / time.Second),
+		Count:     totals.Count,
+	}
+}
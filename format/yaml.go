@@ -0,0 +1,221 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// record is a JSON object that keeps its fields in order, so that values can
+// be written with their fields in the order of their struct definitions.
+type record []field
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+func (r record) get(key string) interface{} {
+	for _, f := range r {
+		if f.key == key {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes the fields in order.
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// toOrdered converts a value to its JSON representation, decoded into
+// records, []interface{}, strings, json.Numbers, bools and nils.
+func toOrdered(v interface{}) (interface{}, error) {
+	if r, ok := v.(record); ok {
+		return r, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeOrdered(dec)
+}
+
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		r := record{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			r = append(r, field{key: key.(string), value: value})
+		}
+		_, err = dec.Token()
+		return r, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// encodeYAML writes a value decoded by toOrdered as a YAML document.
+func encodeYAML(v interface{}) string {
+	if isComposite(v) {
+		return strings.Join(yamlLines(v, 0), "\n") + "\n"
+	}
+	return yamlScalar(v) + "\n"
+}
+
+func isComposite(v interface{}) bool {
+	switch v := v.(type) {
+	case record:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+// yamlLines returns the lines of a non-empty record or list, indented by the
+// given number of spaces.
+func yamlLines(v interface{}, indent int) (lines []string) {
+	pad := strings.Repeat(" ", indent)
+
+	switch v := v.(type) {
+	case record:
+		for _, f := range v {
+			key := yamlScalar(f.key)
+			if isComposite(f.value) {
+				lines = append(lines, pad+key+":")
+				lines = append(lines, yamlLines(f.value, indent+2)...)
+			} else {
+				lines = append(lines, pad+key+": "+yamlScalar(f.value))
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if !isComposite(e) {
+				lines = append(lines, pad+"- "+yamlScalar(e))
+				continue
+			}
+			// The first line of the element goes after the dash
+			child := yamlLines(e, indent+2)
+			child[0] = pad + "- " + child[0][indent+2:]
+			lines = append(lines, child...)
+		}
+	}
+	return lines
+}
+
+// yamlScalar formats a scalar or an empty record or list.
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if needsQuotes(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	case record:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+	return "null"
+}
+
+// needsQuotes returns true if a string can't be written as a plain YAML
+// scalar because it would be read as something else or is malformed.
+func needsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~",
+		".inf", "+.inf", "-.inf", ".nan":
+		return true
+	}
+	if isNumber(s) {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if !strconv.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// sexagesimal matches the base 60 numbers of YAML 1.1, such as 10:30.
+var sexagesimal = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+
+// isNumber returns true if a string would be read as an integer or a float,
+// including out of range ones, hexadecimal and octal integers, integers with
+// underscores and base 60 numbers, which YAML 1.1 readers accept.
+func isNumber(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err == nil || errors.Is(err, strconv.ErrRange) {
+		return true
+	}
+	if sexagesimal.MatchString(s) {
+		return true
+	}
+	_, err := strconv.ParseInt(s, 0, 64)
+	return err == nil || errors.Is(err, strconv.ErrRange)
+}
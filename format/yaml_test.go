@@ -0,0 +1,172 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestYAMLScalar(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, `null`},
+		{true, `true`},
+		{json.Number("1.50"), `1.50`},
+		{record{}, `{}`},
+		{[]interface{}{}, `[]`},
+
+		{"Fix login bug", `Fix login bug`},
+		{"Acme/Website", `Acme/Website`},
+		{"a-b", `a-b`},
+		{"issue#12", `issue#12`},
+		{"2026-10-12T09:00:00Z", `2026-10-12T09:00:00Z`},
+		{"Café", `Café`},
+
+		{"", `""`},
+		{" padded", `" padded"`},
+		{"padded ", `"padded "`},
+
+		{"- item", `"- item"`},
+		{"-x", `"-x"`},
+		{"-", `"-"`},
+		{":x", `":x"`},
+		{"key: value", `"key: value"`},
+		{"key:", `"key:"`},
+		{"# comment", `"# comment"`},
+		{"text # comment", `"text # comment"`},
+		{"? key", `"? key"`},
+		{"[1]", `"[1]"`},
+		{"{a}", `"{a}"`},
+		{"*alias", `"*alias"`},
+		{"&anchor", `"&anchor"`},
+		{"!tag", `"!tag"`},
+		{"|", `"|"`},
+		{">", `">"`},
+		{"@Acme", `"@Acme"`},
+		{"%TAG", `"%TAG"`},
+		{"`cmd`", "\"`cmd`\""},
+		{"'single'", `"'single'"`},
+		{`"double"`, `"\"double\""`},
+
+		{"yes", `"yes"`},
+		{"No", `"No"`},
+		{"ON", `"ON"`},
+		{"off", `"off"`},
+		{"y", `"y"`},
+		{"N", `"N"`},
+		{"true", `"true"`},
+		{"False", `"False"`},
+		{"null", `"null"`},
+		{"Null", `"Null"`},
+		{"~", `"~"`},
+
+		{"1", `"1"`},
+		{"007", `"007"`},
+		{"-5", `"-5"`},
+		{"+5", `"+5"`},
+		{"1.5", `"1.5"`},
+		{".5", `".5"`},
+		{"1e3", `"1e3"`},
+		{"1e999", `"1e999"`},
+		{"0x1F", `"0x1F"`},
+		{"0o17", `"0o17"`},
+		{"1_000", `"1_000"`},
+		{"99999999999999999999", `"99999999999999999999"`},
+		{".inf", `".inf"`},
+		{"-.Inf", `"-.Inf"`},
+		{".NaN", `".NaN"`},
+		{"10:30", `"10:30"`},
+		{"1:02:03", `"1:02:03"`},
+		{"190:20:30.15", `"190:20:30.15"`},
+		{"10:61", `10:61`},
+		{"2026-10-12 10:30", `2026-10-12 10:30`},
+		{"1.2.3", `1.2.3`},
+
+		{"line 1\nline 2", `"line 1\nline 2"`},
+		{"line\r\n", `"line\r\n"`},
+		{"tab\there", `"tab\there"`},
+		{"del\x7f", `"del\x7f"`},
+		{"a\u2028b", `"a\u2028b"`},
+	}
+
+	for _, test := range tests {
+		if got := yamlScalar(test.value); got != test.want {
+			t.Errorf("yamlScalar(%#v) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	type item struct {
+		Name  string      `json:"name"`
+		Count int         `json:"count"`
+		Tags  []string    `json:"tags"`
+		Meta  interface{} `json:"meta"`
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			"nil",
+			nil,
+			"null\n",
+		},
+		{
+			"empty list",
+			[]item{},
+			"[]\n",
+		},
+		{
+			"single",
+			item{Name: "yes", Count: 2, Tags: []string{"no", "007"}},
+			"name: \"yes\"\n" +
+				"count: 2\n" +
+				"tags:\n" +
+				"  - \"no\"\n" +
+				"  - \"007\"\n" +
+				"meta: null\n",
+		},
+		{
+			"nested",
+			[]item{
+				{Name: "a: b", Tags: []string{}, Meta: map[string]interface{}{}},
+				{Name: "multi\nline", Tags: []string{"x"}, Meta: map[string]interface{}{"- key": []int{1, 2}}},
+			},
+			"- name: \"a: b\"\n" +
+				"  count: 0\n" +
+				"  tags: []\n" +
+				"  meta: {}\n" +
+				"- name: \"multi\\nline\"\n" +
+				"  count: 0\n" +
+				"  tags:\n" +
+				"    - x\n" +
+				"  meta:\n" +
+				"    \"- key\":\n" +
+				"      - 1\n" +
+				"      - 2\n",
+		},
+		{
+			"list of lists",
+			[][]string{{"a", "true"}, {}},
+			"- - a\n" +
+				"  - \"true\"\n" +
+				"- []\n",
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, test.value, Options{Format: YAML}); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package toggl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Fuzzy match scores
+const (
+	fuzzyMatchScore       = 1
+	fuzzyConsecutiveBonus = 5
+	fuzzyWordStartBonus   = 8
+	fuzzyPrefixBonus      = 10
+	fuzzyExactBonus       = 100
+	fuzzyMaxGapPenalty    = 10
+)
+
+// FuzzyMatch reports whether the characters of pattern appear in s in order,
+// ignoring case, and scores the match. Higher scores are better matches:
+// characters matching consecutively, at the start of words or at the start
+// of s score higher, and an exact match scores highest. An empty pattern
+// matches everything with a score of zero.
+func FuzzyMatch(pattern, s string) (score int, ok bool) {
+	p := []rune(strings.ToLower(pattern))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	text := []rune(s)
+	lower := []rune(strings.ToLower(s))
+	if len(lower) != len(text) {
+		// Lower casing changed the length; match without position bonuses
+		text = lower
+	}
+
+	pi, first, last := 0, -1, -2
+	for i := 0; i < len(lower) && pi < len(p); i++ {
+		if lower[i] != p[pi] {
+			continue
+		}
+		score += fuzzyMatchScore
+		if last == i-1 {
+			score += fuzzyConsecutiveBonus
+		}
+		if isWordStart(text, i) {
+			score += fuzzyWordStartBonus
+		}
+		if first == -1 {
+			first = i
+		}
+		last = i
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+
+	if first == 0 {
+		score += fuzzyPrefixBonus
+	} else if first < fuzzyMaxGapPenalty {
+		score -= first
+	} else {
+		score -= fuzzyMaxGapPenalty
+	}
+	if strings.EqualFold(pattern, s) {
+		score += fuzzyExactBonus
+	}
+	return score, true
+}
+
+// FuzzyRank returns the indexes of the candidates matching pattern, best
+// matches first. Equal scores are ordered by length, then alphabetically.
+func FuzzyRank(pattern string, candidates []string) []int {
+	var indexes []int
+	scores := make(map[int]int)
+	for i, c := range candidates {
+		if score, ok := FuzzyMatch(pattern, c); ok {
+			indexes = append(indexes, i)
+			scores[i] = score
+		}
+	}
+
+	sort.SliceStable(indexes, func(a, b int) bool {
+		i, j := indexes[a], indexes[b]
+		if scores[i] != scores[j] {
+			return scores[i] > scores[j]
+		}
+		if len(candidates[i]) != len(candidates[j]) {
+			return len(candidates[i]) < len(candidates[j])
+		}
+		return candidates[i] < candidates[j]
+	})
+	return indexes
+}
+
+// isWordStart returns true if the character at i starts a word: it follows a
+// separator or is an upper case letter following a lower case one.
+func isWordStart(s []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := s[i-1], s[i]
+	if unicode.IsSpace(prev) || strings.ContainsRune("-_/.:@#", prev) {
+		return true
+	}
+	return unicode.IsUpper(cur) && unicode.IsLower(prev)
+}
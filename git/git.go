@@ -0,0 +1,212 @@
+/*
+Package git derives time entries from the history of git repositories, for
+developers who forget to start their timers.
+
+Log reads the commits of a repository by running git, and Sessions clusters
+them into working sessions: runs of commits on a branch that follow each other
+within a gap, each starting a lead time before its first commit. Rules map
+repositories and branches to projects, and issue keys in branch names, such as
+ABC-123 in feature/ABC-123-login, become descriptions. Propose then suggests an
+entry for every session the user's entries don't already cover:
+
+	commits, err := git.Log(".", git.LogOptions{Since: from, Until: to})
+	sessions := git.Sessions(commits, git.Options{})
+	rules := []git.Rule{
+		{Repo: "website", Project: "Acme/Website"},
+		{Repo: "dotfiles", Ignore: true},
+	}
+	result, err := git.Propose(&account, sessions, existing, rules, git.Options{})
+
+InstallHook installs a post-checkout hook that runs a command whenever a branch
+is checked out. The command can switch the running timer to the entry
+EntryFor returns for the new branch with Tracker.Switch.
+*/
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a commit of a repository.
+type Commit struct {
+	Hash string `json:"hash"`
+	// Repo is the name of the repository, the base name of its directory.
+	Repo string `json:"repo"`
+	// Branch is the branch the commit was found on. A commit on several
+	// branches is attributed to one of them.
+	Branch  string    `json:"branch,omitempty"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+}
+
+// LogOptions selects the commits Log reads.
+type LogOptions struct {
+	// Since and Until limit the commits to those authored in the time range.
+	// Zero times don't limit it.
+	Since, Until time.Time
+	// Author is a regular expression matching the author's name or email
+	// address, as for git log --author. Empty matches everyone.
+	Author string
+}
+
+// The fields of a commit in git log's output, separated by unit separators
+// and ended by a record separator
+const logFormat = "%H%x1f%S%x1f%an%x1f%ae%x1f%at%x1f%s%x1e"
+
+// Log returns the commits on all branches of the repository containing dir,
+// other than merges, newest first.
+func Log(dir string, options LogOptions) ([]Commit, error) {
+	root, err := TopLevel(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"log", "--all", "--source", "--no-merges", "--format=" + logFormat}
+	// git log limits commit dates; author dates are checked below
+	if !options.Since.IsZero() {
+		args = append(args, "--since="+options.Since.Format(time.RFC3339))
+	}
+	if options.Author != "" {
+		args = append(args, "--author="+options.Author)
+	}
+	out, err := run(root, args...)
+	if err != nil {
+		return nil, err
+	}
+	all, err := parseLog(out, filepath.Base(root))
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, c := range all {
+		if !options.Since.IsZero() && c.Time.Before(options.Since) {
+			continue
+		}
+		if !options.Until.IsZero() && !c.Time.Before(options.Until) {
+			continue
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// parseLog parses the output of git log with logFormat.
+func parseLog(out, repo string) ([]Commit, error) {
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\r\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.Split(record, "\x1f")
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("Invalid git log record %q", record)
+		}
+		secs, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid time of commit %s: %q", fields[0], fields[4])
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Repo:    repo,
+			Branch:  branchName(fields[1]),
+			Author:  fields[2],
+			Email:   fields[3],
+			Time:    time.Unix(secs, 0),
+			Subject: fields[5],
+		})
+	}
+	return commits, nil
+}
+
+// branchName returns the name of the branch of a ref, or an empty string if
+// it isn't a branch.
+func branchName(ref string) string {
+	switch {
+	case strings.HasPrefix(ref, "refs/heads/"):
+		return strings.TrimPrefix(ref, "refs/heads/")
+	case strings.HasPrefix(ref, "refs/remotes/"):
+		// Without the name of the remote
+		name := strings.TrimPrefix(ref, "refs/remotes/")
+		if i := strings.Index(name, "/"); i != -1 && name[i+1:] != "HEAD" {
+			return name[i+1:]
+		}
+	}
+	return ""
+}
+
+// TopLevel returns the root directory of the repository containing dir.
+func TopLevel(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(out)), nil
+}
+
+// CurrentBranch returns the branch checked out in the repository containing
+// dir, or an empty string if no branch is, as during a rebase.
+func CurrentBranch(dir string) (string, error) {
+	out, err := run(dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if e, ok := err.(*Error); ok && e.ExitCode == 1 {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// UserEmail returns the email address git records as the author of the
+// commits made in the repository containing dir, or an empty string if none
+// is configured.
+func UserEmail(dir string) (string, error) {
+	out, err := run(dir, "config", "user.email")
+	if e, ok := err.(*Error); ok && e.ExitCode == 1 {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Error is returned when git fails.
+type Error struct {
+	Args     []string
+	ExitCode int
+	// Stderr is what git wrote to its standard error.
+	Stderr string
+}
+
+// Error describes the command and git's message.
+func (e *Error) Error() string {
+	msg := e.Stderr
+	if msg == "" {
+		msg = fmt.Sprintf("exit status %d", e.ExitCode)
+	}
+	return fmt.Sprintf("git %s: %s", e.Args[0], msg)
+}
+
+// run runs git in dir and returns its output.
+func run(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		exit, ok := err.(*exec.ExitError)
+		if !ok {
+			return "", fmt.Errorf("Unable to run git: %v", err)
+		}
+		return "", &Error{Args: args, ExitCode: exit.ExitCode(), Stderr: strings.TrimSpace(stderr.String())}
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,46 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLog(t *testing.T) {
+	out := "abc\x1frefs/heads/feature/ABC-1\x1fAda\x1fada@example.com\x1f1792000000\x1fAdd login\x1e\n" +
+		"def\x1frefs/remotes/origin/main\x1fAda\x1fada@example.com\x1f1791990000\x1fFix typo: \"teh\"\x1e\n" +
+		"123\x1frefs/tags/v1.0\x1fBob\x1fbob@example.com\x1f1791980000\x1f\x1e\n"
+	commits, err := parseLog(out, "web")
+	want := []Commit{
+		{Hash: "abc", Repo: "web", Branch: "feature/ABC-1", Author: "Ada", Email: "ada@example.com", Time: time.Unix(1792000000, 0), Subject: "Add login"},
+		{Hash: "def", Repo: "web", Branch: "main", Author: "Ada", Email: "ada@example.com", Time: time.Unix(1791990000, 0), Subject: `Fix typo: "teh"`},
+		{Hash: "123", Repo: "web", Author: "Bob", Email: "bob@example.com", Time: time.Unix(1791980000, 0)},
+	}
+	if err != nil || !reflect.DeepEqual(commits, want) {
+		t.Errorf("parseLog = %+v, %v, want %+v", commits, err, want)
+	}
+
+	for _, out := range []string{"abc\x1fmain\x1e", "abc\x1fmain\x1fAda\x1fada@example.com\x1fnow\x1fAdd login\x1e"} {
+		if commits, err := parseLog(out, "web"); err == nil {
+			t.Errorf("parseLog(%q) = %+v, want an error", out, commits)
+		}
+	}
+}
+
+func TestBranchName(t *testing.T) {
+	tests := []struct {
+		ref, want string
+	}{
+		{"refs/heads/main", "main"},
+		{"refs/heads/feature/login", "feature/login"},
+		{"refs/remotes/origin/feature/login", "feature/login"},
+		{"refs/remotes/origin/HEAD", ""},
+		{"refs/tags/v1.0", ""},
+		{"HEAD", ""},
+	}
+	for _, test := range tests {
+		if got := branchName(test.ref); got != test.want {
+			t.Errorf("branchName(%q) = %q, want %q", test.ref, got, test.want)
+		}
+	}
+}
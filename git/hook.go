@@ -0,0 +1,91 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies the hooks InstallHook writes.
+const hookMarker = "# go-toggl post-checkout hook"
+
+// HookPath returns the path of the post-checkout hook of the repository
+// containing dir, taking core.hooksPath and worktrees into account.
+func HookPath(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--git-path", "hooks/post-checkout")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.FromSlash(strings.TrimSpace(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path, nil
+}
+
+// InstallHook installs a post-checkout hook in the repository containing dir
+// that runs a command, a program and its arguments, whenever a branch is
+// checked out, and returns its path. The command is given git's arguments to
+// the hook, and its failures don't fail the checkout. A hook installed before
+// is replaced, but other hooks are left alone and reported as an error.
+func InstallHook(dir string, command ...string) (string, error) {
+	if len(command) == 0 {
+		return "", fmt.Errorf("No hook command given")
+	}
+	path, err := HookPath(dir)
+	if err != nil {
+		return "", err
+	}
+
+	words := make([]string, len(command))
+	for i, word := range command {
+		words[i] = shellQuote(word)
+	}
+	line := strings.Join(words, " ")
+	if data, err := ioutil.ReadFile(path); err == nil && !bytes.Contains(data, []byte(hookMarker)) {
+		return "", fmt.Errorf("%s already exists; add %q to it instead", path, line)
+	} else if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	script := "#!/bin/sh\n" +
+		hookMarker + "\n" +
+		"# Only on checkouts of branches, not of files\n" +
+		"[ \"$3\" = 1 ] || exit 0\n" +
+		line + " \"$@\" || true\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	// WriteFile keeps the mode of an existing file
+	return path, os.Chmod(path, 0755)
+}
+
+// RemoveHook removes the hook installed by InstallHook from the repository
+// containing dir, and returns its path.
+func RemoveHook(dir string) (string, error) {
+	path, err := HookPath(dir)
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && !bytes.Contains(data, []byte(hookMarker))) {
+		return "", fmt.Errorf("No hook was installed at %s", path)
+	} else if err != nil {
+		return "", err
+	}
+	return path, os.Remove(path)
+}
+
+// shellQuote quotes a word for sh.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@") == "" {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo returns the directory of a new repository with one commit on main.
+func testRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "core.hooksPath", ".git/hooks"},
+		{"-c", "user.name=Ada", "-c", "user.email=ada@example.com", "commit", "--quiet", "--allow-empty", "-m", "Start"},
+		{"branch", "-M", "main"},
+	} {
+		if _, err := run(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestHook(t *testing.T) {
+	dir := testRepo(t)
+	out := filepath.Join(t.TempDir(), "it's out")
+	// A command whose words need quoting, that records its arguments
+	command := []string{"sh", "-c", `printf '%s\n' "$@" > "$0"`, out}
+	path, err := InstallHook(dir, command...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".git", "hooks", "post-checkout"); path != want {
+		t.Errorf("installed at %s, want %s", path, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("hook isn't executable: %v, %v", info, err)
+	}
+
+	if _, err := run(dir, "checkout", "--quiet", "-b", "feature/ABC-1"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook didn't run the command: %v", err)
+	}
+	if args := strings.Fields(string(data)); len(args) != 3 || args[2] != "1" {
+		t.Errorf("hook ran the command with %q, want the two heads and 1", args)
+	}
+	if branch, err := CurrentBranch(dir); branch != "feature/ABC-1" || err != nil {
+		t.Errorf("CurrentBranch = %q, %v", branch, err)
+	}
+
+	// Installing again replaces the hook
+	if _, err := InstallHook(dir, "true"); err != nil {
+		t.Errorf("reinstalling: %v", err)
+	}
+	if removed, err := RemoveHook(dir); removed != path || err != nil {
+		t.Errorf("RemoveHook = %s, %v", removed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("hook is still there: %v", err)
+	}
+	if _, err := RemoveHook(dir); err == nil {
+		t.Error("removing a removed hook succeeded")
+	}
+
+	// Other hooks are left alone
+	foreign := "#!/bin/sh\necho mine\n"
+	if err := ioutil.WriteFile(path, []byte(foreign), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InstallHook(dir, "true"); err == nil {
+		t.Error("replaced another hook")
+	}
+	if _, err := RemoveHook(dir); err == nil {
+		t.Error("removed another hook")
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != foreign {
+		t.Errorf("other hook changed to %q", data)
+	}
+
+	if _, err := InstallHook(dir); err == nil {
+		t.Error("installed a hook without a command")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/usr/bin/toggl", "/usr/bin/toggl"},
+		{"git-checkout", "git-checkout"},
+		{"", "''"},
+		{"My Tools/toggl", "'My Tools/toggl'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+	for _, test := range tests {
+		if got := shellQuote(test.in); got != test.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// DefaultIssuePattern matches issue keys such as ABC-123.
+const DefaultIssuePattern = `[A-Z][A-Z0-9]+-[0-9]+`
+
+// Rule matches repositories and branches and says which entries their
+// sessions get. A rule without conditions matches every branch.
+type Rule struct {
+	// Repo matches the name of the repository, the base name of its
+	// directory, as for path.Match and ignoring case.
+	Repo string `json:"repo,omitempty"`
+	// Branch matches the branch as for path.Match, so feature/* matches
+	// feature/login.
+	Branch string `json:"branch,omitempty"`
+
+	// Project is the project of the entries, as for Account.FindProject.
+	Project string   `json:"project,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	// Billable makes the entries billable. Entries are also billable if
+	// their project is.
+	Billable bool `json:"billable,omitempty"`
+	// Description is the description of the entries, in which {issue},
+	// {branch} and {repo} are replaced by the issue key in the branch's
+	// name, the branch and the repository. It defaults to the issue key or,
+	// for branches without one, the branch.
+	Description string `json:"description,omitempty"`
+	// Ignore skips matching branches, such as those of personal projects.
+	Ignore bool `json:"ignore,omitempty"`
+}
+
+// ParseRules reads rules from a JSON array.
+func ParseRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("Invalid rules: %v", err)
+	}
+	for i, rule := range rules {
+		if err := rule.valid(); err != nil {
+			return nil, fmt.Errorf("Rule %d: %v", i+1, err)
+		}
+	}
+	return rules, nil
+}
+
+// valid checks the rule's patterns.
+func (rule Rule) valid() error {
+	if _, err := path.Match(rule.Repo, ""); err != nil {
+		return fmt.Errorf("Invalid repository pattern %q", rule.Repo)
+	}
+	if _, err := path.Match(rule.Branch, ""); err != nil {
+		return fmt.Errorf("Invalid branch pattern %q", rule.Branch)
+	}
+	return nil
+}
+
+// match returns true if the rule matches a branch of a repository.
+func (rule Rule) match(repo, branch string) bool {
+	if rule.Repo != "" {
+		if ok, _ := path.Match(strings.ToLower(rule.Repo), strings.ToLower(repo)); !ok {
+			return false
+		}
+	}
+	if rule.Branch != "" {
+		if ok, _ := path.Match(rule.Branch, branch); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// mapper maps branches to entries with rules whose projects are resolved.
+type mapper struct {
+	account  *toggl.Account
+	rules    []Rule
+	projects []*toggl.Project
+	issue    *regexp.Regexp
+	options  Options
+}
+
+func newMapper(account *toggl.Account, rules []Rule, options Options) (*mapper, error) {
+	options = options.withDefaults()
+	if options.Workspace == 0 && len(account.Data.Workspaces) > 0 {
+		options.Workspace = account.Data.Workspaces[0].ID
+	}
+	issue, err := regexp.Compile(options.Issue)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid issue pattern %q: %v", options.Issue, err)
+	}
+
+	m := &mapper{account: account, rules: rules, projects: make([]*toggl.Project, len(rules)), issue: issue, options: options}
+	for i, rule := range rules {
+		if err := rule.valid(); err != nil {
+			return nil, fmt.Errorf("Rule %d: %v", i+1, err)
+		}
+		if rule.Project != "" && !rule.Ignore {
+			if m.projects[i], err = account.FindProject(rule.Project); err != nil {
+				return nil, fmt.Errorf("Rule %d: %v", i+1, err)
+			}
+		}
+	}
+	return m, nil
+}
+
+// find returns the index of the first rule matching a branch, or -1.
+func (m *mapper) find(repo, branch string) int {
+	for i, rule := range m.rules {
+		if rule.match(repo, branch) {
+			return i
+		}
+	}
+	return -1
+}
+
+// entry returns the entry for a branch matched by the ith rule, and the
+// title of its project.
+func (m *mapper) entry(i int, repo, branch string) (toggl.TimeEntry, string, error) {
+	rule, project := m.rules[i], m.projects[i]
+	entry := toggl.TimeEntry{
+		Wid:         m.options.Workspace,
+		Description: m.description(rule, repo, branch),
+	}
+	if rule.Billable {
+		entry.Billable = 1
+	}
+
+	var title string
+	if project != nil {
+		entry.Wid, entry.Pid = project.Wid, project.ID
+		title = m.account.ProjectTitle(*project)
+		if project.Billable != 0 {
+			entry.Billable = project.Billable
+		}
+	}
+
+	if err := m.account.AddTags(&entry, rule.Tags); err != nil {
+		return entry, title, fmt.Errorf("Rule %d: %v", i+1, err)
+	}
+	return entry, title, nil
+}
+
+// description returns the description of a branch's entries.
+func (m *mapper) description(rule Rule, repo, branch string) string {
+	issue := m.issue.FindString(branch)
+	if rule.Description == "" {
+		switch {
+		case issue != "":
+			return issue
+		case branch != "":
+			return branch
+		}
+		return repo
+	}
+	r := strings.NewReplacer("{issue}", issue, "{branch}", branch, "{repo}", repo)
+	return strings.TrimSpace(r.Replace(rule.Description))
+}
+
+// EntryFor returns the entry for working on a branch of a repository, as a
+// timer to start, or false if no rule matches the branch or the rule that
+// does ignores it. An error is returned if a rule is invalid or names a
+// project that can't be found.
+func EntryFor(account *toggl.Account, repo, branch string, rules []Rule, options Options) (toggl.TimeEntry, bool, error) {
+	m, err := newMapper(account, rules, options)
+	if err != nil {
+		return toggl.TimeEntry{}, false, err
+	}
+	i := m.find(repo, branch)
+	if i == -1 || rules[i].Ignore {
+		return toggl.TimeEntry{}, false, nil
+	}
+	entry, _, err := m.entry(i, repo, branch)
+	return entry, err == nil, err
+}
+
+// Proposal is an entry proposed for a session.
+type Proposal struct {
+	Session Session         `json:"session"`
+	Entry   toggl.TimeEntry `json:"entry"`
+	// Project is the title of the entry's project.
+	Project string `json:"project,omitempty"`
+	// Rule is the index of the rule that matched the session's branch.
+	Rule int `json:"rule"`
+}
+
+// Skipped is a session without a proposal, and why.
+type Skipped struct {
+	Session Session `json:"session"`
+	Reason  string  `json:"reason"`
+}
+
+// Result lists the proposals for sessions, and the sessions skipped.
+type Result struct {
+	Proposals []Proposal `json:"proposals"`
+	Skipped   []Skipped  `json:"skipped"`
+}
+
+// Entries returns the proposed entries.
+func (r *Result) Entries() []toggl.TimeEntry {
+	entries := make([]toggl.TimeEntry, len(r.Proposals))
+	for i, p := range r.Proposals {
+		entries[i] = p.Entry
+	}
+	return entries
+}
+
+// tracked is the part of a session that existing entries must cover for it
+// to be skipped.
+const tracked = 0.5
+
+// Propose proposes entries for sessions. Sessions are skipped if they have
+// no duration, match no rule or a rule that ignores them, or if existing
+// entries cover at least half of them. An error is returned if a rule is
+// invalid or names a project that can't be found.
+func Propose(account *toggl.Account, sessions []Session, existing []toggl.TimeEntry, rules []Rule, options Options) (*Result, error) {
+	m, err := newMapper(account, rules, options)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	skip := func(s Session, reason string) {
+		result.Skipped = append(result.Skipped, Skipped{Session: s, Reason: reason})
+	}
+	covered := toggl.NewCoverage(existing, time.Now())
+	for _, s := range sessions {
+		if s.Duration() <= 0 {
+			skip(s, "No duration")
+			continue
+		}
+		i := m.find(s.Repo, s.Branch)
+		if i == -1 {
+			skip(s, "No rule matches")
+			continue
+		}
+		if rules[i].Ignore {
+			skip(s, fmt.Sprintf("Ignored by rule %d", i+1))
+			continue
+		}
+		if ids := covered.CoveredBy(s.Start, s.Stop, tracked); len(ids) > 0 {
+			skip(s, "Tracked by "+strings.Join(ids, ", "))
+			continue
+		}
+
+		entry, title, err := m.entry(i, s.Repo, s.Branch)
+		if err != nil {
+			return nil, err
+		}
+		start, stop := s.Start, s.Stop
+		entry.Start, entry.Stop = &start, &stop
+		entry.Duration = int64(s.Duration() / time.Second)
+		result.Proposals = append(result.Proposals, Proposal{Session: s, Entry: entry, Project: title, Rule: i})
+	}
+	return result, nil
+}
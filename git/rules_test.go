@@ -0,0 +1,130 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+func testAccount() *toggl.Account {
+	var a toggl.Account
+	a.Data.Timezone = "UTC"
+	a.Data.Workspaces = []toggl.Workspace{{ID: 1, Name: "Work"}, {ID: 2, Name: "Personal"}}
+	a.Data.Clients = []toggl.Client{{Wid: 1, ID: 10, Name: "Acme"}}
+	a.Data.Projects = []toggl.Project{
+		{Wid: 1, ID: 100, Cid: 10, Name: "Website", Active: true, Billable: 1},
+		{Wid: 1, ID: 101, Name: "Internal", Active: true},
+	}
+	a.Data.Tags = []toggl.Tag{{Wid: 1, ID: 1000, Name: "dev"}}
+	return &a
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(`[{"repo": "web*", "branch": "feature/*", "project": "Website", "tags": ["dev"]}, {"ignore": true}]`))
+	want := []Rule{{Repo: "web*", Branch: "feature/*", Project: "Website", Tags: []string{"dev"}}, {Ignore: true}}
+	if err != nil || !reflect.DeepEqual(rules, want) {
+		t.Errorf("ParseRules = %+v, %v, want %+v", rules, err, want)
+	}
+
+	for _, in := range []string{`{}`, `[{"branch": "feature/["}]`, `[{"repo": "[a-"}]`} {
+		if rules, err := ParseRules(strings.NewReader(in)); err == nil {
+			t.Errorf("ParseRules(%s) = %+v, want an error", in, rules)
+		}
+	}
+}
+
+func TestEntryFor(t *testing.T) {
+	a := testAccount()
+	rules := []Rule{
+		{Repo: "dotfiles", Ignore: true},
+		{Repo: "WEB", Branch: "release/*", Project: "Acme/Website", Description: "Release {branch}"},
+		{Repo: "web", Project: "Website", Tags: []string{"DEV"}},
+		{Branch: "chore/*", Project: "Internal", Billable: true, Description: "{repo}: {issue}"},
+		{Branch: "spike/*"},
+	}
+
+	tests := []struct {
+		repo, branch string
+		options      Options
+		ok           bool
+		want         toggl.TimeEntry
+	}{
+		{"dotfiles", "main", Options{}, false, toggl.TimeEntry{}},
+		{"api", "main", Options{}, false, toggl.TimeEntry{}},
+		{"web", "release/2.0", Options{}, true, toggl.TimeEntry{Wid: 1, Pid: 100, Billable: 1, Description: "Release release/2.0"}},
+		{"web", "feature/ABC-123-login", Options{}, true, toggl.TimeEntry{Wid: 1, Pid: 100, Billable: 1, Description: "ABC-123", Tags: []string{"dev"}}},
+		{"web", "fix-typo", Options{}, true, toggl.TimeEntry{Wid: 1, Pid: 100, Billable: 1, Description: "fix-typo", Tags: []string{"dev"}}},
+		{"api", "chore/OPS-7-deps", Options{}, true, toggl.TimeEntry{Wid: 1, Pid: 101, Billable: 1, Description: "api: OPS-7"}},
+		{"api", "chore/deps", Options{}, true, toggl.TimeEntry{Wid: 1, Pid: 101, Billable: 1, Description: "api:"}},
+		{"api", "spike/gh-42", Options{Issue: `gh-[0-9]+`, Workspace: 2}, true, toggl.TimeEntry{Wid: 2, Description: "gh-42"}},
+		{"api", "spike/ABC-1", Options{}, true, toggl.TimeEntry{Wid: 1, Description: "ABC-1"}},
+	}
+
+	for _, test := range tests {
+		entry, ok, err := EntryFor(a, test.repo, test.branch, rules, test.options)
+		if err != nil || ok != test.ok || !reflect.DeepEqual(entry, test.want) {
+			t.Errorf("EntryFor(%s, %s) = %+v, %v, %v, want %+v, %v", test.repo, test.branch, entry, ok, err, test.want, test.ok)
+		}
+	}
+
+	for _, rules := range [][]Rule{
+		{{Project: "Nowhere"}},
+		{{Branch: "["}},
+	} {
+		if _, _, err := EntryFor(a, "web", "main", rules, Options{}); err == nil {
+			t.Errorf("EntryFor with %+v succeeded", rules)
+		}
+	}
+	if _, _, err := EntryFor(a, "web", "main", nil, Options{Issue: "("}); err == nil {
+		t.Error("EntryFor with an invalid issue pattern succeeded")
+	}
+}
+
+func TestPropose(t *testing.T) {
+	a := testAccount()
+	at := func(hour, min int) time.Time {
+		return time.Date(2026, 10, 14, hour, min, 0, 0, time.UTC)
+	}
+	session := func(repo, branch string, start, stop time.Time) Session {
+		return Session{Repo: repo, Branch: branch, Start: start, Stop: stop}
+	}
+	sessions := []Session{
+		session("web", "feature/ABC-1", at(9, 0), at(10, 0)),
+		session("web", "feature/ABC-2", at(10, 0), at(11, 0)),
+		session("web", "main", at(11, 0), at(11, 0)),
+		session("dotfiles", "main", at(12, 0), at(13, 0)),
+		session("api", "main", at(13, 0), at(14, 0)),
+	}
+	// Covers 40 of the first session's 60 minutes, and 10 of the second's
+	start, stop := at(9, 20), at(10, 10)
+	existing := []toggl.TimeEntry{{ID: 7, Wid: 1, Start: &start, Stop: &stop, Duration: 3000}}
+	rules := []Rule{{Repo: "dotfiles", Ignore: true}, {Repo: "web", Project: "Website"}}
+
+	result, err := Propose(a, sessions, existing, rules, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Proposals) != 1 {
+		t.Fatalf("proposed %+v, want one entry", result.Proposals)
+	}
+	p := result.Proposals[0]
+	if p.Rule != 1 || p.Project != "Acme/Website" || p.Entry.Description != "ABC-2" || p.Entry.Pid != 100 ||
+		!p.Entry.Start.Equal(at(10, 0)) || !p.Entry.Stop.Equal(at(11, 0)) || p.Entry.Duration != 3600 {
+		t.Errorf("proposed %+v", p)
+	}
+	if entries := result.Entries(); len(entries) != 1 || entries[0].Description != "ABC-2" {
+		t.Errorf("Entries = %+v", entries)
+	}
+
+	var reasons []string
+	for _, s := range result.Skipped {
+		reasons = append(reasons, s.Session.Branch+": "+s.Reason)
+	}
+	want := []string{"feature/ABC-1: Tracked by entry 7", "main: No duration", "main: Ignored by rule 1", "main: No rule matches"}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("skipped %q, want %q", reasons, want)
+	}
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"sort"
+	"time"
+)
+
+// Defaults of Options
+const (
+	DefaultGap  = 2 * time.Hour
+	DefaultLead = 30 * time.Minute
+)
+
+// Session is a run of commits on a branch, and the time spent on them.
+type Session struct {
+	Repo    string    `json:"repo"`
+	Branch  string    `json:"branch,omitempty"`
+	Start   time.Time `json:"start"`
+	Stop    time.Time `json:"stop"`
+	Commits []Commit  `json:"commits"`
+}
+
+// Duration returns how long the session lasts.
+func (s Session) Duration() time.Duration {
+	return s.Stop.Sub(s.Start)
+}
+
+// Options controls how commits are clustered into sessions and which entries
+// the sessions get.
+type Options struct {
+	// Gap is the longest time between two commits of a session. Zero means
+	// DefaultGap.
+	Gap time.Duration
+	// Lead is the time spent on a session's first commit before making it.
+	// Zero means DefaultLead, and a negative lead means none.
+	Lead time.Duration
+	// Issue is a regular expression matching the issue keys in branch
+	// names. Empty means DefaultIssuePattern.
+	Issue string
+	// Workspace is the ID of the workspace of entries without a project.
+	// Zero means the account's first workspace.
+	Workspace int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Gap <= 0 {
+		o.Gap = DefaultGap
+	}
+	switch {
+	case o.Lead == 0:
+		o.Lead = DefaultLead
+	case o.Lead < 0:
+		o.Lead = 0
+	}
+	if o.Issue == "" {
+		o.Issue = DefaultIssuePattern
+	}
+	return o
+}
+
+// Sessions clusters commits, of one or more repositories, into sessions in
+// order of time. A commit continues the latest session if it's on the same
+// branch of the same repository and made within options.Gap of the session's
+// last commit. A session starts options.Lead before its first commit, but not
+// before the previous session stops, and stops at its last commit. Sessions
+// that would have no duration, such as single commits without a lead, are
+// dropped, since time entries can't be empty.
+func Sessions(commits []Commit, options Options) []Session {
+	options = options.withDefaults()
+	sorted := append([]Commit(nil), commits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	var sessions []Session
+	for _, c := range sorted {
+		n := len(sessions)
+		if n > 0 {
+			last := &sessions[n-1]
+			if last.Repo == c.Repo && last.Branch == c.Branch && c.Time.Sub(last.Stop) <= options.Gap {
+				last.Commits = append(last.Commits, c)
+				last.Stop = c.Time
+				continue
+			}
+		}
+
+		start := c.Time.Add(-options.Lead)
+		if n > 0 && start.Before(sessions[n-1].Stop) {
+			start = sessions[n-1].Stop
+		}
+		sessions = append(sessions, Session{
+			Repo:    c.Repo,
+			Branch:  c.Branch,
+			Start:   start,
+			Stop:    c.Time,
+			Commits: []Commit{c},
+		})
+	}
+
+	kept := sessions[:0]
+	for _, s := range sessions {
+		if s.Duration() > 0 {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
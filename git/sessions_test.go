@@ -0,0 +1,88 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	base := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return base.Add(time.Duration(minutes) * time.Minute)
+	}
+	commit := func(hash, repo, branch string, minutes int) Commit {
+		return Commit{Hash: hash, Repo: repo, Branch: branch, Time: at(minutes)}
+	}
+	// Out of order, as Log returns them newest first
+	commits := []Commit{
+		commit("f", "api", "main", 400),
+		commit("e", "web", "main", 200),
+		commit("d", "web", "feature/ABC-1", 70),
+		commit("c", "web", "main", 60),
+		commit("b", "web", "main", 50),
+		commit("a", "web", "main", 0),
+	}
+
+	type session struct {
+		branch      string
+		start, stop int
+		hashes      string
+	}
+	tests := []struct {
+		name    string
+		options Options
+		want    []session
+	}{
+		{"defaults", Options{}, []session{
+			{"main", -30, 60, "abc"},
+			{"feature/ABC-1", 60, 70, "d"},
+			{"main", 170, 200, "e"},
+			{"main", 370, 400, "f"},
+		}},
+		{"short gap", Options{Gap: 20 * time.Minute}, []session{
+			{"main", -30, 0, "a"},
+			{"main", 20, 60, "bc"},
+			{"feature/ABC-1", 60, 70, "d"},
+			{"main", 170, 200, "e"},
+			{"main", 370, 400, "f"},
+		}},
+		{"long gap", Options{Gap: 3 * time.Hour, Lead: 10 * time.Minute}, []session{
+			{"main", -10, 60, "abc"},
+			{"feature/ABC-1", 60, 70, "d"},
+			{"main", 190, 200, "e"},
+			{"main", 390, 400, "f"},
+		}},
+		// Single commits have no duration without a lead, and are dropped
+		{"no lead", Options{Lead: -1}, []session{
+			{"main", 0, 60, "abc"},
+		}},
+	}
+
+	for _, test := range tests {
+		got := Sessions(commits, test.options)
+		var want []Session
+		for _, s := range test.want {
+			w := Session{Repo: "web", Branch: s.branch, Start: at(s.start), Stop: at(s.stop)}
+			if s.hashes == "f" {
+				w.Repo = "api"
+			}
+			for _, h := range s.hashes {
+				for _, c := range commits {
+					if c.Hash == string(h) {
+						w.Commits = append(w.Commits, c)
+					}
+				}
+			}
+			want = append(want, w)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, want)
+		}
+		for _, s := range got {
+			if s.Duration() <= 0 {
+				t.Errorf("%s: session of %v", test.name, s.Duration())
+			}
+		}
+	}
+}
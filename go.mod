@@ -1,3 +1,3 @@
 module github.com/Jberlinsky/go-toggl
 
-go 1.13
+go 1.18
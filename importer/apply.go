@@ -0,0 +1,290 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Defaults for Importer
+const (
+	// DefaultInterval keeps requests below the rate of one per second the
+	// Toggl API asks clients to stay under.
+	DefaultInterval   = time.Second
+	DefaultBatchSize  = 50
+	DefaultBatchPause = 10 * time.Second
+	// maxRetries is how many times a request is retried after the API
+	// asks the client to slow down or fails temporarily.
+	maxRetries = 4
+)
+
+// Importer applies plans.
+type Importer struct {
+	Session *toggl.Session
+	// Interval is the minimum time between requests.
+	Interval time.Duration
+	// BatchSize entries are created between pauses of BatchPause.
+	BatchSize  int
+	BatchPause time.Duration
+	// Progress, if not nil, records the entries created so that an
+	// interrupted import can be resumed.
+	Progress *Progress
+	// OnItem is called after an entry has been created, or has failed.
+	OnItem func(item Item, created toggl.TimeEntry, err error)
+
+	last time.Time
+}
+
+// Result summarizes an applied plan.
+type Result struct {
+	Created  int
+	Failed   int
+	Clients  int
+	Projects int
+	Tags     int
+}
+
+// Apply creates the clients, projects and tags of a plan, then the entries
+// of its items with ActionCreate. Failing to create an entity stops the
+// import; failing to create an entry is reported to OnItem and the import
+// continues, unless the Toggl API can't be reached.
+func (im *Importer) Apply(ctx context.Context, plan *Plan) (result Result, err error) {
+	for _, c := range plan.Clients {
+		if c.ID != 0 {
+			continue
+		}
+		var created toggl.Client
+		err = im.call(ctx, func() (err error) {
+			created, err = im.Session.CreateClient(c.Name, c.Workspace)
+			return err
+		})
+		if err != nil {
+			return result, fmt.Errorf("Unable to create client %q: %v", c.Name, err)
+		}
+		c.ID = created.ID
+		result.Clients++
+	}
+
+	for _, np := range plan.Projects {
+		if np.ID != 0 {
+			continue
+		}
+		if err = im.createProject(ctx, np); err != nil {
+			return result, fmt.Errorf("Unable to create project %q: %v", np.Title(), err)
+		}
+		result.Projects++
+	}
+
+	for _, t := range plan.Tags {
+		err = im.call(ctx, func() error {
+			_, err := im.Session.CreateTag(t.Name, t.Workspace)
+			return err
+		})
+		if err != nil {
+			return result, fmt.Errorf("Unable to create tag %q: %v", t.Name, err)
+		}
+		result.Tags++
+	}
+
+	batch := 0
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		if item.Action != ActionCreate {
+			continue
+		}
+		if batch == im.batchSize() {
+			if err = sleep(ctx, im.batchPause()); err != nil {
+				return result, err
+			}
+			batch = 0
+		}
+		batch++
+
+		if item.newProject != nil {
+			item.Entry.Pid = item.newProject.ID
+		}
+		var created toggl.TimeEntry
+		err = im.call(ctx, func() (err error) {
+			created, err = im.Session.CreateTimeEntry(item.Entry)
+			return err
+		})
+		if im.OnItem != nil {
+			im.OnItem(*item, created, err)
+		}
+
+		if err == nil {
+			result.Created++
+			if im.Progress != nil {
+				if err = im.Progress.Add(item.Record.Key(), created.ID); err != nil {
+					return result, fmt.Errorf("Unable to record progress: %v", err)
+				}
+			}
+			continue
+		}
+		result.Failed++
+		if _, ok := err.(*url.Error); ok || ctx.Err() != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// createProject creates a project, assigning it to its client.
+func (im *Importer) createProject(ctx context.Context, np *NewProject) error {
+	var created toggl.Project
+	err := im.call(ctx, func() (err error) {
+		created, err = im.Session.CreateProject(np.Name, np.Workspace)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	np.ID = created.ID
+
+	cid := np.ClientID
+	if np.NewClient != nil {
+		cid = np.NewClient.ID
+	}
+	if cid == 0 {
+		return nil
+	}
+	created.Cid = cid
+	return im.call(ctx, func() error {
+		_, err := im.Session.UpdateProject(created)
+		return err
+	})
+}
+
+// call makes a request, waiting first so that requests are at least
+// Interval apart. Requests the API rejects as too frequent, or that fail
+// with a server error, are retried with increasing delays.
+func (im *Importer) call(ctx context.Context, request func() error) error {
+	interval := im.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	delay := interval
+	for attempt := 0; ; attempt++ {
+		if err := sleep(ctx, time.Until(im.last.Add(interval))); err != nil {
+			return err
+		}
+		im.last = time.Now()
+
+		err := request()
+		apiErr, ok := err.(*toggl.APIError)
+		if !ok || attempt == maxRetries || (apiErr.StatusCode != 429 && apiErr.StatusCode < 500) {
+			return err
+		}
+
+		delay *= 2
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (im *Importer) batchSize() int {
+	if im.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return im.BatchSize
+}
+
+func (im *Importer) batchPause() time.Duration {
+	if im.BatchPause <= 0 {
+		return DefaultBatchPause
+	}
+	return im.BatchPause
+}
+
+// sleep waits for a duration, returning early with the context's error if
+// it's cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Progress is a log of the records an import has created entries for. It's
+// kept in a file, with a line holding the key of each record and the ID of
+// its entry, so that an interrupted import can be run again without
+// creating entries twice.
+type Progress struct {
+	mu   sync.Mutex
+	file *os.File
+	done map[string]int
+}
+
+// OpenProgress opens the progress log at path, creating it if it doesn't
+// exist.
+func OpenProgress(path string) (*Progress, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Progress{file: file, done: make(map[string]int)}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		id, _ := strconv.Atoi(fields[1])
+		p.done[fields[0]] = id
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Imported returns true if the record with the given key has been imported.
+func (p *Progress) Imported(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.done[key]
+	return ok
+}
+
+// Len returns the number of records imported.
+func (p *Progress) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.done)
+}
+
+// Add records that the record with the given key was imported as the entry
+// with the given ID. The log is flushed to disk before Add returns.
+func (p *Progress) Add(key string, id int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := fmt.Fprintf(p.file, "%s %d\n", key, id); err != nil {
+		return err
+	}
+	p.done[key] = id
+	return p.file.Sync()
+}
+
+// Close closes the log file.
+func (p *Progress) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,185 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// clockifyColumns are the columns of Clockify's detailed report.
+var clockifyColumns = map[string]string{
+	FieldDate:        "Start Date",
+	FieldStart:       "Start Time",
+	FieldStop:        "End Time",
+	FieldDuration:    "Duration (h)",
+	FieldDescription: "Description",
+	FieldClient:      "Client",
+	FieldProject:     "Project",
+	FieldTags:        "Tags",
+	FieldBillable:    "Billable",
+}
+
+// NewClockifyReader returns a reader for entries exported from Clockify,
+// either as the CSV of its detailed report or as JSON. The JSON can be the
+// time entries of its API, requested with hydrated=true so that they include
+// project and tag names, or the export of its detailed report. For CSV,
+// options can change the layout; dates default to Clockify's 01/02/2006 and
+// times to 03:04:05 PM.
+func NewClockifyReader(r io.Reader, options CSVOptions) (Reader, error) {
+	buffered := bufio.NewReader(r)
+	if first, err := peekNonSpace(buffered); err == nil && (first == '[' || first == '{') {
+		return newClockifyJSONReader(buffered)
+	}
+
+	options.Columns = mergeColumns(clockifyColumns, options.Columns)
+	if options.DateFormat == "" {
+		options.DateFormat = "01/02/2006"
+	}
+	if options.TimeFormat == "" {
+		options.TimeFormat = "03:04:05 PM"
+	}
+	return NewCSVReader(buffered, options)
+}
+
+// clockifyEntry is a time entry of Clockify's API or detailed report.
+type clockifyEntry struct {
+	Description string `json:"description"`
+	Billable    bool   `json:"billable"`
+	ProjectID   string `json:"projectId"`
+	// Projects are objects in the API and names in reports
+	Project *struct {
+		Name       string `json:"name"`
+		ClientName string `json:"clientName"`
+	} `json:"project"`
+	ProjectName string   `json:"projectName"`
+	ClientName  string   `json:"clientName"`
+	TagIDs      []string `json:"tagIds"`
+	Tags        []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+	TimeInterval struct {
+		Start time.Time  `json:"start"`
+		End   *time.Time `json:"end"`
+	} `json:"timeInterval"`
+}
+
+func newClockifyJSONReader(r io.Reader) (Reader, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []json.RawMessage
+	if first, _ := peekNonSpace(bufio.NewReader(bytes.NewReader(data))); first == '{' {
+		// A detailed report holds its entries with its totals
+		var report struct {
+			TimeEntries []json.RawMessage `json:"timeentries"`
+		}
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("Invalid Clockify export: %v", err)
+		}
+		entries = report.TimeEntries
+	} else if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("Invalid Clockify export: %v", err)
+	}
+
+	list := &recordList{}
+	for i, raw := range entries {
+		record, err := parseClockifyEntry(raw)
+		record.Line = i + 1
+		list.add(record, err)
+	}
+	return list, nil
+}
+
+func parseClockifyEntry(raw json.RawMessage) (Record, error) {
+	var e clockifyEntry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return Record{}, fmt.Errorf("Invalid entry: %v", err)
+	}
+
+	record := Record{
+		Description: e.Description,
+		Billable:    e.Billable,
+		Project:     e.ProjectName,
+		Client:      e.ClientName,
+		Start:       e.TimeInterval.Start,
+	}
+	if e.Project != nil {
+		record.Project, record.Client = e.Project.Name, e.Project.ClientName
+	}
+	if e.ProjectID != "" && record.Project == "" {
+		return record, fmt.Errorf("The entry's project has no name; export entries with hydrated=true")
+	}
+	if len(e.TagIDs) > len(e.Tags) {
+		return record, fmt.Errorf("The entry's tags have no names; export entries with hydrated=true")
+	}
+	for _, tag := range e.Tags {
+		record.addTag(tag.Name)
+	}
+
+	if record.Start.IsZero() {
+		return record, fmt.Errorf("The entry has no start time")
+	}
+	if e.TimeInterval.End == nil {
+		return record, fmt.Errorf("The entry is still running")
+	}
+	record.Duration = e.TimeInterval.End.Sub(record.Start)
+	if record.Duration <= 0 {
+		return record, fmt.Errorf("The entry has no duration")
+	}
+	return record, nil
+}
+
+// peekNonSpace returns the first byte of a reader that isn't white space,
+// without consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for n := 1; ; n++ {
+		b, err := r.Peek(n)
+		if len(b) < n {
+			return 0, err
+		}
+		switch c := b[n-1]; c {
+		case ' ', '\t', '\r', '\n':
+		default:
+			if n == 1 && c == 0xef {
+				// Skip a byte order mark
+				if bom, _ := r.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+					r.Discard(3)
+					n = 0
+					continue
+				}
+			}
+			return c, nil
+		}
+	}
+}
+
+// recordList is a Reader of records read in advance.
+type recordList struct {
+	records []Record
+	errs    []error
+	next    int
+}
+
+// add appends a record, or the error that kept it from being read.
+func (l *recordList) add(record Record, err error) {
+	if err != nil {
+		err = &LineError{Line: record.Line, Err: err}
+	}
+	l.records = append(l.records, record)
+	l.errs = append(l.errs, err)
+}
+
+// Read returns the next record.
+func (l *recordList) Read() (Record, error) {
+	if l.next == len(l.records) {
+		return Record{}, io.EOF
+	}
+	l.next++
+	return l.records[l.next-1], l.errs[l.next-1]
+}
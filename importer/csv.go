@@ -0,0 +1,378 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Fields of a record that can be read from CSV columns.
+const (
+	FieldDate        = "date"
+	FieldStart       = "start"
+	FieldStop        = "stop"
+	FieldDuration    = "duration"
+	FieldDescription = "description"
+	FieldProject     = "project"
+	FieldClient      = "client"
+	FieldWorkspace   = "workspace"
+	FieldTags        = "tags"
+	FieldBillable    = "billable"
+)
+
+// Fields lists the fields that can be read from CSV columns.
+var Fields = []string{FieldDate, FieldStart, FieldStop, FieldDuration, FieldDescription,
+	FieldProject, FieldClient, FieldWorkspace, FieldTags, FieldBillable}
+
+// defaultHeaders are the headers of the columns fields are read from when
+// they aren't mapped, matched ignoring case.
+var defaultHeaders = map[string][]string{
+	FieldDate:        {"date", "day", "start date"},
+	FieldStart:       {"start", "start time", "from", "begin"},
+	FieldStop:        {"stop", "end", "end time", "stop time", "to", "finish"},
+	FieldDuration:    {"duration", "hours", "time"},
+	FieldDescription: {"description", "notes", "note", "task", "activity"},
+	FieldProject:     {"project"},
+	FieldClient:      {"client", "customer"},
+	FieldWorkspace:   {"workspace"},
+	FieldTags:        {"tags", "tag", "labels"},
+	FieldBillable:    {"billable"},
+}
+
+// Duration formats for CSVOptions.DurationFormat
+const (
+	DurationAny     = ""
+	DurationSeconds = "seconds"
+	DurationMinutes = "minutes"
+	DurationHours   = "hours"
+)
+
+// timeLayouts are tried after CSVOptions.TimeFormat for times of day.
+var timeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04 pm", "3:04pm"}
+
+// CSVOptions describes the layout of a CSV file.
+type CSVOptions struct {
+	// Columns maps fields to the headers of the columns they're read from.
+	// Fields that aren't mapped are read from columns with common names,
+	// such as "Date", "Start", "End" or "Notes".
+	Columns map[string]string
+	// DateFormat is the layout of dates, as for time.Parse. It defaults to
+	// "2006-01-02".
+	DateFormat string
+	// TimeFormat is the layout of start and stop times. It defaults to
+	// "15:04", and common 12- and 24-hour layouts are also accepted. Start
+	// and stop columns can hold a date and a time separated by a space or a
+	// T, or an RFC 3339 timestamp, in which case no date column is needed.
+	TimeFormat string
+	// DurationFormat is the unit of durations given as plain numbers:
+	// DurationSeconds, DurationMinutes or DurationHours. By default
+	// durations are read as by toggl.ParseDuration, so "1:30", "1.5" and
+	// "1h 30m" are all an hour and a half.
+	DurationFormat string
+	// Location is the time zone of dates and times without one. A nil
+	// Location means the local time zone.
+	Location *time.Location
+	// Comma is the field separator. It defaults to a comma.
+	Comma rune
+	// TagSeparator separates tags in the tags column. It defaults to a
+	// comma.
+	TagSeparator string
+}
+
+// CSVReader reads records from a CSV file with a header line.
+type CSVReader struct {
+	r       *csv.Reader
+	options CSVOptions
+	columns map[string]int
+	line    int
+}
+
+// NewCSVReader reads the header of a CSV file and returns a reader for its
+// records. It returns an error if the header lacks the columns needed to
+// tell when entries started and how long they were.
+func NewCSVReader(r io.Reader, options CSVOptions) (*CSVReader, error) {
+	if options.DateFormat == "" {
+		options.DateFormat = "2006-01-02"
+	}
+	if options.TimeFormat == "" {
+		options.TimeFormat = "15:04"
+	}
+	if options.Location == nil {
+		options.Location = time.Local
+	}
+	if options.TagSeparator == "" {
+		options.TagSeparator = ","
+	}
+	switch options.DurationFormat {
+	case DurationAny, DurationSeconds, DurationMinutes, DurationHours:
+	default:
+		return nil, fmt.Errorf("Unknown duration format %q", options.DurationFormat)
+	}
+
+	c := &CSVReader{r: csv.NewReader(r), options: options, columns: make(map[string]int)}
+	if options.Comma != 0 {
+		c.r.Comma = options.Comma
+	}
+	c.r.FieldsPerRecord = -1
+	c.r.TrimLeadingSpace = true
+
+	header, err := c.r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("The CSV file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.line = 1
+	if len(header) > 0 {
+		// Spreadsheets often start files with a byte order mark
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	for field, name := range options.Columns {
+		if _, ok := defaultHeaders[field]; !ok {
+			return nil, fmt.Errorf("Unknown field %q; fields are %s", field, strings.Join(Fields, ", "))
+		}
+		i := findHeader(header, name)
+		if i == -1 {
+			return nil, fmt.Errorf("No column named %q for the %s", name, field)
+		}
+		c.columns[field] = i
+	}
+	for _, field := range Fields {
+		if _, ok := c.columns[field]; ok {
+			continue
+		}
+		for _, name := range defaultHeaders[field] {
+			if i := findHeader(header, name); i != -1 {
+				c.columns[field] = i
+				break
+			}
+		}
+	}
+
+	if !c.has(FieldStart) && !c.has(FieldDate) {
+		return nil, fmt.Errorf("The CSV file needs a start or date column")
+	}
+	if !c.has(FieldStop) && !c.has(FieldDuration) {
+		return nil, fmt.Errorf("The CSV file needs a stop or duration column")
+	}
+	return c, nil
+}
+
+// Columns returns the index of the column each field is read from.
+func (c *CSVReader) Columns() map[string]int {
+	columns := make(map[string]int)
+	for field, i := range c.columns {
+		columns[field] = i
+	}
+	return columns
+}
+
+// Read reads the next record, skipping blank lines. Errors in a record are
+// returned as a *LineError, after which reading can continue.
+func (c *CSVReader) Read() (Record, error) {
+	for {
+		row, err := c.r.Read()
+		if err != nil {
+			return Record{}, err
+		}
+		// The csv package skips empty lines, and quoted fields can span
+		// lines, so lines aren't counted
+		c.line, _ = c.r.FieldPos(0)
+		if isBlank(row) {
+			continue
+		}
+
+		record, err := c.parse(row)
+		if err != nil {
+			return Record{}, &LineError{Line: c.line, Err: err}
+		}
+		return record, nil
+	}
+}
+
+func (c *CSVReader) parse(row []string) (Record, error) {
+	o := c.options
+	record := Record{
+		Line:        c.line,
+		Description: c.get(row, FieldDescription),
+		Workspace:   c.get(row, FieldWorkspace),
+		Client:      c.get(row, FieldClient),
+		Project:     c.get(row, FieldProject),
+	}
+
+	var day time.Time
+	if s := c.get(row, FieldDate); s != "" {
+		d, err := time.ParseInLocation(o.DateFormat, s, o.Location)
+		if err != nil {
+			return record, fmt.Errorf("Invalid date %q; expected the format %s", s, o.DateFormat)
+		}
+		day = d
+	}
+
+	start, err := c.parseMoment(c.get(row, FieldStart), day)
+	if err != nil {
+		return record, fmt.Errorf("Invalid start time: %v", err)
+	}
+	stop, err := c.parseMoment(c.get(row, FieldStop), day)
+	if err != nil {
+		return record, fmt.Errorf("Invalid stop time: %v", err)
+	}
+	duration, err := c.parseDuration(c.get(row, FieldDuration))
+	if err != nil {
+		return record, err
+	}
+
+	switch {
+	case start.IsZero() && day.IsZero():
+		return record, fmt.Errorf("No start time or date")
+	case start.IsZero():
+		// Only the day is known
+		record.Start = day
+		record.DurationOnly = true
+	default:
+		record.Start = start
+	}
+
+	switch {
+	case !stop.IsZero() && !record.DurationOnly:
+		if stop.Before(record.Start) {
+			// An entry running past midnight
+			stop = stop.AddDate(0, 0, 1)
+		}
+		record.Duration = stop.Sub(record.Start)
+	case duration != 0:
+		record.Duration = duration
+	default:
+		return record, fmt.Errorf("No stop time or duration")
+	}
+	if record.Duration <= 0 {
+		return record, fmt.Errorf("The entry has no duration")
+	}
+
+	if s := c.get(row, FieldTags); s != "" {
+		for _, tag := range strings.Split(s, o.TagSeparator) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				record.Tags = append(record.Tags, tag)
+			}
+		}
+	}
+	if s := c.get(row, FieldBillable); s != "" {
+		billable, err := parseBool(s)
+		if err != nil {
+			return record, err
+		}
+		record.Billable = billable
+	}
+	return record, nil
+}
+
+// parseMoment parses a start or stop time, which is either a time of day on
+// the given day or includes its own date. An empty string is a zero time.
+func (c *CSVReader) parseMoment(s string, day time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	o := c.options
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, sep := range []string{" ", "T"} {
+		for _, layout := range append([]string{o.TimeFormat}, timeLayouts...) {
+			if t, err := time.ParseInLocation(o.DateFormat+sep+layout, s, o.Location); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	for _, layout := range append([]string{o.TimeFormat}, timeLayouts...) {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		if day.IsZero() {
+			return time.Time{}, fmt.Errorf("%q has no date, and there's no date column", s)
+		}
+		y, m, d := day.Date()
+		return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, o.Location), nil
+	}
+	return time.Time{}, fmt.Errorf("%q isn't a time in the format %s", s, o.TimeFormat)
+}
+
+func (c *CSVReader) parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	var unit time.Duration
+	switch c.options.DurationFormat {
+	case DurationSeconds:
+		unit = time.Second
+	case DurationMinutes:
+		unit = time.Minute
+	case DurationHours:
+		unit = time.Hour
+	default:
+		d, err := toggl.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid duration %q", s)
+		}
+		return d, nil
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid duration %q; expected a number of %s", s, c.options.DurationFormat)
+	}
+	return time.Duration(n * float64(unit)).Round(time.Second), nil
+}
+
+func (c *CSVReader) has(field string) bool {
+	_, ok := c.columns[field]
+	return ok
+}
+
+// get returns the value of a field in a row, or an empty string if the
+// field isn't mapped.
+func (c *CSVReader) get(row []string, field string) string {
+	i, ok := c.columns[field]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func findHeader(header []string, name string) int {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "1", "y", "yes", "true", "billable":
+		return true, nil
+	case "0", "n", "no", "false", "non-billable", "not billable":
+		return false, nil
+	}
+	return false, fmt.Errorf("Invalid billable value %q", s)
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVReader(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name    string
+		options CSVOptions
+		csv     string
+		// want is start, duration and description of each record, or the
+		// error of a line
+		want []string
+	}{
+		{
+			name: "default headers",
+			csv: "\xef\xbb\xbfDate,Start,End,Notes,Project,Client,Tags,Billable\n" +
+				"2026-10-12,09:00,10:30,Fix login,Website,Acme,\"dev, bug\",yes\n" +
+				"2026-10-12,23:30,00:15,Deploy,,,,\n" +
+				"2026-10-13,9:15 AM,11:00,Review,,,,maybe\n",
+			want: []string{
+				"2026-10-12 09:00 1h30m0s Fix login",
+				"2026-10-12 23:30 45m0s Deploy",
+				`Line 4: Invalid billable value "maybe"`,
+			},
+		},
+		{
+			name:    "mapped columns",
+			options: CSVOptions{Columns: map[string]string{FieldStart: "When", FieldDuration: "Minutes", FieldDescription: "What"}, DurationFormat: DurationMinutes, Comma: ';'},
+			csv:     "When;Minutes;What\n2026-10-12 14:00;90;Meeting\n2026-10-12T16:00:00Z;15.5;Call\n2026-10-12 17:00;-5;Negative\n",
+			want: []string{
+				"2026-10-12 14:00 1h30m0s Meeting",
+				"2026-10-12 18:00 15m30s Call",
+				`Line 4: Invalid duration "-5"; expected a number of minutes`,
+			},
+		},
+		{
+			name:    "date only",
+			options: CSVOptions{DateFormat: "02.01.2006"},
+			csv:     "Day,Hours,Activity\n12.10.2026,1:45,Writing\n13.10.2026,,Nothing\n2026-10-14,1,Wrong date\n",
+			want: []string{
+				"2026-10-12 00:00 1h45m0s Writing",
+				"Line 3: No stop time or duration",
+				`Line 4: Invalid date "2026-10-14"; expected the format 02.01.2006`,
+			},
+		},
+		{
+			name: "multi-line field",
+			csv:  "Start,Duration,Description\n2026-10-12 08:00,1h,\"Two\nlines\"\n\n2026-10-12 09:00,,Missing\n",
+			want: []string{
+				"2026-10-12 08:00 1h0m0s Two\nlines",
+				"Line 5: No stop time or duration",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.options.Location = berlin
+			r, err := NewCSVReader(strings.NewReader(test.csv), test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for {
+				record, err := r.Read()
+				if err != nil {
+					if _, ok := err.(*LineError); !ok {
+						break
+					}
+					got = append(got, err.Error())
+					continue
+				}
+				got = append(got, record.Start.In(berlin).Format("2006-01-02 15:04")+" "+record.Duration.String()+" "+record.Description)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCSVReaderFields(t *testing.T) {
+	r, err := NewCSVReader(strings.NewReader("Date,Start,End,Notes,Project,Client,Tags,Billable\n2026-10-12,09:00,10:30,Fix login,Website,Acme,\"dev, bug\",yes\n"), CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Project != "Website" || record.Client != "Acme" || strings.Join(record.Tags, "|") != "dev|bug" || !record.Billable || record.DurationOnly {
+		t.Errorf("record = %+v", record)
+	}
+}
+
+func TestCSVReaderHeaderErrors(t *testing.T) {
+	tests := []struct {
+		csv     string
+		options CSVOptions
+		err     string
+	}{
+		{"", CSVOptions{}, "The CSV file is empty"},
+		{"Description,Duration\n", CSVOptions{}, "The CSV file needs a start or date column"},
+		{"Date,Description\n", CSVOptions{}, "The CSV file needs a stop or duration column"},
+		{"Date,Hours\n", CSVOptions{Columns: map[string]string{FieldDescription: "Summary"}}, `No column named "Summary" for the description`},
+		{"Date,Hours\n", CSVOptions{Columns: map[string]string{"colour": "Date"}}, `Unknown field "colour"`},
+		{"Date,Hours\n", CSVOptions{DurationFormat: "days"}, `Unknown duration format "days"`},
+	}
+	for _, test := range tests {
+		_, err := NewCSVReader(strings.NewReader(test.csv), test.options)
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("NewCSVReader(%q) = %v, want %s", test.csv, err, test.err)
+		}
+	}
+}
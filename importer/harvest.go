@@ -0,0 +1,38 @@
+package importer
+
+import "io"
+
+// harvestColumns are the columns of Harvest's detailed time report.
+var harvestColumns = map[string]string{
+	FieldDate:        "Date",
+	FieldClient:      "Client",
+	FieldProject:     "Project",
+	FieldDescription: "Notes",
+	FieldDuration:    "Hours",
+	FieldTags:        "Task",
+	FieldBillable:    "Billable?",
+}
+
+// NewHarvestReader returns a reader for the CSV export of Harvest's detailed
+// time report. Harvest records how long entries were but not when they
+// started, so records are DurationOnly. An entry's task becomes a tag.
+// options can change the layout, such as DateFormat for accounts that don't
+// export ISO dates, and Columns are added to the columns of the export.
+func NewHarvestReader(r io.Reader, options CSVOptions) (*CSVReader, error) {
+	options.Columns = mergeColumns(harvestColumns, options.Columns)
+	// A task is a single tag, even if its name has a comma
+	options.TagSeparator = "\n"
+	return NewCSVReader(r, options)
+}
+
+// mergeColumns returns the columns of an export, overridden by columns.
+func mergeColumns(export, columns map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for field, name := range export {
+		merged[field] = name
+	}
+	for field, name := range columns {
+		merged[field] = name
+	}
+	return merged
+}
@@ -0,0 +1,95 @@
+/*
+Package importer imports time entries recorded elsewhere into Toggl.
+
+An import has three steps. A Reader reads Records: time entries with names
+rather than Toggl IDs. There are readers for CSV files of any layout, and for
+the exports of Harvest, Clockify and Timewarrior. NewPlan maps the names with
+Rules and resolves them against the user's account, decides which projects,
+clients and tags need creating, lists the names that match nothing, and marks
+records that duplicate existing entries, so that the plan can be previewed
+before anything changes. An Importer then applies the plan,
+creating entities and entries at a pace the Toggl API accepts, and records
+its progress so an interrupted import can be resumed.
+*/
+package importer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Record is a time entry read from another source.
+type Record struct {
+	// Line is the position of the record in its source, used in messages.
+	Line        int
+	Start       time.Time
+	Duration    time.Duration
+	Description string
+	Workspace   string
+	Client      string
+	Project     string
+	Tags        []string
+	Billable    bool
+	// DurationOnly is true if the source only recorded the day and
+	// duration of the entry, and not when it started.
+	DurationOnly bool
+}
+
+// Stop returns the time the entry ended.
+func (r Record) Stop() time.Time {
+	return r.Start.Add(r.Duration)
+}
+
+// Key identifies a record by its contents, so that it's recognized when an
+// import is run again.
+func (r Record) Key() string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%d\x00%d\x00%s\x00%s\x00%s\x00%s\x00%s\x00%t",
+		r.Start.Unix(), int64(r.Duration/time.Second), r.Description,
+		strings.ToLower(r.Workspace), strings.ToLower(r.Client), strings.ToLower(r.Project),
+		strings.ToLower(strings.Join(r.Tags, ",")), r.Billable)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Reader reads records from a source. Read returns io.EOF after the last
+// record, and a *LineError for a record that can't be read, after which
+// reading can continue.
+type Reader interface {
+	Read() (Record, error)
+}
+
+// ReadAll reads the remaining records from a reader. Records with errors
+// are skipped and their *LineErrors returned as invalid, so that all of a
+// source's problems can be reported at once; err is an error that stopped
+// reading.
+func ReadAll(r Reader) (records []Record, invalid []*LineError, err error) {
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, invalid, nil
+		}
+		if lineErr, ok := err.(*LineError); ok {
+			invalid = append(invalid, lineErr)
+			continue
+		}
+		if err != nil {
+			return records, invalid, err
+		}
+		records = append(records, record)
+	}
+}
+
+// LineError is an error in a record of a source.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+// Error describes the error and where it is.
+func (e *LineError) Error() string {
+	return fmt.Sprintf("Line %d: %v", e.Line, e.Err)
+}
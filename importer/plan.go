@@ -0,0 +1,415 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Actions planned for records
+const (
+	// ActionCreate creates an entry for the record.
+	ActionCreate = "create"
+	// ActionDuplicate skips a record that duplicates an existing entry or
+	// an earlier record.
+	ActionDuplicate = "duplicate"
+	// ActionImported skips a record imported by an earlier run.
+	ActionImported = "imported"
+	// ActionError skips a record that can't be imported.
+	ActionError = "error"
+)
+
+// DefaultTolerance is how far apart the start times and durations of
+// duplicate entries can be when Options.Tolerance is zero.
+const DefaultTolerance = time.Minute
+
+// Options controls how records are matched to the account.
+type Options struct {
+	// Workspace is the ID of the workspace of records that don't name one.
+	// Zero means the account's first workspace.
+	Workspace int
+	// Create allows creating the clients, projects and tags records name
+	// that don't exist. Otherwise such records are errors.
+	Create bool
+	// Imported returns true if the record with the given key was imported
+	// by an earlier run, as recorded by a Progress.
+	Imported func(key string) bool
+	// Tolerance is how far apart the start times and durations of entries
+	// can be for them to be duplicates.
+	Tolerance time.Duration
+	// Rules map the names records use before they're matched.
+	Rules Rules
+}
+
+// Item is the action planned for a record.
+type Item struct {
+	Record Record `json:"record"`
+	Action string `json:"action"`
+	// Entry is the entry to create. If the plan creates its project, Pid
+	// is set when the plan is applied.
+	Entry toggl.TimeEntry `json:"entry"`
+	// Project is the title of the entry's project.
+	Project string `json:"project,omitempty"`
+	// Reason explains why a record is skipped.
+	Reason string `json:"reason,omitempty"`
+
+	newProject *NewProject
+}
+
+// NewClient is a client a plan creates.
+type NewClient struct {
+	Workspace int    `json:"wid"`
+	Name      string `json:"name"`
+	// ID is set once the client has been created.
+	ID int `json:"id,omitempty"`
+}
+
+// NewProject is a project a plan creates.
+type NewProject struct {
+	Workspace int    `json:"wid"`
+	Name      string `json:"name"`
+	// Client is the project's client, if it has one. Either ClientID is
+	// an existing client or NewClient is created by the plan.
+	Client    string     `json:"client,omitempty"`
+	ClientID  int        `json:"cid,omitempty"`
+	NewClient *NewClient `json:"-"`
+	// ID is set once the project has been created.
+	ID int `json:"id,omitempty"`
+}
+
+// NewTag is a tag a plan creates.
+type NewTag struct {
+	Workspace int    `json:"wid"`
+	Name      string `json:"name"`
+}
+
+// Plan describes what an import will do.
+type Plan struct {
+	Items    []Item        `json:"items"`
+	Clients  []*NewClient  `json:"clients,omitempty"`
+	Projects []*NewProject `json:"projects,omitempty"`
+	Tags     []*NewTag     `json:"tags,omitempty"`
+	// Unmapped lists the names that match nothing in the account and
+	// aren't created, in the order records use them.
+	Unmapped []*Unmapped `json:"unmapped,omitempty"`
+}
+
+// Unmapped is a workspace, project or tag name records use that matches
+// nothing in the account. Rules can map it to a name that does.
+type Unmapped struct {
+	Kind string `json:"kind"`
+	// Name is the name after rules are applied; projects include their
+	// client, as in "Acme/Website".
+	Name string `json:"name"`
+	// Records is the number of records using the name.
+	Records int `json:"records"`
+}
+
+// Count returns the number of items with the given action.
+func (p *Plan) Count(action string) int {
+	n := 0
+	for _, item := range p.Items {
+		if item.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Range returns the time range covered by records, for retrieving the
+// existing entries they could duplicate.
+func Range(records []Record) (start, end time.Time) {
+	for i, r := range records {
+		if i == 0 || r.Start.Before(start) {
+			start = r.Start
+		}
+		if i == 0 || r.Stop().After(end) {
+			end = r.Stop()
+		}
+	}
+	return start, end
+}
+
+// planner holds the state of NewPlan.
+type planner struct {
+	account  toggl.Account
+	options  Options
+	plan     *Plan
+	existing []toggl.TimeEntry
+}
+
+// NewPlan matches records to an account. Workspaces, clients, projects and
+// tags are matched by name after applying Options.Rules, ignoring case but
+// otherwise exactly, so that an import never guesses. Records are duplicates if an existing entry or an
+// earlier record has the same description and starts and lasts about as
+// long.
+func NewPlan(account toggl.Account, existing []toggl.TimeEntry, records []Record, options Options) *Plan {
+	if options.Tolerance <= 0 {
+		options.Tolerance = DefaultTolerance
+	}
+	if options.Workspace == 0 && len(account.Data.Workspaces) > 0 {
+		options.Workspace = account.Data.Workspaces[0].ID
+	}
+
+	p := &planner{account: account, options: options, plan: &Plan{}}
+	for _, e := range existing {
+		if e.ServerDeletedAt == nil && !e.IsRunning() && e.Start != nil {
+			p.existing = append(p.existing, e)
+		}
+	}
+
+	sorted := make([]Record, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	for _, r := range sorted {
+		p.plan.Items = append(p.plan.Items, p.item(r))
+	}
+	return p.plan
+}
+
+func (p *planner) item(r Record) Item {
+	item := Item{Record: r, Action: ActionCreate}
+	if p.options.Imported != nil && p.options.Imported(r.Key()) {
+		item.Action = ActionImported
+		return item
+	}
+	if err := p.resolve(&item); err != nil {
+		item.Action = ActionError
+		item.Reason = err.Error()
+		return item
+	}
+
+	if dup := p.duplicate(item.Entry); dup != nil {
+		item.Action = ActionDuplicate
+		item.Reason = fmt.Sprintf("Duplicates entry %d", dup.ID)
+		return item
+	}
+	for _, earlier := range p.plan.Items {
+		if earlier.Action == ActionCreate && p.same(earlier.Entry, item.Entry) {
+			item.Action = ActionDuplicate
+			item.Reason = fmt.Sprintf("Duplicates line %d", earlier.Record.Line)
+			return item
+		}
+	}
+	return item
+}
+
+// resolve fills in the entry of an item.
+func (p *planner) resolve(item *Item) error {
+	r := p.options.Rules.Apply(item.Record)
+	start, stop := r.Start, r.Stop()
+	entry := toggl.TimeEntry{
+		Wid:         p.options.Workspace,
+		Description: r.Description,
+		Start:       &start,
+		Stop:        &stop,
+		Duration:    int64(r.Duration / time.Second),
+		DurOnly:     r.DurationOnly,
+	}
+	if r.Billable {
+		entry.Billable = 1
+	}
+
+	if r.Workspace != "" {
+		w := p.workspace(r.Workspace)
+		if w == nil {
+			p.unmapped(KindWorkspace, r.Workspace)
+			return fmt.Errorf("No workspace named %q", r.Workspace)
+		}
+		entry.Wid = w.ID
+	}
+	if entry.Wid == 0 {
+		return fmt.Errorf("The account has no workspace")
+	}
+
+	// The project and every tag are resolved, even after an error, so that
+	// all of the names that match nothing are reported
+	var firstErr error
+	if r.Project != "" {
+		firstErr = p.resolveProject(item, r, &entry)
+	} else if r.Client != "" {
+		firstErr = fmt.Errorf("Client %q is given without a project", r.Client)
+	}
+	for _, name := range r.Tags {
+		tag, err := p.tag(name, entry.Wid)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		entry.AddTag(tag)
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	item.Entry = entry
+	return nil
+}
+
+func (p *planner) resolveProject(item *Item, r Record, entry *toggl.TimeEntry) error {
+	var client *toggl.Client
+	if r.Client != "" {
+		client = p.client(r.Client, entry.Wid)
+	}
+
+	var matches []toggl.Project
+	for _, project := range p.account.Data.Projects {
+		if project.Wid != entry.Wid || !project.IsActive() || !strings.EqualFold(project.Name, r.Project) {
+			continue
+		}
+		if r.Client != "" && (client == nil || project.Cid != client.ID) {
+			continue
+		}
+		matches = append(matches, project)
+	}
+
+	switch len(matches) {
+	case 1:
+		entry.Pid = matches[0].ID
+		item.Project = p.account.ProjectTitle(matches[0])
+		if matches[0].Billable != 0 && !r.Billable {
+			entry.Billable = matches[0].Billable
+		}
+		return nil
+	case 0:
+	default:
+		var titles []string
+		for _, m := range matches {
+			titles = append(titles, p.account.ProjectTitle(m))
+		}
+		return &toggl.AmbiguityError{Kind: "project", Name: r.Project, Matches: titles}
+	}
+
+	// Projects created by earlier records
+	for _, np := range p.plan.Projects {
+		if np.Workspace == entry.Wid && strings.EqualFold(np.Name, r.Project) && strings.EqualFold(np.Client, r.Client) {
+			item.newProject = np
+			item.Project = np.Title()
+			return nil
+		}
+	}
+
+	title := r.Project
+	if r.Client != "" {
+		title = r.Client + "/" + r.Project
+	}
+	if !p.options.Create {
+		p.unmapped(KindProject, title)
+		return fmt.Errorf("No project named %q", title)
+	}
+
+	np := &NewProject{Workspace: entry.Wid, Name: r.Project}
+	if r.Client != "" {
+		if client != nil {
+			np.Client, np.ClientID = client.Name, client.ID
+		} else {
+			np.Client, np.NewClient = r.Client, p.newClient(r.Client, entry.Wid)
+		}
+	}
+	p.plan.Projects = append(p.plan.Projects, np)
+	item.newProject = np
+	item.Project = np.Title()
+	return nil
+}
+
+func (p *planner) newClient(name string, wid int) *NewClient {
+	for _, c := range p.plan.Clients {
+		if c.Workspace == wid && strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	c := &NewClient{Workspace: wid, Name: name}
+	p.plan.Clients = append(p.plan.Clients, c)
+	return c
+}
+
+func (p *planner) workspace(name string) *toggl.Workspace {
+	for i, w := range p.account.Data.Workspaces {
+		if w.ServerDeletedAt == nil && strings.EqualFold(w.Name, name) {
+			return &p.account.Data.Workspaces[i]
+		}
+	}
+	return nil
+}
+
+func (p *planner) client(name string, wid int) *toggl.Client {
+	for i, c := range p.account.Data.Clients {
+		if c.ServerDeletedAt == nil && c.Wid == wid && strings.EqualFold(c.Name, name) {
+			return &p.account.Data.Clients[i]
+		}
+	}
+	return nil
+}
+
+// tag returns the account's spelling of a tag, planning to create it if
+// it's missing.
+func (p *planner) tag(name string, wid int) (string, error) {
+	for _, t := range p.account.Data.Tags {
+		if t.ServerDeletedAt == nil && t.Wid == wid && strings.EqualFold(t.Name, name) {
+			return t.Name, nil
+		}
+	}
+	for _, t := range p.plan.Tags {
+		if t.Workspace == wid && strings.EqualFold(t.Name, name) {
+			return t.Name, nil
+		}
+	}
+	if !p.options.Create {
+		p.unmapped(KindTag, name)
+		return "", fmt.Errorf("No tag named %q", name)
+	}
+	p.plan.Tags = append(p.plan.Tags, &NewTag{Workspace: wid, Name: name})
+	return name, nil
+}
+
+// unmapped counts a record using a name that matches nothing.
+func (p *planner) unmapped(kind, name string) {
+	for _, u := range p.plan.Unmapped {
+		if u.Kind == kind && strings.EqualFold(u.Name, name) {
+			u.Records++
+			return
+		}
+	}
+	p.plan.Unmapped = append(p.plan.Unmapped, &Unmapped{Kind: kind, Name: name, Records: 1})
+}
+
+// duplicate returns the existing entry an entry duplicates, if any.
+func (p *planner) duplicate(entry toggl.TimeEntry) *toggl.TimeEntry {
+	for i := range p.existing {
+		if p.same(p.existing[i], entry) {
+			return &p.existing[i]
+		}
+	}
+	return nil
+}
+
+// same returns true if two entries are duplicates.
+func (p *planner) same(a, b toggl.TimeEntry) bool {
+	tolerance := p.options.Tolerance
+	start := a.Start.Sub(*b.Start)
+	duration := time.Duration(a.Duration-b.Duration) * time.Second
+	return a.Wid == b.Wid && abs(start) <= tolerance && abs(duration) <= tolerance &&
+		strings.EqualFold(strings.TrimSpace(a.Description), strings.TrimSpace(b.Description))
+}
+
+// Title returns the project's name, prefixed with its client's name.
+func (np *NewProject) Title() string {
+	if np.Client != "" {
+		return np.Client + "/" + np.Name
+	}
+	return np.Name
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
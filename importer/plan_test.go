@@ -0,0 +1,180 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+func testAccount() toggl.Account {
+	var a toggl.Account
+	a.Data.Workspaces = []toggl.Workspace{{ID: 1, Name: "Work"}, {ID: 2, Name: "Side"}}
+	a.Data.Clients = []toggl.Client{{ID: 3, Wid: 1, Name: "Acme"}, {ID: 4, Wid: 1, Name: "Globex"}}
+	a.Data.Projects = []toggl.Project{
+		{ID: 10, Wid: 1, Cid: 3, Name: "Website", Active: true, Billable: 1},
+		{ID: 11, Wid: 1, Cid: 4, Name: "Website", Active: true},
+		{ID: 12, Wid: 1, Name: "Internal", Active: true},
+		{ID: 13, Wid: 2, Name: "Blog", Active: true},
+	}
+	a.Data.Tags = []toggl.Tag{{ID: 1, Wid: 1, Name: "Dev"}, {ID: 2, Wid: 2, Name: "writing"}}
+	return a
+}
+
+// at returns a time on 12 October 2026.
+func at(clock string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", "2026-10-12 "+clock)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// summary describes the items of a plan, one to a line.
+func summary(plan *Plan) string {
+	var lines []string
+	for _, item := range plan.Items {
+		line := fmt.Sprintf("%d %s", item.Record.Line, item.Action)
+		switch item.Action {
+		case ActionCreate:
+			line += fmt.Sprintf(" wid=%d pid=%d %s [%s] billable=%v", item.Entry.Wid, item.Entry.Pid, item.Project,
+				strings.Join(item.Entry.Tags, ","), item.Entry.Billable)
+		default:
+			line += ": " + item.Reason
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestNewPlan(t *testing.T) {
+	existing := []toggl.TimeEntry{
+		{ID: 500, Wid: 1, Description: "Standup", Start: timePtr(at("09:00")), Duration: 900},
+		{ID: 501, Wid: 1, Description: "Deleted", Start: timePtr(at("13:00")), Duration: 900, ServerDeletedAt: timePtr(at("14:00"))},
+	}
+	records := []Record{
+		{Line: 1, Start: at("10:00"), Duration: time.Hour, Description: "Fix login", Client: "acme", Project: "website", Tags: []string{"dev"}},
+		{Line: 2, Start: at("09:00").Add(30 * time.Second), Duration: 15 * time.Minute, Description: " standup "},
+		{Line: 3, Start: at("10:00"), Duration: time.Hour, Description: "fix login", Client: "Acme", Project: "Website", Tags: []string{"Dev"}},
+		{Line: 4, Start: at("11:00"), Duration: time.Hour, Description: "Which website?", Project: "Website"},
+		{Line: 5, Start: at("12:00"), Duration: time.Hour, Description: "Unknown", Project: "Mobile", Tags: []string{"ios", "dev"}},
+		{Line: 6, Start: at("13:00"), Duration: 15 * time.Minute, Description: "Deleted", Workspace: "side", Project: "Blog", Tags: []string{"Writing"}},
+		{Line: 7, Start: at("14:00"), Duration: time.Hour, Description: "Elsewhere", Workspace: "Home"},
+		{Line: 8, Start: at("15:00"), Duration: time.Hour, Description: "Imported"},
+		{Line: 9, Start: at("08:00"), Duration: time.Hour, Description: "Orphan client", Client: "Acme"},
+		{Line: 10, Start: at("16:00"), Duration: time.Hour, Description: "More", Project: "Mobile"},
+	}
+	imported := records[7].Key()
+
+	plan := NewPlan(testAccount(), existing, records, Options{
+		Imported: func(key string) bool { return key == imported },
+	})
+	want := `9 error: Client "Acme" is given without a project
+2 duplicate: Duplicates entry 500
+1 create wid=1 pid=10 Acme/Website [Dev] billable=1
+3 duplicate: Duplicates line 1
+4 error: project "Website" is ambiguous: Acme/Website, Globex/Website
+5 error: No project named "Mobile"
+6 create wid=2 pid=13 Blog [writing] billable=0
+7 error: No workspace named "Home"
+8 imported: 
+10 error: No project named "Mobile"`
+	if got := summary(plan); got != want {
+		t.Errorf("plan:\n%s\nwant:\n%s", got, want)
+	}
+
+	var unmapped []string
+	for _, u := range plan.Unmapped {
+		unmapped = append(unmapped, fmt.Sprintf("%s %s %d", u.Kind, u.Name, u.Records))
+	}
+	if got := strings.Join(unmapped, "; "); got != "project Mobile 2; tag ios 1; workspace Home 1" {
+		t.Errorf("unmapped: %s", got)
+	}
+	if len(plan.Clients)+len(plan.Projects)+len(plan.Tags) != 0 {
+		t.Errorf("plan creates entities without Options.Create")
+	}
+	if plan.Count(ActionError) != 5 || plan.Count(ActionCreate) != 2 {
+		t.Errorf("counts: %d errors, %d to create", plan.Count(ActionError), plan.Count(ActionCreate))
+	}
+}
+
+func TestNewPlanCreate(t *testing.T) {
+	records := []Record{
+		{Line: 1, Start: at("09:00"), Duration: time.Hour, Description: "App", Client: "Initech", Project: "Mobile", Tags: []string{"ios"}},
+		{Line: 2, Start: at("10:00"), Duration: time.Hour, Description: "More app", Client: "initech", Project: "mobile", Tags: []string{"IOS"}},
+		{Line: 3, Start: at("11:00"), Duration: time.Hour, Description: "Acme app", Client: "Acme", Project: "Mobile"},
+		{Line: 4, Start: at("12:00"), Duration: time.Hour, Description: "Tagged", Tags: []string{"label"}},
+	}
+	plan := NewPlan(testAccount(), nil, records, Options{Create: true})
+
+	if plan.Count(ActionCreate) != 4 {
+		t.Fatalf("plan:\n%s", summary(plan))
+	}
+	if len(plan.Clients) != 1 || plan.Clients[0].Name != "Initech" {
+		t.Errorf("clients: %+v", plan.Clients)
+	}
+	var projects []string
+	for _, np := range plan.Projects {
+		projects = append(projects, fmt.Sprintf("%s cid=%d new=%t", np.Title(), np.ClientID, np.NewClient != nil))
+	}
+	if got := strings.Join(projects, "; "); got != "Initech/Mobile cid=0 new=true; Acme/Mobile cid=3 new=false" {
+		t.Errorf("projects: %s", got)
+	}
+	var tags []string
+	for _, tag := range plan.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if got := strings.Join(tags, ","); got != "ios,label" {
+		t.Errorf("tags: %s", got)
+	}
+	if plan.Items[1].Entry.Tags[0] != "ios" || plan.Items[1].Project != "Initech/Mobile" {
+		t.Errorf("second record doesn't reuse the planned project and tag: %+v", plan.Items[1])
+	}
+}
+
+func TestNewPlanRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader("tag web = project Acme/Website\nproject Old = Internal\ntag development = Dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []Record{
+		{Line: 1, Start: at("09:00"), Duration: time.Hour, Tags: []string{"web", "development"}},
+		{Line: 2, Start: at("10:00"), Duration: time.Hour, Project: "Old"},
+	}
+	plan := NewPlan(testAccount(), nil, records, Options{Rules: rules})
+	want := `1 create wid=1 pid=10 Acme/Website [Dev] billable=1
+2 create wid=1 pid=12 Internal [] billable=0`
+	if got := summary(plan); got != want {
+		t.Errorf("plan:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNewPlanTolerance(t *testing.T) {
+	existing := []toggl.TimeEntry{{ID: 500, Wid: 1, Description: "Standup", Start: timePtr(at("09:00")), Duration: 900}}
+	records := []Record{{Line: 1, Start: at("09:03"), Duration: 15 * time.Minute, Description: "Standup"}}
+
+	if plan := NewPlan(testAccount(), existing, records, Options{}); plan.Items[0].Action != ActionCreate {
+		t.Errorf("3 minutes apart is a duplicate by default")
+	}
+	if plan := NewPlan(testAccount(), existing, records, Options{Tolerance: 5 * time.Minute}); plan.Items[0].Action != ActionDuplicate {
+		t.Errorf("3 minutes apart isn't a duplicate with a tolerance of 5")
+	}
+}
+
+func TestRange(t *testing.T) {
+	records := []Record{
+		{Start: at("10:00"), Duration: time.Hour},
+		{Start: at("08:00"), Duration: 30 * time.Minute},
+		{Start: at("09:00"), Duration: 4 * time.Hour},
+	}
+	start, end := Range(records)
+	if !start.Equal(at("08:00")) || !end.Equal(at("13:00")) {
+		t.Errorf("Range = %v, %v", start, end)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
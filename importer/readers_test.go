@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestReaders(t *testing.T) {
+	utc := CSVOptions{Location: time.UTC}
+	tests := []struct {
+		file    string
+		open    func(io.Reader) (Reader, error)
+		records []Record
+		invalid []int
+	}{
+		{
+			file: "harvest.csv",
+			open: func(r io.Reader) (Reader, error) { return NewHarvestReader(r, utc) },
+			records: []Record{
+				{Line: 2, Start: date("2026-10-12T00:00:00Z"), Duration: 150 * time.Minute, Description: "Landing page, mobile",
+					Client: "Acme", Project: "Website Redesign", Tags: []string{"Design"}, Billable: true, DurationOnly: true},
+				{Line: 3, Start: date("2026-10-12T00:00:00Z"), Duration: 45 * time.Minute, Description: "Weekly sync",
+					Client: "Acme", Project: "Website Redesign", Tags: []string{"Meetings, calls"}, Billable: true, DurationOnly: true},
+				{Line: 4, Start: date("2026-10-13T00:00:00Z"), Duration: 75 * time.Minute,
+					Client: "Internal", Project: "Admin", Tags: []string{"Admin"}, DurationOnly: true},
+			},
+			invalid: []int{6},
+		},
+		{
+			file: "clockify.csv",
+			open: func(r io.Reader) (Reader, error) { return NewClockifyReader(r, utc) },
+			records: []Record{
+				{Line: 2, Start: date("2026-10-12T09:00:00Z"), Duration: 90 * time.Minute, Description: "Fix login",
+					Client: "Acme", Project: "Website", Tags: []string{"dev", "bug"}, Billable: true},
+				{Line: 3, Start: date("2026-10-12T23:45:00Z"), Duration: 30 * time.Minute, Description: "Email", Project: "Internal"},
+			},
+		},
+		{
+			file: "clockify-api.json",
+			open: func(r io.Reader) (Reader, error) { return NewClockifyReader(r, utc) },
+			records: []Record{
+				{Line: 1, Start: date("2026-10-12T09:00:00Z"), Duration: 90 * time.Minute, Description: "Fix login",
+					Client: "Acme", Project: "Website", Tags: []string{"dev", "bug"}, Billable: true},
+				{Line: 2, Start: date("2026-10-12T11:00:00Z"), Duration: 20 * time.Minute, Description: "Email"},
+			},
+			invalid: []int{3, 4},
+		},
+		{
+			file: "clockify-report.json",
+			open: func(r io.Reader) (Reader, error) { return NewClockifyReader(r, utc) },
+			records: []Record{
+				{Line: 1, Start: date("2026-10-12T07:00:00Z"), Duration: 90 * time.Minute, Description: "Fix login",
+					Client: "Acme", Project: "Website", Tags: []string{"dev"}, Billable: true},
+				{Line: 2, Start: date("2026-10-13T08:00:00Z"), Duration: 30 * time.Minute, Description: "Planning", Project: "Internal"},
+			},
+		},
+		{
+			file: "timewarrior.data",
+			open: NewTimewarriorReader,
+			records: []Record{
+				{Line: 1, Start: date("2026-10-12T09:00:00Z"), Duration: 90 * time.Minute, Description: "Fix login", Tags: []string{"review", "acme web"}},
+				{Line: 2, Start: date("2026-10-12T11:00:00Z"), Duration: 30 * time.Minute, Tags: []string{"email"}},
+				{Line: 4, Start: date("2026-10-12T13:00:00Z"), Duration: time.Hour, Description: "Call with Bob", Tags: []string{`say "hi"`}},
+			},
+			invalid: []int{5, 6},
+		},
+		{
+			file: "timewarrior.json",
+			open: NewTimewarriorReader,
+			records: []Record{
+				{Line: 1, Start: date("2026-10-12T09:00:00Z"), Duration: 90 * time.Minute, Description: "Fix login", Tags: []string{"review", "acme web"}},
+				{Line: 2, Start: date("2026-10-12T11:00:00Z"), Duration: 30 * time.Minute, Tags: []string{"email"}},
+			},
+			invalid: []int{3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			file, err := os.Open(filepath.Join("testdata", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			r, err := test.open(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			records, invalid, err := ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != len(test.records) {
+				t.Errorf("read %d records, want %d", len(records), len(test.records))
+			}
+			for i := 0; i < len(records) && i < len(test.records); i++ {
+				got, want := records[i], test.records[i]
+				if !got.Start.Equal(want.Start) {
+					t.Errorf("record %d starts at %v, want %v", i, got.Start, want.Start)
+				}
+				got.Start = want.Start
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("record %d:\ngot  %+v\nwant %+v", i, got, want)
+				}
+			}
+
+			var lines []int
+			for _, e := range invalid {
+				lines = append(lines, e.Line)
+			}
+			if !reflect.DeepEqual(lines, test.invalid) {
+				t.Errorf("invalid lines %v, want %v: %v", lines, test.invalid, invalid)
+			}
+		})
+	}
+}
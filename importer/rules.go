@@ -0,0 +1,187 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Kinds of names that rules map
+const (
+	KindWorkspace = "workspace"
+	KindClient    = "client"
+	KindProject   = "project"
+	KindTag       = "tag"
+)
+
+// Rule maps a client, project or tag name used by a source to the name used
+// in Toggl.
+type Rule struct {
+	// Kind and Name select what the rule matches: the client, project or a
+	// tag of a record whose name matches Name, ignoring case. Name can be a
+	// pattern as for path.Match, such as "acme-*". A project's Name can
+	// include its client, as in "Acme/Website".
+	Kind string
+	Name string
+	// Target is the kind of name the match becomes, usually Kind. Tags can
+	// become projects, for sources such as Timewarrior that only have tags.
+	Target string
+	// Value is the new name. A project can be given with its client, as in
+	// "Acme/Website". An empty Value drops the match.
+	Value string
+}
+
+// Rules are applied to records before they're matched to an account. The
+// first rule matching a name is used.
+type Rules []Rule
+
+// ParseRules reads rules, one to a line, in the form
+//
+//	KIND NAME = [TARGET] VALUE
+//
+// such as "project Website Redesign = Acme/Website", "tag dev = Development"
+// or "tag acme-* = project Acme/Support". Blank lines and lines starting
+// with # are ignored.
+func ParseRules(r io.Reader) (Rules, error) {
+	var rules Rules
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rule, err := parseRule(text)
+		if err != nil {
+			return nil, &LineError{Line: line, Err: err}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+func parseRule(s string) (Rule, error) {
+	i := strings.Index(s, "=")
+	if i == -1 {
+		return Rule{}, fmt.Errorf("Expected KIND NAME = [TARGET] VALUE")
+	}
+	left, right := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+
+	var rule Rule
+	rule.Kind, rule.Name = splitKind(left)
+	if rule.Kind == "" || rule.Kind == KindWorkspace {
+		return rule, fmt.Errorf("Rules start with client, project or tag")
+	}
+	if rule.Name == "" {
+		return rule, fmt.Errorf("The rule has no name to match")
+	}
+	if _, err := path.Match(rule.Name, ""); err != nil {
+		return rule, fmt.Errorf("Invalid pattern %q", rule.Name)
+	}
+
+	rule.Target, rule.Value = splitKind(right)
+	if rule.Target == "" || rule.Target == KindWorkspace {
+		rule.Target, rule.Value = rule.Kind, right
+	}
+	return rule, nil
+}
+
+// splitKind splits the kind a string starts with from the rest of it. The
+// kind is empty if the string doesn't start with one.
+func splitKind(s string) (kind, rest string) {
+	fields := strings.SplitN(s, " ", 2)
+	switch strings.ToLower(fields[0]) {
+	case KindWorkspace, KindClient, KindProject, KindTag:
+		kind = strings.ToLower(fields[0])
+	default:
+		return "", s
+	}
+	if len(fields) == 2 {
+		rest = strings.TrimSpace(fields[1])
+	}
+	return kind, rest
+}
+
+// Match returns true if the rule matches a name of its kind.
+func (rule Rule) Match(name string) bool {
+	if name == "" {
+		return false
+	}
+	ok, _ := path.Match(strings.ToLower(rule.Name), strings.ToLower(name))
+	return ok
+}
+
+// find returns the first rule of a kind that matches any of names.
+func (rules Rules) find(kind string, names ...string) *Rule {
+	for i, rule := range rules {
+		if rule.Kind != kind {
+			continue
+		}
+		for _, name := range names {
+			if rule.Match(name) {
+				return &rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Apply returns a record with its client, project and tags mapped by rules.
+// Rules match the names the record has before any are mapped.
+func (rules Rules) Apply(r Record) Record {
+	if len(rules) == 0 {
+		return r
+	}
+	out := r
+	out.Tags = nil
+
+	if rule := rules.find(KindClient, r.Client); rule != nil {
+		out.Client = ""
+		out.set(*rule)
+	}
+	title := r.Project
+	if r.Client != "" {
+		title = r.Client + "/" + r.Project
+	}
+	if rule := rules.find(KindProject, title, r.Project); rule != nil && r.Project != "" {
+		out.Project = ""
+		out.set(*rule)
+	}
+	for _, tag := range r.Tags {
+		if rule := rules.find(KindTag, tag); rule != nil {
+			out.set(*rule)
+		} else {
+			out.addTag(tag)
+		}
+	}
+	return out
+}
+
+// set gives a record the name of a rule's target.
+func (r *Record) set(rule Rule) {
+	if rule.Value == "" {
+		return
+	}
+	switch rule.Target {
+	case KindClient:
+		r.Client = rule.Value
+	case KindProject:
+		if i := strings.Index(rule.Value, "/"); i != -1 {
+			r.Client, r.Project = rule.Value[:i], rule.Value[i+1:]
+		} else {
+			r.Project = rule.Value
+		}
+	case KindTag:
+		r.addTag(rule.Value)
+	}
+}
+
+func (r *Record) addTag(tag string) {
+	for _, t := range r.Tags {
+		if strings.EqualFold(t, tag) {
+			return
+		}
+	}
+	r.Tags = append(r.Tags, tag)
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testRules = `
+# Clients and projects
+client Old Acme = Acme
+project Website Redesign = Acme/Website
+project Acme/Legacy = Maintenance
+project Scratch =
+
+tag acme-* = project Acme/Support
+tag dev = Development
+tag wip =
+`
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Rules{
+		{Kind: KindClient, Name: "Old Acme", Target: KindClient, Value: "Acme"},
+		{Kind: KindProject, Name: "Website Redesign", Target: KindProject, Value: "Acme/Website"},
+		{Kind: KindProject, Name: "Acme/Legacy", Target: KindProject, Value: "Maintenance"},
+		{Kind: KindProject, Name: "Scratch", Target: KindProject, Value: ""},
+		{Kind: KindTag, Name: "acme-*", Target: KindProject, Value: "Acme/Support"},
+		{Kind: KindTag, Name: "dev", Target: KindTag, Value: "Development"},
+		{Kind: KindTag, Name: "wip", Target: KindTag, Value: ""},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules:\ngot  %+v\nwant %+v", rules, want)
+	}
+}
+
+func TestParseRulesErrors(t *testing.T) {
+	for _, s := range []string{
+		"project Website",
+		"Website = Acme/Website",
+		"workspace Old = New",
+		"project = Acme/Website",
+		"tag [ = dev",
+	} {
+		_, err := ParseRules(strings.NewReader("\n" + s))
+		if lineErr, ok := err.(*LineError); !ok || lineErr.Line != 2 {
+			t.Errorf("ParseRules(%q) = %v, want an error on line 2", s, err)
+		}
+	}
+}
+
+func TestRulesApply(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		in   Record
+		want Record
+	}{
+		{
+			name: "unmatched",
+			in:   Record{Client: "Globex", Project: "Website Redesign2", Tags: []string{"review"}},
+			want: Record{Client: "Globex", Project: "Website Redesign2", Tags: []string{"review"}},
+		},
+		{
+			name: "project with client",
+			in:   Record{Project: "website redesign"},
+			want: Record{Client: "Acme", Project: "Website"},
+		},
+		{
+			name: "project title",
+			in:   Record{Client: "Acme", Project: "Legacy"},
+			want: Record{Client: "Acme", Project: "Maintenance"},
+		},
+		{
+			name: "client",
+			in:   Record{Client: "Old Acme", Project: "Intranet"},
+			want: Record{Client: "Acme", Project: "Intranet"},
+		},
+		{
+			name: "rules match names before mapping",
+			in:   Record{Client: "Old Acme", Project: "Legacy"},
+			want: Record{Client: "Acme", Project: "Legacy"},
+		},
+		{
+			name: "tag to project",
+			in:   Record{Tags: []string{"review", "ACME-web"}},
+			want: Record{Client: "Acme", Project: "Support", Tags: []string{"review"}},
+		},
+		{
+			name: "tag renamed",
+			in:   Record{Tags: []string{"dev", "development"}},
+			want: Record{Tags: []string{"Development"}},
+		},
+		{
+			name: "dropped",
+			in:   Record{Project: "Scratch", Tags: []string{"wip", "review"}},
+			want: Record{Tags: []string{"review"}},
+		},
+	}
+	for _, test := range tests {
+		if got := rules.Apply(test.in); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", test.name, got, test.want)
+		}
+	}
+
+	r := Record{Project: "Website Redesign", Tags: []string{"dev"}}
+	if got := Rules(nil).Apply(r); !reflect.DeepEqual(got, r) {
+		t.Errorf("without rules, got %+v", got)
+	}
+}
@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// timewarriorLayout is the layout of Timewarrior's UTC timestamps.
+const timewarriorLayout = "20060102T150405Z"
+
+// TimewarriorReader reads the intervals of a Timewarrior data file, such as
+// ~/.timewarrior/data/2026-10.data, in which each line is an interval like
+//
+//	inc 20261012T090000Z - 20261012T103000Z # review "acme web" # "Fix login"
+//
+// Timewarrior has no projects, so records have the tags of their interval,
+// and its annotation as their description. Rules can map tags to projects.
+type TimewarriorReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewTimewarriorReader returns a reader for a Timewarrior data file, or for
+// the JSON written by "timew export".
+func NewTimewarriorReader(r io.Reader) (Reader, error) {
+	buffered := bufio.NewReader(r)
+	if first, err := peekNonSpace(buffered); err == nil && first == '[' {
+		return newTimewarriorJSONReader(buffered)
+	}
+	return &TimewarriorReader{scanner: bufio.NewScanner(buffered)}, nil
+}
+
+// Read reads the next interval, skipping blank lines and lines that aren't
+// intervals.
+func (t *TimewarriorReader) Read() (Record, error) {
+	for t.scanner.Scan() {
+		t.line++
+		fields := tokenize(t.scanner.Text())
+		if len(fields) == 0 || fields[0].quoted || fields[0].text != "inc" {
+			continue
+		}
+		record, err := parseInterval(fields[1:])
+		record.Line = t.line
+		if err != nil {
+			return record, &LineError{Line: t.line, Err: err}
+		}
+		return record, nil
+	}
+	if err := t.scanner.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+// parseInterval parses the fields of an interval following "inc".
+func parseInterval(fields []token) (Record, error) {
+	var record Record
+	if len(fields) == 0 {
+		return record, fmt.Errorf("The interval has no start time")
+	}
+	start, err := time.Parse(timewarriorLayout, fields[0].text)
+	if err != nil {
+		return record, fmt.Errorf("Invalid start time %q", fields[0].text)
+	}
+	record.Start = start
+	fields = fields[1:]
+
+	if len(fields) < 2 || fields[0].quoted || fields[0].text != "-" {
+		return record, fmt.Errorf("The interval is still running")
+	}
+	stop, err := time.Parse(timewarriorLayout, fields[1].text)
+	if err != nil {
+		return record, fmt.Errorf("Invalid end time %q", fields[1].text)
+	}
+	record.Duration = stop.Sub(start)
+	fields = fields[2:]
+
+	// Tags follow a #, and the annotation a second #
+	if len(fields) > 0 && !fields[0].quoted && fields[0].text == "#" {
+		fields = fields[1:]
+		for len(fields) > 0 && !(fields[0].text == "#" && !fields[0].quoted) {
+			record.addTag(fields[0].text)
+			fields = fields[1:]
+		}
+		if len(fields) > 0 {
+			var words []string
+			for _, f := range fields[1:] {
+				words = append(words, f.text)
+			}
+			record.Description = strings.Join(words, " ")
+		}
+	}
+
+	if record.Duration <= 0 {
+		return record, fmt.Errorf("The interval has no duration")
+	}
+	return record, nil
+}
+
+// token is a word of a data file line.
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits a line into words, keeping quoted strings, in which
+// quotes and backslashes are escaped with backslashes, together.
+func tokenize(s string) []token {
+	var tokens []token
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return tokens
+		}
+		if s[0] != '"' {
+			end := strings.IndexAny(s, " \t")
+			if end == -1 {
+				end = len(s)
+			}
+			tokens = append(tokens, token{text: s[:end]})
+			s = s[end:]
+			continue
+		}
+
+		var text strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			text.WriteByte(s[i])
+		}
+		tokens = append(tokens, token{text: text.String(), quoted: true})
+		if i < len(s) {
+			i++
+		}
+		s = s[i:]
+	}
+}
+
+// timewarriorInterval is an interval written by "timew export".
+type timewarriorInterval struct {
+	Start      string   `json:"start"`
+	End        string   `json:"end"`
+	Tags       []string `json:"tags"`
+	Annotation string   `json:"annotation"`
+}
+
+func newTimewarriorJSONReader(r io.Reader) (Reader, error) {
+	var intervals []timewarriorInterval
+	if err := json.NewDecoder(r).Decode(&intervals); err != nil {
+		return nil, fmt.Errorf("Invalid Timewarrior export: %v", err)
+	}
+
+	list := &recordList{}
+	for i, interval := range intervals {
+		fields := []token{{text: interval.Start}}
+		if interval.End != "" {
+			fields = append(fields, token{text: "-"}, token{text: interval.End})
+		}
+		record, err := parseInterval(fields)
+		record.Line = i + 1
+		record.Description = interval.Annotation
+		for _, tag := range interval.Tags {
+			record.addTag(tag)
+		}
+		list.add(record, err)
+	}
+	return list, nil
+}
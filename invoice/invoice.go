@@ -0,0 +1,218 @@
+/*
+Package invoice builds invoices from the billable time recorded in Toggl.
+
+Hourly rates are resolved per entry the same way Toggl resolves them: a
+user's rate for the project, then the project's rate, then the workspace's
+default rate. Durations are rounded with the workspace's rounding rule unless
+another rule is given. Line items group entries by project and task, and
+invoices can be exported as JSON, CSV or HTML.
+*/
+package invoice
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Options describes the invoice to generate.
+type Options struct {
+	WorkspaceID int
+	// ClientID restricts the invoice to the projects of one client.
+	ClientID int
+	// Since and Until delimit the invoiced dates, inclusive.
+	Since time.Time
+	Until time.Time
+	// Number is the invoice number.
+	Number string
+	// Issued is the issue date. It defaults to today.
+	Issued time.Time
+	// Currency overrides the currency of the projects and workspace.
+	Currency string
+	// Rounding overrides the workspace's rounding rule.
+	Rounding *toggl.Rounding
+	// IncludeNonBillable includes entries that aren't marked as billable.
+	IncludeNonBillable bool
+}
+
+// Line is an invoice line item: the time spent on a task of a project at a
+// single hourly rate.
+type Line struct {
+	Project  string        `json:"project"`
+	Task     string        `json:"task,omitempty"`
+	Duration time.Duration `json:"-"`
+	Hours    float64       `json:"hours"`
+	Rate     float64       `json:"rate"`
+	Amount   float64       `json:"amount"`
+	Entries  int           `json:"entries"`
+
+	total toggl.Accumulator
+}
+
+// Invoice is an invoice for the time spent on a client's projects.
+type Invoice struct {
+	Number   string        `json:"number,omitempty"`
+	Client   string        `json:"client,omitempty"`
+	Currency string        `json:"currency,omitempty"`
+	Since    time.Time     `json:"since"`
+	Until    time.Time     `json:"until"`
+	Issued   time.Time     `json:"issued"`
+	Lines    []*Line       `json:"lines"`
+	Duration time.Duration `json:"-"`
+	Hours    float64       `json:"hours"`
+	Total    float64       `json:"total"`
+}
+
+// Generate retrieves the detailed report entries for the invoiced period and
+// client and builds an invoice from them.
+func Generate(session *toggl.Session, options Options) (*Invoice, error) {
+	account, err := session.GetAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	projectUsers, err := session.GetProjectUsers(options.WorkspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve project rates: %v", err)
+	}
+
+	config := &toggl.DetailedReportConfig{
+		WorkspaceId: options.WorkspaceID,
+		Since:       options.Since.Format("2006-01-02"),
+		Until:       options.Until.Format("2006-01-02"),
+	}
+	if options.ClientID != 0 {
+		config.ClientIds = []string{strconv.Itoa(options.ClientID)}
+	}
+	entries, err := session.GetDetailedReportEntries(config)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve time entries: %v", err)
+	}
+
+	return Build(&account, projectUsers, entries, options)
+}
+
+// Build builds an invoice from detailed report entries. The account provides
+// the workspace's default rate and rounding rule and the projects' rates.
+func Build(account *toggl.Account, projectUsers []toggl.ProjectUser, entries []toggl.DetailedTimeEntry, options Options) (*Invoice, error) {
+	var workspace toggl.Workspace
+	for _, w := range account.Data.Workspaces {
+		if w.ID == options.WorkspaceID {
+			workspace = w
+		}
+	}
+
+	projects := make(map[int]toggl.Project)
+	for _, p := range account.Data.Projects {
+		projects[p.ID] = p
+	}
+
+	rounding := workspace.RoundingRule()
+	if options.Rounding != nil {
+		rounding = *options.Rounding
+	}
+
+	invoice := &Invoice{
+		Number:   options.Number,
+		Currency: options.Currency,
+		Since:    options.Since,
+		Until:    options.Until,
+		Issued:   options.Issued,
+	}
+	if invoice.Issued.IsZero() {
+		invoice.Issued = time.Now()
+	}
+	for _, c := range account.Data.Clients {
+		if c.ID == options.ClientID {
+			invoice.Client = c.Name
+		}
+	}
+
+	lines := make(map[string]*Line)
+	for _, entry := range entries {
+		project, known := projects[entry.Pid]
+		if options.ClientID != 0 && known && project.Cid != options.ClientID {
+			continue
+		}
+		if !entry.IsBillable && !options.IncludeNonBillable {
+			continue
+		}
+
+		currency := options.Currency
+		if currency == "" {
+			currency = project.Currency
+		}
+		if currency == "" {
+			currency = workspace.DefaultCurrency
+		}
+		if invoice.Currency == "" {
+			invoice.Currency = currency
+		} else if currency != "" && currency != invoice.Currency {
+			return nil, fmt.Errorf("Entry %d is billed in %s, not %s", entry.ID, currency, invoice.Currency)
+		}
+
+		if invoice.Client == "" {
+			invoice.Client = entry.Client
+		}
+
+		rate := rateOf(entry, project, workspace, projectUsers)
+		key := fmt.Sprintf("%d/%s/%g", entry.Pid, entry.Task, rate)
+		line, ok := lines[key]
+		if !ok {
+			line = &Line{Project: entry.Project, Task: entry.Task, Rate: rate}
+			line.total.Rule = rounding
+			lines[key] = line
+			invoice.Lines = append(invoice.Lines, line)
+		}
+		// Detailed report durations are in milliseconds
+		line.total.Add(time.Duration(entry.Duration) * time.Millisecond)
+		line.Entries++
+	}
+
+	for _, line := range invoice.Lines {
+		line.Duration = line.total.Total()
+		line.Hours = round(line.Duration.Hours(), 2)
+		line.Amount = round(line.Duration.Hours()*line.Rate, 2)
+		invoice.Duration += line.Duration
+		invoice.Total += line.Amount
+	}
+	invoice.Hours = round(invoice.Duration.Hours(), 2)
+	invoice.Total = round(invoice.Total, 2)
+
+	sort.SliceStable(invoice.Lines, func(i, j int) bool {
+		a, b := invoice.Lines[i], invoice.Lines[j]
+		if a.Project != b.Project {
+			return a.Project < b.Project
+		}
+		if a.Task != b.Task {
+			return a.Task < b.Task
+		}
+		return a.Rate < b.Rate
+	})
+
+	return invoice, nil
+}
+
+// rateOf resolves the hourly rate of an entry: the user's rate for the
+// project, the project's rate, or the workspace's default rate.
+func rateOf(entry toggl.DetailedTimeEntry, project toggl.Project, workspace toggl.Workspace, projectUsers []toggl.ProjectUser) float64 {
+	for _, pu := range projectUsers {
+		if pu.Pid == entry.Pid && pu.Uid == entry.Uid && pu.Rate > 0 {
+			return pu.Rate
+		}
+	}
+	if project.Rate > 0 {
+		return project.Rate
+	}
+	return workspace.DefaultHourlyRate
+}
+
+// round rounds x to the given number of decimal places.
+func round(x float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(x*scale) / scale
+}
@@ -0,0 +1,228 @@
+package invoice
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+func testAccount() *toggl.Account {
+	var a toggl.Account
+	a.Data.Workspaces = []toggl.Workspace{{ID: 1, Name: "Work", DefaultHourlyRate: 50, DefaultCurrency: "EUR", Rounding: 1, RoundingMinutes: 15}}
+	a.Data.Clients = []toggl.Client{{ID: 10, Wid: 1, Name: "Acme"}, {ID: 11, Wid: 1, Name: "Globex"}}
+	a.Data.Projects = []toggl.Project{
+		{ID: 100, Wid: 1, Cid: 10, Name: "Website", Rate: 80},
+		{ID: 101, Wid: 1, Cid: 10, Name: "Support"},
+		{ID: 102, Wid: 1, Cid: 11, Name: "Other", Rate: 200},
+	}
+	return &a
+}
+
+func testEntries() []toggl.DetailedTimeEntry {
+	entry := func(id, uid, pid int, task string, minutes int, billable bool) toggl.DetailedTimeEntry {
+		projects := map[int]string{100: "Website", 101: "Support", 102: "Other"}
+		return toggl.DetailedTimeEntry{ID: id, Uid: uid, Pid: pid, Project: projects[pid], Task: task,
+			Duration: int64(minutes) * 60 * 1000, IsBillable: billable}
+	}
+	return []toggl.DetailedTimeEntry{
+		entry(1, 1, 100, "Design", 50, true),
+		entry(2, 1, 100, "Design", 60, true),
+		entry(3, 2, 100, "Design", 20, true),
+		entry(4, 1, 101, "", 100, true),
+		entry(5, 2, 101, "", 10, true),
+		entry(6, 1, 100, "Design", 60, false),
+		entry(7, 1, 102, "", 60, true),
+	}
+}
+
+// User 2 has a rate of their own on the website, but not on support
+var testProjectUsers = []toggl.ProjectUser{
+	{Pid: 100, Uid: 2, Rate: 120},
+	{Pid: 101, Uid: 2},
+}
+
+func testOptions() Options {
+	return Options{
+		WorkspaceID: 1,
+		ClientID:    10,
+		Number:      "2026-042",
+		Since:       time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Until:       time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC),
+		Issued:      time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	noRounding := toggl.Rounding{}
+	tests := []struct {
+		name    string
+		options func(*Options)
+		// lines are project, task, hours, rate, amount and entries
+		lines [][6]interface{}
+		hours float64
+		total float64
+	}{
+		{
+			// Durations are rounded up to 15 minutes per entry, as the
+			// workspace says
+			"rates", func(*Options) {},
+			[][6]interface{}{
+				{"Support", "", 2.0, 50.0, 100.0, 2},
+				{"Website", "Design", 2.0, 80.0, 160.0, 2},
+				{"Website", "Design", 0.5, 120.0, 60.0, 1},
+			},
+			4.5, 320,
+		},
+		{
+			"no rounding", func(o *Options) { o.Rounding = &noRounding },
+			[][6]interface{}{
+				{"Support", "", 1.83, 50.0, 91.67, 2},
+				{"Website", "Design", 1.83, 80.0, 146.67, 2},
+				{"Website", "Design", 0.33, 120.0, 40.0, 1},
+			},
+			4, 278.34,
+		},
+		{
+			"non-billable", func(o *Options) { o.IncludeNonBillable = true },
+			[][6]interface{}{
+				{"Support", "", 2.0, 50.0, 100.0, 2},
+				{"Website", "Design", 3.0, 80.0, 240.0, 3},
+				{"Website", "Design", 0.5, 120.0, 60.0, 1},
+			},
+			5.5, 400,
+		},
+		{
+			"every client", func(o *Options) { o.ClientID = 0 },
+			[][6]interface{}{
+				{"Other", "", 1.0, 200.0, 200.0, 1},
+				{"Support", "", 2.0, 50.0, 100.0, 2},
+				{"Website", "Design", 2.0, 80.0, 160.0, 2},
+				{"Website", "Design", 0.5, 120.0, 60.0, 1},
+			},
+			5.5, 520,
+		},
+	}
+
+	for _, test := range tests {
+		options := testOptions()
+		test.options(&options)
+		invoice, err := Build(testAccount(), testProjectUsers, testEntries(), options)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var lines [][6]interface{}
+		for _, l := range invoice.Lines {
+			lines = append(lines, [6]interface{}{l.Project, l.Task, l.Hours, l.Rate, l.Amount, l.Entries})
+		}
+		if len(lines) != len(test.lines) {
+			t.Errorf("%s: lines %v, want %v", test.name, lines, test.lines)
+			continue
+		}
+		for i := range lines {
+			if lines[i] != test.lines[i] {
+				t.Errorf("%s: line %d is %v, want %v", test.name, i, lines[i], test.lines[i])
+			}
+		}
+		if invoice.Hours != test.hours || invoice.Total != test.total || invoice.Currency != "EUR" {
+			t.Errorf("%s: %v hours for %v %s, want %v for %v EUR", test.name, invoice.Hours, invoice.Total, invoice.Currency, test.hours, test.total)
+		}
+	}
+
+	// Entries can't be added up in different currencies
+	account := testAccount()
+	account.Data.Projects[1].Currency = "USD"
+	if _, err := Build(account, testProjectUsers, testEntries(), testOptions()); err == nil {
+		t.Error("built an invoice in two currencies")
+	}
+	options := testOptions()
+	options.Currency = "GBP"
+	if invoice, err := Build(account, testProjectUsers, testEntries(), options); err != nil || invoice.Currency != "GBP" {
+		t.Errorf("invoice in GBP = %+v, %v", invoice, err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	invoice, err := Build(testAccount(), testProjectUsers, testEntries(), testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := invoice.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "project,task,hours,rate,amount,currency,entries\n" +
+		"Support,,2.00,50.00,100.00,EUR,2\n" +
+		"Website,Design,2.00,80.00,160.00,EUR,2\n" +
+		"Website,Design,0.50,120.00,60.00,EUR,1\n" +
+		"Total,,4.50,,320.00,EUR,\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV: got\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := invoice.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want = `{
+  "number": "2026-042",
+  "client": "Acme",
+  "currency": "EUR",
+  "since": "2026-10-01T00:00:00Z",
+  "until": "2026-10-31T00:00:00Z",
+  "issued": "2026-11-02T00:00:00Z",
+  "lines": [
+    {
+      "project": "Support",
+      "hours": 2,
+      "rate": 50,
+      "amount": 100,
+      "entries": 2
+    },
+    {
+      "project": "Website",
+      "task": "Design",
+      "hours": 2,
+      "rate": 80,
+      "amount": 160,
+      "entries": 2
+    },
+    {
+      "project": "Website",
+      "task": "Design",
+      "hours": 0.5,
+      "rate": 120,
+      "amount": 60,
+      "entries": 1
+    }
+  ],
+  "hours": 4.5,
+  "total": 320
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("JSON: got\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	invoice.Lines[0].Project = "R&D <internal>"
+	if err := invoice.WriteHTML(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		"<title>Invoice 2026-042</title>",
+		"Client: Acme",
+		"Period: 2026-10-01 to 2026-10-31",
+		`<tr><td>R&amp;D &lt;internal&gt;</td><td></td><td class="num">2.00</td><td class="num">50.00</td><td class="num">100.00</td></tr>`,
+		`<td class="num">4.50</td><td></td><td class="num">320.00 EUR</td>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML doesn't contain %s:\n%s", want, html)
+		}
+	}
+}
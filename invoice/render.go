@@ -0,0 +1,96 @@
+package invoice
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"strconv"
+)
+
+// DefaultTemplate is the HTML template used by WriteHTML when no other
+// template is given. Templates are executed with the *Invoice as data.
+var DefaultTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"money": formatMoney,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 0.4em; border-bottom: 1px solid #ddd; text-align: left; }
+td.num, th.num { text-align: right; }
+tfoot td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Invoice{{if .Number}} {{.Number}}{{end}}</h1>
+<p>
+{{if .Client}}Client: {{.Client}}<br>{{end}}
+Period: {{.Since.Format "2006-01-02"}} to {{.Until.Format "2006-01-02"}}<br>
+Issued: {{.Issued.Format "2006-01-02"}}
+</p>
+<table>
+<thead>
+<tr><th>Project</th><th>Task</th><th class="num">Hours</th><th class="num">Rate</th><th class="num">Amount</th></tr>
+</thead>
+<tbody>
+{{range .Lines}}<tr><td>{{.Project}}</td><td>{{.Task}}</td><td class="num">{{printf "%.2f" .Hours}}</td><td class="num">{{money .Rate}}</td><td class="num">{{money .Amount}}</td></tr>
+{{end}}</tbody>
+<tfoot>
+<tr><td colspan="2">Total</td><td class="num">{{printf "%.2f" .Hours}}</td><td></td><td class="num">{{money .Total}} {{.Currency}}</td></tr>
+</tfoot>
+</table>
+</body>
+</html>
+`))
+
+// WriteJSON writes an invoice as indented JSON.
+func (invoice *Invoice) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(invoice)
+}
+
+// WriteCSV writes an invoice's line items as CSV, followed by a total row.
+func (invoice *Invoice) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"project", "task", "hours", "rate", "amount", "currency", "entries"})
+	for _, line := range invoice.Lines {
+		out.Write([]string{
+			line.Project,
+			line.Task,
+			strconv.FormatFloat(line.Hours, 'f', 2, 64),
+			formatMoney(line.Rate),
+			formatMoney(line.Amount),
+			invoice.Currency,
+			strconv.Itoa(line.Entries),
+		})
+	}
+	out.Write([]string{
+		"Total",
+		"",
+		strconv.FormatFloat(invoice.Hours, 'f', 2, 64),
+		"",
+		formatMoney(invoice.Total),
+		invoice.Currency,
+		"",
+	})
+	out.Flush()
+	return out.Error()
+}
+
+// WriteHTML renders an invoice as an HTML document using the given template,
+// or DefaultTemplate if it's nil.
+func (invoice *Invoice) WriteHTML(w io.Writer, tmpl *template.Template) error {
+	if tmpl == nil {
+		tmpl = DefaultTemplate
+	}
+	return tmpl.Execute(w, invoice)
+}
+
+func formatMoney(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
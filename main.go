@@ -43,6 +43,10 @@ type Session struct {
 	APIToken string
 	username string
 	password string
+	sync     *syncState
+	cache    *Cache
+	queue    *Queue
+	location *time.Location
 }
 
 // Account represents a user account.
@@ -64,19 +68,23 @@ type Account struct {
 
 // Workspace represents a user workspace.
 type Workspace struct {
-	ID              int    `json:"id"`
-	RoundingMinutes int    `json:"rounding_minutes"`
-	Rounding        int    `json:"rounding"`
-	Name            string `json:"name"`
-	Premium         bool   `json:"premium"`
+	ID                int        `json:"id"`
+	RoundingMinutes   int        `json:"rounding_minutes"`
+	Rounding          int        `json:"rounding"`
+	Name              string     `json:"name"`
+	Premium           bool       `json:"premium"`
+	DefaultHourlyRate float64    `json:"default_hourly_rate,omitempty"`
+	DefaultCurrency   string     `json:"default_currency,omitempty"`
+	ServerDeletedAt   *time.Time `json:"server_deleted_at,omitempty"`
 }
 
 // Client represents a client.
 type Client struct {
-	Wid   int    `json:"wid"`
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Notes string `json:"notes"`
+	Wid             int        `json:"wid"`
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	Notes           string     `json:"notes"`
+	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
 }
 
 // Project represents a project.
@@ -87,9 +95,21 @@ type Project struct {
 	Name            string     `json:"name"`
 	Active          bool       `json:"active"`
 	Billable        float32    `json:"billable"`
+	Rate            float64    `json:"rate,omitempty"`
+	Currency        string     `json:"currency,omitempty"`
 	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
 }
 
+// ProjectUser represents a user's membership of a project.
+type ProjectUser struct {
+	ID      int     `json:"id"`
+	Pid     int     `json:"pid"`
+	Uid     int     `json:"uid"`
+	Wid     int     `json:"wid"`
+	Manager bool    `json:"manager"`
+	Rate    float64 `json:"rate,omitempty"`
+}
+
 type Group struct {
 	Wid  int    `json:"wid"`
 	ID   int    `json:"id"`
@@ -104,32 +124,36 @@ func (p *Project) IsActive() bool {
 
 // Task represents a task.
 type Task struct {
-	Wid  int    `json:"wid"`
-	Pid  int    `json:"pid"`
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	Wid             int        `json:"wid"`
+	Pid             int        `json:"pid"`
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
 }
 
 // Tag represents a tag.
 type Tag struct {
-	Wid  int    `json:"wid"`
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	Wid             int        `json:"wid"`
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
 }
 
 // TimeEntry represents a single time entry.
 type TimeEntry struct {
-	Wid         int        `json:"wid,omitempty"`
-	ID          int        `json:"id,omitempty"`
-	Pid         int        `json:"pid"`
-	Tid         int        `json:"tid"`
-	Description string     `json:"description,omitempty"`
-	Stop        *time.Time `json:"stop,omitempty"`
-	Start       *time.Time `json:"start,omitempty"`
-	Tags        []string   `json:"tags"`
-	Duration    int64      `json:"duration,omitempty"`
-	DurOnly     bool       `json:"duronly"`
-	Billable    float32    `json:"billable"`
+	Wid             int        `json:"wid,omitempty"`
+	ID              int        `json:"id,omitempty"`
+	Pid             int        `json:"pid"`
+	Tid             int        `json:"tid"`
+	Description     string     `json:"description,omitempty"`
+	Stop            *time.Time `json:"stop,omitempty"`
+	Start           *time.Time `json:"start,omitempty"`
+	Tags            []string   `json:"tags"`
+	Duration        int64      `json:"duration,omitempty"`
+	DurOnly         bool       `json:"duronly"`
+	Billable        float32    `json:"billable"`
+	At              *time.Time `json:"at,omitempty"`
+	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
 }
 
 type DetailedTimeEntry struct {
@@ -143,31 +167,47 @@ type DetailedTimeEntry struct {
 	ProjectColor    string     `json:"project_color"`
 	ProjectHexColor string     `json:"project_hex_color"`
 	Client          string     `json:"client"`
+	Task            string     `json:"task"`
 	Start           *time.Time `json:"start"`
 	End             *time.Time `json:"end"`
 	Updated         *time.Time `json:"updated"`
 	Duration        int64      `json:"dur"`
+	IsBillable      bool       `json:"is_billable"`
 	Billable        float32    `json:"billable"`
 	Tags            []string   `json:"tags"`
+	At              *time.Time `json:"at,omitempty"`
+	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
 }
 
 // SummaryReport represents a summary report generated by Toggl's reporting API.
 type SummaryReport struct {
-	TotalGrand int `json:"total_grand"`
-	Data       []struct {
-		ID    int `json:"id"`
-		Time  int `json:"time"`
-		Title struct {
-			Project  string `json:"project"`
-			Client   string `json:"client"`
-			Color    string `json:"color"`
-			HexColor string `json:"hex_color"`
-		} `json:"title"`
-		Items []struct {
-			Title map[string]string `json:"title"`
-			Time  int               `json:"time"`
-		} `json:"items"`
-	} `json:"data"`
+	TotalGrand    int                  `json:"total_grand"`
+	TotalBillable int                  `json:"total_billable"`
+	Data          []SummaryReportGroup `json:"data"`
+}
+
+// SummaryReportGroup is a top level group in a summary report. Times are in
+// milliseconds.
+type SummaryReportGroup struct {
+	ID    int                 `json:"id"`
+	Time  int                 `json:"time"`
+	Title SummaryReportTitle  `json:"title"`
+	Items []SummaryReportItem `json:"items"`
+}
+
+// SummaryReportTitle describes the project or client of a summary report
+// group.
+type SummaryReportTitle struct {
+	Project  string `json:"project"`
+	Client   string `json:"client"`
+	Color    string `json:"color"`
+	HexColor string `json:"hex_color"`
+}
+
+// SummaryReportItem is a sub group in a summary report group.
+type SummaryReportItem struct {
+	Title map[string]string `json:"title"`
+	Time  int               `json:"time"`
 }
 
 // DetailedReport represents a summary report generated by Toggl's reporting API.
@@ -178,6 +218,18 @@ type DetailedReport struct {
 	Data       []DetailedTimeEntry `json:"data"`
 }
 
+// APIError is returned when the Toggl API responds with an error status.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+// Error returns the HTTP status of the failed request.
+func (e *APIError) Error() string {
+	return e.Status
+}
+
 // functions ////////////////////////////
 
 // OpenSession opens a session using an existing API token.
@@ -210,8 +262,31 @@ func NewSession(username, password string) (session Session, err error) {
 
 // GetAccount returns a user's account information, including a list of active
 // projects and timers.
-func (session *Session) GetAccount() (Account, error) {
+func (session *Session) GetAccount() (account Account, err error) {
+	if session.cache != nil {
+		account, err = session.cache.account(session)
+	} else {
+		account, err = session.fetchAccount(0)
+	}
+
+	if err == nil && session.location == nil {
+		if loc, locErr := account.Location(); locErr == nil {
+			session.location = loc
+		} else {
+			dlog.Printf("Ignoring account time zone: %v", locErr)
+		}
+	}
+	return account, err
+}
+
+// fetchAccount retrieves a user's account information with related data. If
+// since is non-zero, only data changed after that server timestamp is
+// included.
+func (session *Session) fetchAccount(since int) (Account, error) {
 	params := map[string]string{"with_related_data": "true"}
+	if since > 0 {
+		params["since"] = fmt.Sprintf("%d", since)
+	}
 	data, err := session.get(TogglAPI, "/me", params)
 	if err != nil {
 		return Account{}, err
@@ -261,6 +336,8 @@ type DetailedReportConfig struct {
 	UserAgent   string   `json:"jc-toggl"`
 	Rounding    string   `json:"rounding"`
 	GroupIds    []string `json:"group_ids"`
+	ClientIds   []string `json:"client_ids"`
+	ProjectIds  []string `json:"project_ids"`
 }
 
 // GetDetailedReport retrieves a detailed report using Toggle's reporting API.
@@ -282,6 +359,12 @@ func (session *Session) GetDetailedReport(config *DetailedReportConfig) (Detaile
 		"workspace_id":         fmt.Sprintf("%d", config.WorkspaceId),
 		"members_of_group_ids": strings.Join(config.GroupIds, ","),
 	}
+	if len(config.ClientIds) > 0 {
+		params["client_ids"] = strings.Join(config.ClientIds, ",")
+	}
+	if len(config.ProjectIds) > 0 {
+		params["project_ids"] = strings.Join(config.ProjectIds, ",")
+	}
 	data, err := session.get(ReportsAPI, "/details", params)
 	if err != nil {
 		return DetailedReport{}, err
@@ -293,8 +376,28 @@ func (session *Session) GetDetailedReport(config *DetailedReportConfig) (Detaile
 	return report, err
 }
 
+// GetDetailedReportEntries retrieves every entry of a detailed report,
+// requesting one page after another starting with the first.
+func (session *Session) GetDetailedReportEntries(config *DetailedReportConfig) (entries []DetailedTimeEntry, err error) {
+	pageConfig := *config
+	for pageConfig.Page = 1; ; pageConfig.Page++ {
+		var report DetailedReport
+		if report, err = session.GetDetailedReport(&pageConfig); err != nil {
+			return nil, err
+		}
+		entries = append(entries, report.Data...)
+		if len(report.Data) == 0 || len(entries) >= report.TotalCount {
+			return entries, nil
+		}
+	}
+}
+
 // StartTimeEntry creates a new time entry.
 func (session *Session) StartTimeEntry(description string) (TimeEntry, error) {
+	if session.offline(nil) {
+		return session.queue.start(TimeEntry{Description: description}), nil
+	}
+
 	data := map[string]interface{}{
 		"time_entry": map[string]string{
 			"description":  description,
@@ -302,12 +405,70 @@ func (session *Session) StartTimeEntry(description string) (TimeEntry, error) {
 		},
 	}
 	respData, err := session.post(TogglAPI, "/time_entries/start", data)
+	if session.canQueue(err) {
+		return session.queue.start(TimeEntry{Description: description}), nil
+	}
+	session.invalidate(CacheTimeEntries)
+	return timeEntryRequest(respData, err)
+}
+
+// CreateTimeEntry creates a time entry with the start time, duration and other
+// details of the given entry. Unlike StartTimeEntry it can be used to record
+// completed entries or timers that were started in the past.
+func (session *Session) CreateTimeEntry(entry TimeEntry) (TimeEntry, error) {
+	if session.offline(nil) {
+		return session.queue.create(entry), nil
+	}
+
+	dlog.Printf("Creating time entry %v", entry)
+	timeEntry := map[string]interface{}{
+		"description":  entry.Description,
+		"pid":          entry.Pid,
+		"tid":          entry.Tid,
+		"billable":     entry.Billable,
+		"start":        entry.Start,
+		"duration":     entry.Duration,
+		"tags":         entry.Tags,
+		"duronly":      entry.DurOnly,
+		"created_with": AppName,
+	}
+	if entry.Wid != 0 {
+		timeEntry["wid"] = entry.Wid
+	}
+	if entry.Stop != nil {
+		timeEntry["stop"] = entry.Stop
+	}
+
+	data := map[string]interface{}{
+		"time_entry": timeEntry,
+	}
+	respData, err := session.post(TogglAPI, "/time_entries", data)
+	if session.canQueue(err) {
+		return session.queue.create(entry), nil
+	}
+	session.invalidate(CacheTimeEntries)
 	return timeEntryRequest(respData, err)
 }
 
-// GetCurrentTimeEntry returns the current time entry, that's running
+// GetTimeEntry returns the time entry with the given ID.
+func (session *Session) GetTimeEntry(id int) (TimeEntry, error) {
+	path := fmt.Sprintf("/time_entries/%v", id)
+	data, err := session.get(TogglAPI, path, nil)
+	return timeEntryRequest(data, err)
+}
+
+// GetCurrentTimeEntry returns the current time entry, that's running. In
+// offline mode, it's the timer the queued operations leave running while the
+// API can't be reached.
 func (session *Session) GetCurrentTimeEntry() (TimeEntry, error) {
+	if session.offline(nil) {
+		return session.offlineCurrent(), nil
+	}
+
 	data, err := session.get(TogglAPI, "/time_entries/current", nil)
+	if session.canQueue(err) {
+		return session.offlineCurrent(), nil
+	}
 	if err != nil {
 		return TimeEntry{}, err
 	}
@@ -332,9 +493,67 @@ func (session *Session) GetTimeEntries(startDate, endDate time.Time) ([]TimeEntr
 	return results, nil
 }
 
+// TimeEntriesLimit is the most entries GetTimeEntries returns; entries past
+// it are left out.
+const TimeEntriesLimit = 1000
+
+// GetAllTimeEntries returns the time entries started in a time range of any
+// length. It requests them a month at a time, and splits months holding more
+// than TimeEntriesLimit entries, so that none are left out.
+func (session *Session) GetAllTimeEntries(startDate, endDate time.Time) ([]TimeEntry, error) {
+	var entries []TimeEntry
+	seen := make(map[int]bool)
+	for from := startDate; from.Before(endDate); {
+		to := from.AddDate(0, 1, 0)
+		if to.After(endDate) {
+			to = endDate
+		}
+		window, err := session.getTimeEntriesSplit(from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range window {
+			// Entries starting where ranges meet can be in both
+			if !seen[e.ID] {
+				seen[e.ID] = true
+				entries = append(entries, e)
+			}
+		}
+		from = to
+	}
+	return entries, nil
+}
+
+// getTimeEntriesSplit returns the time entries of a time range, halving it
+// until every request returns fewer than TimeEntriesLimit entries.
+func (session *Session) getTimeEntriesSplit(startDate, endDate time.Time) ([]TimeEntry, error) {
+	entries, err := session.GetTimeEntries(startDate, endDate)
+	if err != nil || len(entries) < TimeEntriesLimit || endDate.Sub(startDate) <= time.Minute {
+		return entries, err
+	}
+	middle := startDate.Add(endDate.Sub(startDate) / 2).Truncate(time.Second)
+	first, err := session.getTimeEntriesSplit(startDate, middle)
+	if err != nil {
+		return nil, err
+	}
+	second, err := session.getTimeEntriesSplit(middle, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
+}
+
 // StartTimeEntryForProject creates a new time entry for a specific project. Note that the 'billable' option is only
 // meaningful for Toggl Pro accounts; it will be ignored for free accounts.
 func (session *Session) StartTimeEntryForProject(description string, projectID int, billable bool) (TimeEntry, error) {
+	local := TimeEntry{Description: description, Pid: projectID}
+	if billable {
+		local.Billable = 1
+	}
+	if session.offline(nil) {
+		return session.queue.start(local), nil
+	}
+
 	data := map[string]interface{}{
 		"time_entry": map[string]interface{}{
 			"description":  description,
@@ -344,35 +563,61 @@ func (session *Session) StartTimeEntryForProject(description string, projectID i
 		},
 	}
 	respData, err := session.post(TogglAPI, "/time_entries/start", data)
+	if session.canQueue(err) {
+		return session.queue.start(local), nil
+	}
+	session.invalidate(CacheTimeEntries)
 	return timeEntryRequest(respData, err)
 }
 
 // UpdateTimeEntry changes information about an existing time entry.
 func (session *Session) UpdateTimeEntry(timer TimeEntry) (TimeEntry, error) {
+	if session.offline(&timer.ID) {
+		return session.queue.update(timer), nil
+	}
+
 	dlog.Printf("Updating timer %v", timer)
 	data := map[string]interface{}{
 		"time_entry": timer,
 	}
 	path := fmt.Sprintf("/time_entries/%v", timer.ID)
 	respData, err := session.post(TogglAPI, path, data)
+	if session.canQueue(err) {
+		return session.queue.update(timer), nil
+	}
+	session.invalidate(CacheTimeEntries)
 	return timeEntryRequest(respData, err)
 }
 
 // ContinueTimeEntry continues a time entry, either by creating a new entry
 // with the same description or by extending the duration of an existing entry.
 // In both cases the new entry will have the same description and project ID as
-// the existing one.
+// the existing one. A duration-only entry of today is extended by running it
+// again, as if it had started as long before now as it has lasted; one that
+// has lasted longer than today so far is continued in a new entry instead.
+//
+// Continuing an entry that is still running used to start a second copy of
+// it; it now returns a *TimerRunningError for the entry instead, so callers
+// that continued running entries should stop them first.
 func (session *Session) ContinueTimeEntry(timer TimeEntry, duronly bool) (TimeEntry, error) {
 	dlog.Printf("Continuing timer %v", timer)
 	var respData []byte
 	var err error
 
-	if duronly && time.Now().Local().Format("2006-01-02") == timer.Start.Local().Format("2006-01-02") {
-		// If we're doing a duration-only continuation for a timer today, then
-		// create a new entry that's a copy of the existing one with an
-		// adjusted duration
+	if timer.IsRunning() {
+		return TimeEntry{}, &TimerRunningError{Entry: timer}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	start := now.Add(-time.Duration(timer.Duration) * time.Second)
+	calendar := Calendar{Location: session.Location()}
+	if duronly && timer.ID != 0 && calendar.SameDay(now, timer.StartTime()) && calendar.SameDay(now, start) {
+		// Running entries have the negated Unix time of their start as their
+		// duration, so the entry keeps the time it has and stays within today
 		entry := timer.Copy()
-		entry.Duration = -(time.Now().Unix() - entry.Duration)
+		entry.Start = &start
+		entry.Stop = nil
+		entry.Duration = -start.Unix()
 		entry.DurOnly = true
 		data := map[string]interface{}{
 			"time_entry": entry,
@@ -395,6 +640,7 @@ func (session *Session) ContinueTimeEntry(timer TimeEntry, duronly bool) (TimeEn
 		}
 		respData, err = session.post(TogglAPI, "/time_entries/start", data)
 	}
+	session.invalidate(CacheTimeEntries)
 	return timeEntryRequest(respData, err)
 }
 
@@ -442,15 +688,27 @@ func (session *Session) UnstopTimeEntry(timer TimeEntry) (newEntry TimeEntry, er
 
 // StopTimeEntry stops a running time entry.
 func (session *Session) StopTimeEntry(timer TimeEntry) (TimeEntry, error) {
+	if session.offline(&timer.ID) {
+		return session.queue.stop(timer), nil
+	}
+
 	dlog.Printf("Stopping timer %v", timer)
 	path := fmt.Sprintf("/time_entries/%v/stop", timer.ID)
 	respData, err := session.put(TogglAPI, path, nil)
+	if session.canQueue(err) {
+		return session.queue.stop(timer), nil
+	}
+	session.invalidate(CacheTimeEntries)
 	return timeEntryRequest(respData, err)
 }
 
 // AddRemoveTag adds or removes a tag from the time entry corresponding to a
 // given ID.
 func (session *Session) AddRemoveTag(entryID int, tag string, add bool) (TimeEntry, error) {
+	if session.offline(&entryID) {
+		return session.queue.tag(entryID, session.entryAt(entryID), tag, add), nil
+	}
+
 	dlog.Printf("Adding tag to time entry %v", entryID)
 
 	action := "add"
@@ -466,15 +724,30 @@ func (session *Session) AddRemoveTag(entryID int, tag string, add bool) (TimeEnt
 	}
 	path := fmt.Sprintf("/time_entries/%v", entryID)
 	respData, err := session.post(TogglAPI, path, data)
+	if session.canQueue(err) {
+		return session.queue.tag(entryID, session.entryAt(entryID), tag, add), nil
+	}
+	session.invalidate(CacheTimeEntries)
 
 	return timeEntryRequest(respData, err)
 }
 
 // DeleteTimeEntry deletes a time entry.
 func (session *Session) DeleteTimeEntry(timer TimeEntry) ([]byte, error) {
+	if session.offline(&timer.ID) {
+		session.queue.remove(timer)
+		return nil, nil
+	}
+
 	dlog.Printf("Deleting timer %v", timer)
 	path := fmt.Sprintf("/time_entries/%v", timer.ID)
-	return session.delete(TogglAPI, path)
+	respData, err := session.delete(TogglAPI, path)
+	if session.canQueue(err) {
+		session.queue.remove(timer)
+		return nil, nil
+	}
+	session.invalidate(CacheTimeEntries)
+	return respData, err
 }
 
 // IsRunning returns true if the receiver is currently running.
@@ -485,6 +758,19 @@ func (e *TimeEntry) IsRunning() bool {
 // GetProjects allows to query for all projects in a workspace
 func (session *Session) GetProjects(wid int) (projects []Project, err error) {
 	dlog.Printf("Getting projects for workspace %d", wid)
+	if session.cache != nil {
+		var account Account
+		if account, err = session.GetAccount(); err != nil {
+			return
+		}
+		for _, p := range account.Data.Projects {
+			if p.Wid == wid {
+				projects = append(projects, p)
+			}
+		}
+		return
+	}
+
 	path := fmt.Sprintf("/workspaces/%v/projects", wid)
 	data, err := session.get(TogglAPI, path, nil)
 	if err != nil {
@@ -502,6 +788,14 @@ func (session *Session) GetProject(id int) (project *Project, err error) {
 		Data Project
 	}
 	dlog.Printf("Getting project with id %d", id)
+	if session.cache != nil {
+		if account, err := session.GetAccount(); err == nil {
+			if p, ok := findProject(account.Data.Projects, id); ok {
+				return &p, nil
+			}
+		}
+	}
+
 	path := fmt.Sprintf("/projects/%v", id)
 	data, err := session.get(TogglAPI, path, nil)
 	if err != nil {
@@ -513,6 +807,20 @@ func (session *Session) GetProject(id int) (project *Project, err error) {
 	return &dProject.Data, nil
 }
 
+// GetProjectUsers returns the project memberships of a workspace, including
+// the users' hourly rates.
+func (session *Session) GetProjectUsers(wid int) (users []ProjectUser, err error) {
+	dlog.Printf("Getting project users for workspace %d", wid)
+	path := fmt.Sprintf("/workspaces/%v/project_users", wid)
+	data, err := session.get(TogglAPI, path, nil)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, &users)
+	return
+}
+
 // CreateProject creates a new project.
 func (session *Session) CreateProject(name string, wid int) (proj Project, err error) {
 	dlog.Printf("Creating project %s", name)
@@ -524,6 +832,7 @@ func (session *Session) CreateProject(name string, wid int) (proj Project, err e
 	}
 
 	respData, err := session.post(TogglAPI, "/projects", data)
+	session.invalidate(CacheProjects)
 	if err != nil {
 		return proj, err
 	}
@@ -548,6 +857,7 @@ func (session *Session) UpdateProject(project Project) (Project, error) {
 	}
 	path := fmt.Sprintf("/projects/%v", project.ID)
 	respData, err := session.put(TogglAPI, path, data)
+	session.invalidate(CacheProjects)
 
 	if err != nil {
 		return Project{}, err
@@ -569,7 +879,11 @@ func (session *Session) UpdateProject(project Project) (Project, error) {
 func (session *Session) DeleteProject(project Project) ([]byte, error) {
 	dlog.Printf("Deleting project %v", project)
 	path := fmt.Sprintf("/projects/%v", project.ID)
-	return session.delete(TogglAPI, path)
+	respData, err := session.delete(TogglAPI, path)
+	if err == nil {
+		session.invalidate(CacheProjects, CacheTasks)
+	}
+	return respData, err
 }
 
 // CreateTag creates a new tag.
@@ -583,6 +897,7 @@ func (session *Session) CreateTag(name string, wid int) (proj Tag, err error) {
 	}
 
 	respData, err := session.post(TogglAPI, "/tags", data)
+	session.invalidate(CacheTags)
 	if err != nil {
 		return proj, err
 	}
@@ -607,6 +922,7 @@ func (session *Session) UpdateTag(tag Tag) (Tag, error) {
 	}
 	path := fmt.Sprintf("/tags/%v", tag.ID)
 	respData, err := session.put(TogglAPI, path, data)
+	session.invalidate(CacheTags, CacheTimeEntries)
 
 	if err != nil {
 		return Tag{}, err
@@ -628,12 +944,23 @@ func (session *Session) UpdateTag(tag Tag) (Tag, error) {
 func (session *Session) DeleteTag(tag Tag) ([]byte, error) {
 	dlog.Printf("Deleting tag %v", tag)
 	path := fmt.Sprintf("/tags/%v", tag.ID)
-	return session.delete(TogglAPI, path)
+	respData, err := session.delete(TogglAPI, path)
+	if err == nil {
+		session.invalidate(CacheTags, CacheTimeEntries)
+	}
+	return respData, err
 }
 
 // GetClients returns a list of clients for the current account
 func (session *Session) GetClients() (clients []Client, err error) {
 	dlog.Println("Retrieving clients")
+	if session.cache != nil {
+		var account Account
+		if account, err = session.GetAccount(); err != nil {
+			return
+		}
+		return account.Data.Clients, nil
+	}
 
 	data, err := session.get(TogglAPI, "/clients", nil)
 	if err != nil {
@@ -654,6 +981,7 @@ func (session *Session) CreateClient(name string, wid int) (client Client, err e
 	}
 
 	respData, err := session.post(TogglAPI, "/clients", data)
+	session.invalidate(CacheClients)
 	if err != nil {
 		return client, err
 	}
@@ -787,6 +1115,49 @@ func (e *TimeEntry) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON marshals a TimeEntry to JSON data, formatting timestamp fields
+// the same way Toggl does.
+func (e TimeEntry) MarshalJSON() ([]byte, error) {
+	entry := tempTimeEntry{
+		embeddedTimeEntry: embeddedTimeEntry(e),
+		Start:             formatTimestamp(e.Start),
+		Stop:              formatTimestamp(e.Stop),
+		At:                formatTimestamp(e.At),
+		ServerDeletedAt:   formatTimestamp(e.ServerDeletedAt),
+	}
+	return json.Marshal(entry)
+}
+
+// UnmarshalJSON unmarshals a DetailedTimeEntry from JSON data, converting
+// timestamp fields to Go Time values.
+func (e *DetailedTimeEntry) UnmarshalJSON(b []byte) error {
+	var entry tempDetailedTimeEntry
+	err := json.Unmarshal(b, &entry)
+	if err != nil {
+		return err
+	}
+	de, err := entry.asDetailedTimeEntry()
+	if err != nil {
+		return err
+	}
+	*e = de
+	return nil
+}
+
+// MarshalJSON marshals a DetailedTimeEntry to JSON data, formatting timestamp
+// fields the same way Toggl does.
+func (e DetailedTimeEntry) MarshalJSON() ([]byte, error) {
+	entry := tempDetailedTimeEntry{
+		embeddedDetailedTimeEntry: embeddedDetailedTimeEntry(e),
+		Start:                     formatTimestamp(e.Start),
+		End:                       formatTimestamp(e.End),
+		Updated:                   formatTimestamp(e.Updated),
+		At:                        formatTimestamp(e.At),
+		ServerDeletedAt:           formatTimestamp(e.ServerDeletedAt),
+	}
+	return json.Marshal(entry)
+}
+
 // support /////////////////////////////////////////////////////////////
 
 func (session *Session) request(method string, requestURL string, body io.Reader) ([]byte, error) {
@@ -812,7 +1183,7 @@ func (session *Session) request(method string, requestURL string, body io.Reader
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		return content, fmt.Errorf(resp.Status)
+		return content, &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: content}
 	}
 
 	return content, nil
@@ -926,40 +1297,96 @@ type embeddedTimeEntry TimeEntry
 // tempTimeEntry is an intermediate type used as for decoding TimeEntries.
 type tempTimeEntry struct {
 	embeddedTimeEntry
-	Stop  string `json:"stop"`
-	Start string `json:"start"`
+	Stop            string `json:"stop,omitempty"`
+	Start           string `json:"start,omitempty"`
+	At              string `json:"at,omitempty"`
+	ServerDeletedAt string `json:"server_deleted_at,omitempty"`
 }
 
 func (t *tempTimeEntry) asTimeEntry() (entry TimeEntry, err error) {
 	entry = TimeEntry(t.embeddedTimeEntry)
 
-	parseTime := func(s string) (t time.Time, err error) {
-		t, err = time.Parse("2006-01-02T15:04:05Z", s)
-		if err != nil {
-			t, err = time.Parse("2006-01-02T15:04:05-07:00", s)
-		}
+	if entry.Start, err = parseTimestamp(t.Start); err != nil {
+		return
+	}
+	if entry.Stop, err = parseTimestamp(t.Stop); err != nil {
 		return
 	}
+	if entry.At, err = parseTimestamp(t.At); err != nil {
+		return
+	}
+	entry.ServerDeletedAt, err = parseTimestamp(t.ServerDeletedAt)
+	return
+}
 
-	if t.Start != "" {
-		var start time.Time
-		start, err = parseTime(t.Start)
-		if err != nil {
-			return
-		}
-		entry.Start = &start
+// This is an alias for DetailedTimeEntry that is used in
+// tempDetailedTimeEntry to prevent the unmarshaler from infinitely recursing
+// while unmarshaling.
+type embeddedDetailedTimeEntry DetailedTimeEntry
+
+// tempDetailedTimeEntry is an intermediate type used for decoding
+// DetailedTimeEntries.
+type tempDetailedTimeEntry struct {
+	embeddedDetailedTimeEntry
+	Start           string `json:"start,omitempty"`
+	End             string `json:"end,omitempty"`
+	Updated         string `json:"updated,omitempty"`
+	At              string `json:"at,omitempty"`
+	ServerDeletedAt string `json:"server_deleted_at,omitempty"`
+}
+
+func (t *tempDetailedTimeEntry) asDetailedTimeEntry() (entry DetailedTimeEntry, err error) {
+	entry = DetailedTimeEntry(t.embeddedDetailedTimeEntry)
+
+	if entry.Start, err = parseTimestamp(t.Start); err != nil {
+		return
+	}
+	if entry.End, err = parseTimestamp(t.End); err != nil {
+		return
+	}
+	if entry.Updated, err = parseTimestamp(t.Updated); err != nil {
+		return
 	}
+	if entry.At, err = parseTimestamp(t.At); err != nil {
+		return
+	}
+	entry.ServerDeletedAt, err = parseTimestamp(t.ServerDeletedAt)
+	return
+}
 
-	if t.Stop != "" {
-		var stop time.Time
-		stop, err = parseTime(t.Stop)
-		if err != nil {
-			return
+// timestampLayouts are the timestamp formats used by the various Toggl APIs.
+// RFC3339 also matches timestamps with fractional seconds.
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+}
+
+// parseTimestamp parses a timestamp in any of the formats used by Toggl. An
+// empty string results in a nil time.
+func parseTimestamp(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var err error
+	for _, layout := range timestampLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return &t, nil
 		}
-		entry.Stop = &stop
 	}
+	return nil, fmt.Errorf("Invalid timestamp %q", s)
+}
 
-	return
+// formatTimestamp formats a timestamp as RFC3339, including fractional
+// seconds only when present. A nil time results in an empty string.
+func formatTimestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
 }
 
 func timeEntryRequest(data []byte, err error) (TimeEntry, error) {
@@ -0,0 +1,196 @@
+package toggl
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func FuzzParseTimestamp(f *testing.F) {
+	for _, seed := range []string{
+		"2026-10-12T09:30:00Z",
+		"2026-10-12T09:30:00+00:00",
+		"2026-10-12T09:30:00+0000",
+		"2026-10-12T11:30:00+02:00",
+		"2026-10-12T09:30:00.123456Z",
+		"2026-10-12T09:30:00.5-07:00",
+		"2026-10-12 09:30:00Z",
+		"2026-10-12 09:30:00+00:00",
+		"2026-10-12 09:30:00+0530",
+		"",
+		"not a time",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		start, err := parseTimestamp(s)
+		if err != nil || start == nil {
+			return
+		}
+
+		if again, err := parseTimestamp(formatTimestamp(start)); err != nil || !again.Equal(*start) {
+			t.Fatalf("%q formatted as %q parses as %v, %v", s, formatTimestamp(start), again, err)
+		}
+
+		data, err := json.Marshal(TimeEntry{ID: 1, Start: start, Stop: start})
+		if err != nil {
+			t.Fatalf("Marshaling %q: %v", s, err)
+		}
+		var entry TimeEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatalf("Unmarshaling %s: %v", data, err)
+		}
+		if entry.Start == nil || !entry.Start.Equal(*start) || entry.Stop == nil || !entry.Stop.Equal(*start) {
+			t.Fatalf("%q round-tripped through %s as %v", s, data, entry.Start)
+		}
+	})
+}
+
+// roundTripFunc is an http.RoundTripper serving requests with a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestGetAllTimeEntries(t *testing.T) {
+	// Entries every half hour for three months, and a busy day in the
+	// second month with more entries than a request returns
+	start := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 3, 0)
+	var stored []TimeEntry
+	for at := start; at.Before(end); at = at.Add(30 * time.Minute) {
+		stored = append(stored, TimeEntry{ID: len(stored) + 1, Start: timePtr(at), Duration: 60})
+	}
+	busy := time.Date(2026, 8, 12, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2500; i++ {
+		stored = append(stored, TimeEntry{ID: len(stored) + 1, Start: timePtr(busy.Add(time.Duration(i) * 30 * time.Second)), Duration: 10})
+	}
+
+	requests := 0
+	saved := client
+	defer func() { client = saved }()
+	client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		query := req.URL.Query()
+		from, err := time.Parse(time.RFC3339, query.Get("start_date"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		to, err := time.Parse(time.RFC3339, query.Get("end_date"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if to.After(from.AddDate(0, 1, 0)) {
+			t.Errorf("request for %v to %v spans more than a month", from, to)
+		}
+		var page []TimeEntry
+		for _, e := range stored {
+			if !e.Start.Before(from) && !e.Start.After(to) && len(page) < TimeEntriesLimit {
+				page = append(page, e)
+			}
+		}
+		body, _ := json.Marshal(page)
+		return &http.Response{StatusCode: 200, Status: "200 OK", Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+	})}
+
+	DisableLog()
+	session := OpenSession("token")
+	entries, err := session.GetAllTimeEntries(start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(stored) {
+		t.Errorf("got %d entries, want %d", len(entries), len(stored))
+	}
+	seen := make(map[int]bool)
+	for _, e := range entries {
+		if seen[e.ID] {
+			t.Errorf("entry %d returned twice", e.ID)
+		}
+		seen[e.ID] = true
+	}
+	if requests < 4 {
+		t.Errorf("%d requests, expected the busy month to be split", requests)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestContinueTimeEntry(t *testing.T) {
+	// A time zone in which it's noon, so that an hour ago is today
+	now := time.Now()
+	midnight := time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	noon := time.FixedZone("noon", int((12*time.Hour-now.Sub(midnight))/time.Second))
+
+	var method, path string
+	var sent struct {
+		TimeEntry TimeEntry `json:"time_entry"`
+	}
+	saved := client
+	defer func() { client = saved }()
+	client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		method, path = req.Method, req.URL.Path
+		sent.TimeEntry = TimeEntry{}
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		body, _ := json.Marshal(map[string]interface{}{"data": sent.TimeEntry})
+		return &http.Response{StatusCode: 200, Status: "200 OK", Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+	})}
+
+	DisableLog()
+	session := OpenSession("token")
+	session.SetLocation(noon)
+	earlier := now.Add(-3 * time.Hour)
+	timer := TimeEntry{ID: 7, Description: "Writing", Start: &earlier, Duration: 3600, DurOnly: true}
+
+	if _, err := session.ContinueTimeEntry(timer, true); err != nil {
+		t.Fatal(err)
+	}
+	start := now.Add(-time.Hour)
+	if method != "PUT" || path != "/api/v8/time_entries/7" {
+		t.Errorf("today's entry was continued with %s %s", method, path)
+	}
+	if e := sent.TimeEntry; e.Start == nil || e.Start.Sub(start) > 2*time.Second || e.Start.Sub(start) < -2*time.Second ||
+		e.Duration != -e.Start.Unix() || e.Stop != nil || !e.DurOnly {
+		t.Errorf("today's entry was continued as %+v, want it running since %v", e, start)
+	}
+
+	long := timer
+	long.Duration = int64(13 * time.Hour / time.Second)
+	if _, err := session.ContinueTimeEntry(long, true); err != nil {
+		t.Fatal(err)
+	}
+	if method != "POST" || path != "/api/v8/time_entries/start" {
+		t.Errorf("an entry longer than today was continued with %s %s", method, path)
+	}
+
+	yesterday := now.Add(-24 * time.Hour)
+	old := timer
+	old.Start = &yesterday
+	if _, err := session.ContinueTimeEntry(old, true); err != nil {
+		t.Fatal(err)
+	}
+	if method != "POST" || path != "/api/v8/time_entries/start" {
+		t.Errorf("yesterday's entry was continued with %s %s", method, path)
+	}
+
+	method = ""
+	running := timer
+	running.Duration = -earlier.Unix()
+	if _, err := session.ContinueTimeEntry(running, true); err == nil {
+		t.Errorf("a running entry was continued")
+	} else if _, ok := err.(*TimerRunningError); !ok {
+		t.Errorf("continuing a running entry returned %v", err)
+	}
+	if method != "" {
+		t.Errorf("continuing a running entry sent %s %s", method, path)
+	}
+}
@@ -0,0 +1,226 @@
+package toggl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Names given to the Find methods are first matched exactly, ignoring case.
+// If nothing matches exactly, the names of projects, clients and workspaces
+// are matched fuzzily, as with FuzzyMatch, and the best match is used if no
+// other match scores as well; otherwise an *AmbiguityError lists the best
+// matches. Tags are only matched exactly, since a tag that matches nothing is
+// a new tag.
+
+// fuzziness is how findName treats a name that matches nothing exactly.
+type fuzziness int
+
+const (
+	// fuzzyBest uses the best fuzzy match.
+	fuzzyBest fuzziness = iota
+	// fuzzyReport reports the fuzzy matches as an *AmbiguityError rather
+	// than guessing.
+	fuzzyReport
+	// fuzzyNone doesn't match fuzzily.
+	fuzzyNone
+)
+
+// maxReportedMatches limits the fuzzy matches fuzzyReport lists.
+const maxReportedMatches = 5
+
+// ProjectTitle returns a project's name, prefixed with its client's name and
+// a slash if it has a client. FindProject accepts titles to tell apart
+// projects with the same name.
+func (a *Account) ProjectTitle(p Project) string {
+	if client := a.clientOf(p); client != nil {
+		return client.Name + "/" + p.Name
+	}
+	return p.Name
+}
+
+// FindProject finds the active project with the given name, optionally
+// prefixed with its client's name and a slash.
+func (a *Account) FindProject(name string) (*Project, error) {
+	return a.findProject(name, fuzzyBest)
+}
+
+func (a *Account) findProject(name string, fuzzy fuzziness) (*Project, error) {
+	var projects []*Project
+	var names [][]string
+	var titles []string
+
+	for i := range a.Data.Projects {
+		p := &a.Data.Projects[i]
+		if !p.IsActive() {
+			continue
+		}
+		title := a.ProjectTitle(*p)
+		projects = append(projects, p)
+		names = append(names, []string{p.Name, title})
+		titles = append(titles, title)
+	}
+
+	i, err := findName("project", name, names, titles, fuzzy)
+	if err != nil {
+		return nil, err
+	}
+	return projects[i], nil
+}
+
+// FindClient finds the client with the given name.
+func (a *Account) FindClient(name string) (*Client, error) {
+	var clients []*Client
+	var names [][]string
+	var titles []string
+
+	for i := range a.Data.Clients {
+		c := &a.Data.Clients[i]
+		if c.ServerDeletedAt != nil {
+			continue
+		}
+		clients = append(clients, c)
+		names = append(names, []string{c.Name})
+		titles = append(titles, c.Name)
+	}
+
+	i, err := findName("client", name, names, titles, fuzzyBest)
+	if err != nil {
+		return nil, err
+	}
+	return clients[i], nil
+}
+
+// FindWorkspace finds the workspace with the given name.
+func (a *Account) FindWorkspace(name string) (*Workspace, error) {
+	var workspaces []*Workspace
+	var names [][]string
+	var titles []string
+
+	for i := range a.Data.Workspaces {
+		w := &a.Data.Workspaces[i]
+		if w.ServerDeletedAt != nil {
+			continue
+		}
+		workspaces = append(workspaces, w)
+		names = append(names, []string{w.Name})
+		titles = append(titles, w.Name)
+	}
+
+	i, err := findName("workspace", name, names, titles, fuzzyBest)
+	if err != nil {
+		return nil, err
+	}
+	return workspaces[i], nil
+}
+
+// FindTag finds the tag with the given name, ignoring case. A non-zero wid
+// limits the search to the tags of a workspace. To resolve the tags of new
+// entries, which may not exist yet, use ResolveTag.
+func (a *Account) FindTag(name string, wid int) (*Tag, error) {
+	var tags []*Tag
+	var names [][]string
+	var titles []string
+
+	for i := range a.Data.Tags {
+		t := &a.Data.Tags[i]
+		if t.ServerDeletedAt != nil || (wid != 0 && t.Wid != wid) {
+			continue
+		}
+		if indexOfTag(t.Name, titles) != -1 {
+			// The same tag in another workspace
+			continue
+		}
+		tags = append(tags, t)
+		names = append(names, []string{t.Name})
+		titles = append(titles, t.Name)
+	}
+
+	i, err := findName("tag", name, names, titles, fuzzyNone)
+	if err != nil {
+		return nil, err
+	}
+	return tags[i], nil
+}
+
+// findName returns the index of the candidate with the given name. Each
+// candidate can have several names, and is described by its title in
+// errors.
+func findName(kind, name string, names [][]string, titles []string, fuzzy fuzziness) (int, error) {
+	var exact []int
+	for i, candidate := range names {
+		for _, n := range candidate {
+			if strings.EqualFold(n, name) {
+				exact = append(exact, i)
+				break
+			}
+		}
+	}
+	switch len(exact) {
+	case 0:
+	case 1:
+		return exact[0], nil
+	default:
+		return -1, ambiguity(kind, name, exact, titles)
+	}
+
+	if fuzzy == fuzzyNone {
+		return -1, fmt.Errorf("No %s named %q", kind, name)
+	}
+
+	var matches []int
+	scores := make(map[int]int)
+	for i, candidate := range names {
+		best, found := 0, false
+		for _, n := range candidate {
+			if score, ok := FuzzyMatch(name, n); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		if found {
+			matches = append(matches, i)
+			scores[i] = best
+		}
+	}
+	if len(matches) == 0 {
+		return -1, fmt.Errorf("No %s named %q", kind, name)
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		return scores[matches[a]] > scores[matches[b]]
+	})
+	if fuzzy == fuzzyReport {
+		if len(matches) > maxReportedMatches {
+			matches = matches[:maxReportedMatches]
+		}
+		return -1, ambiguity(kind, name, matches, titles)
+	}
+	best := 1
+	for best < len(matches) && scores[matches[best]] == scores[matches[0]] {
+		best++
+	}
+	if best == 1 {
+		return matches[0], nil
+	}
+	return -1, ambiguity(kind, name, matches[:best], titles)
+}
+
+func ambiguity(kind, name string, indexes []int, titles []string) *AmbiguityError {
+	err := &AmbiguityError{Kind: kind, Name: name}
+	for _, i := range indexes {
+		err.Matches = append(err.Matches, titles[i])
+	}
+	return err
+}
+
+func (a *Account) clientOf(p Project) *Client {
+	if p.Cid == 0 {
+		return nil
+	}
+	for i := range a.Data.Clients {
+		if a.Data.Clients[i].ID == p.Cid {
+			return &a.Data.Clients[i]
+		}
+	}
+	return nil
+}
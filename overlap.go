@@ -0,0 +1,310 @@
+package toggl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Overlap describes two time entries whose intervals overlap.
+type Overlap struct {
+	Earlier TimeEntry
+	Later   TimeEntry
+	// Start and End delimit the interval covered by both entries.
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the length of the overlapping interval.
+func (o *Overlap) Duration() time.Duration {
+	return o.End.Sub(o.Start)
+}
+
+// OverlapStrategy determines how PlanOverlapResolution resolves overlapping
+// time entries.
+type OverlapStrategy int
+
+// Overlap resolution strategies.
+const (
+	// TrimEarlier stops the earlier entry when the later one starts.
+	TrimEarlier OverlapStrategy = iota
+	// TrimLater starts the later entry when the earlier one stops.
+	TrimLater
+	// Split stops the earlier entry when the later one starts and, if the
+	// earlier entry ran past the later one, continues it in a new entry once
+	// the later one stops.
+	Split
+	// DropShorter deletes the shorter of the two entries.
+	DropShorter
+)
+
+// Kinds of OverlapAction.
+const (
+	OverlapUpdate = "update"
+	OverlapDelete = "delete"
+	OverlapCreate = "create"
+)
+
+// OverlapAction is a single change in an OverlapPlan.
+type OverlapAction struct {
+	Kind string
+	// Entry is the entry to create, the updated version of an entry, or the
+	// entry to delete.
+	Entry TimeEntry
+	// Original is the entry before an update.
+	Original TimeEntry
+}
+
+// OverlapPlan is a list of changes that removes the overlaps between a set of
+// time entries. It can be previewed with String and applied with
+// Session.ApplyOverlapPlan.
+type OverlapPlan struct {
+	Actions []OverlapAction
+}
+
+// FindOverlaps returns every pair of overlapping time entries, ordered by the
+// start of the earlier entry. Running entries are treated as ending now, and
+// entries without a start time are ignored.
+func FindOverlaps(entries []TimeEntry) (overlaps []Overlap) {
+	now := time.Now()
+	items := newOverlapItems(entries)
+	for _, pair := range findOverlaps(items, now) {
+		earlier, later := &items[pair[0]], &items[pair[1]]
+		start, _ := bounds(&later.entry, now)
+		_, end := bounds(&earlier.entry, now)
+		if _, laterEnd := bounds(&later.entry, now); laterEnd.Before(end) {
+			end = laterEnd
+		}
+		overlaps = append(overlaps, Overlap{
+			Earlier: earlier.entry,
+			Later:   later.entry,
+			Start:   start,
+			End:     end,
+		})
+	}
+	return
+}
+
+// PlanOverlapResolution returns the changes needed to remove every overlap
+// between the given time entries using the given strategy. Entries that end
+// up with no time left are deleted rather than trimmed. Running entries are
+// treated as ending now, and remain running unless their end is trimmed.
+func PlanOverlapResolution(entries []TimeEntry, strategy OverlapStrategy) (plan OverlapPlan) {
+	now := time.Now()
+	items := newOverlapItems(entries)
+
+	// Overlaps are found again after each one is resolved, since the rest of
+	// an entry Split continues can overlap a third entry, as can an entry
+	// TrimLater starts later. Each step removes the overlap of a pair without
+	// covering any new time, so this ends.
+	for {
+		pairs := findOverlaps(items, now)
+		if len(pairs) == 0 {
+			break
+		}
+		items = resolveOverlap(items, pairs[0][0], pairs[0][1], strategy, now)
+	}
+
+	for _, item := range items {
+		switch {
+		case item.original == -1 && !item.deleted:
+			plan.Actions = append(plan.Actions, OverlapAction{Kind: OverlapCreate, Entry: item.entry})
+		case item.original == -1:
+		case item.deleted:
+			plan.Actions = append(plan.Actions, OverlapAction{Kind: OverlapDelete, Entry: entries[item.original]})
+		case item.changed:
+			plan.Actions = append(plan.Actions, OverlapAction{
+				Kind:     OverlapUpdate,
+				Entry:    item.entry,
+				Original: entries[item.original],
+			})
+		}
+	}
+
+	// Deletions and trims go first so new entries never overlap on the server
+	sort.SliceStable(plan.Actions, func(i, j int) bool {
+		return plan.Actions[i].Kind != OverlapCreate && plan.Actions[j].Kind == OverlapCreate
+	})
+	return
+}
+
+// IsEmpty returns true if the plan contains no changes.
+func (p *OverlapPlan) IsEmpty() bool {
+	return len(p.Actions) == 0
+}
+
+// String returns a human readable preview of the plan, one change per line.
+func (p *OverlapPlan) String() string {
+	var buf bytes.Buffer
+	for _, action := range p.Actions {
+		switch action.Kind {
+		case OverlapUpdate:
+			fmt.Fprintf(&buf, "update %d %q: %s -> %s\n", action.Entry.ID, action.Entry.Description,
+				formatInterval(&action.Original), formatInterval(&action.Entry))
+		case OverlapDelete:
+			fmt.Fprintf(&buf, "delete %d %q: %s\n", action.Entry.ID, action.Entry.Description,
+				formatInterval(&action.Entry))
+		case OverlapCreate:
+			fmt.Fprintf(&buf, "create %q: %s\n", action.Entry.Description, formatInterval(&action.Entry))
+		}
+	}
+	return buf.String()
+}
+
+// ApplyOverlapPlan applies the changes in a plan in order, returning the
+// updated and created entries. It stops at the first change that fails.
+func (session *Session) ApplyOverlapPlan(plan OverlapPlan) (entries []TimeEntry, err error) {
+	for _, action := range plan.Actions {
+		var entry TimeEntry
+		switch action.Kind {
+		case OverlapUpdate:
+			entry, err = session.UpdateTimeEntry(action.Entry)
+		case OverlapDelete:
+			_, err = session.DeleteTimeEntry(action.Entry)
+		case OverlapCreate:
+			entry, err = session.CreateTimeEntry(action.Entry)
+		default:
+			err = fmt.Errorf("Unknown overlap action %q", action.Kind)
+		}
+		if err != nil {
+			return entries, fmt.Errorf("Unable to %s time entry %d: %v", action.Kind, action.Entry.ID, err)
+		}
+		if action.Kind != OverlapDelete {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// support /////////////////////////////////////////////////////////////
+
+// overlapItem tracks an entry while overlaps are being resolved.
+type overlapItem struct {
+	entry TimeEntry
+	// original is the entry's index in the input, or -1 for new entries.
+	original int
+	changed  bool
+	deleted  bool
+}
+
+func newOverlapItems(entries []TimeEntry) []overlapItem {
+	items := make([]overlapItem, 0, len(entries))
+	for i := range entries {
+		if entries[i].Start != nil {
+			items = append(items, overlapItem{entry: entries[i].Copy(), original: i})
+		}
+	}
+	return items
+}
+
+// bounds returns the interval covered by an entry, treating running entries
+// as ending at now.
+func bounds(e *TimeEntry, now time.Time) (start, end time.Time) {
+	start = e.StartTime()
+	switch {
+	case e.IsRunning():
+		end = now
+	case e.Stop != nil:
+		end = *e.Stop
+	default:
+		end = start.Add(time.Duration(e.Duration) * time.Second)
+	}
+	return
+}
+
+// setBounds changes the interval covered by an entry. A running entry stays
+// running unless its end is changed.
+func setBounds(e *TimeEntry, start, end, now time.Time) {
+	running := e.IsRunning() && end.Equal(now)
+	e.Start = &start
+	if running {
+		e.Stop = nil
+		e.Duration = -start.Unix()
+	} else {
+		e.Stop = &end
+		e.Duration = int64(end.Sub(start) / time.Second)
+	}
+}
+
+// findOverlaps returns the indexes of overlapping pairs of live items, earlier
+// item first, ordered by start time.
+func findOverlaps(items []overlapItem, now time.Time) (pairs [][2]int) {
+	order := make([]int, 0, len(items))
+	for i := range items {
+		// Empty entries can't overlap anything
+		if start, end := bounds(&items[i].entry, now); !items[i].deleted && end.After(start) {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return items[order[a]].entry.Start.Before(*items[order[b]].entry.Start)
+	})
+
+	for a, i := range order {
+		_, end := bounds(&items[i].entry, now)
+		for _, j := range order[a+1:] {
+			start, _ := bounds(&items[j].entry, now)
+			if !start.Before(end) {
+				break
+			}
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	return
+}
+
+// resolveOverlap resolves the overlap between an earlier and a later item
+// using the given strategy.
+func resolveOverlap(items []overlapItem, i, j int, strategy OverlapStrategy, now time.Time) []overlapItem {
+	earlier, later := &items[i], &items[j]
+	earlierStart, earlierEnd := bounds(&earlier.entry, now)
+	laterStart, laterEnd := bounds(&later.entry, now)
+
+	trimEarlier := func() {
+		if !laterStart.After(earlierStart) {
+			earlier.deleted = true
+			return
+		}
+		setBounds(&earlier.entry, earlierStart, laterStart, now)
+		earlier.changed = true
+	}
+
+	switch strategy {
+	case TrimEarlier:
+		trimEarlier()
+	case TrimLater:
+		if !laterEnd.After(earlierEnd) {
+			later.deleted = true
+			break
+		}
+		setBounds(&later.entry, earlierEnd, laterEnd, now)
+		later.changed = true
+	case Split:
+		if earlierEnd.After(laterEnd) {
+			rest := earlier.entry.Copy()
+			rest.ID = 0
+			setBounds(&rest, laterEnd, earlierEnd, now)
+			trimEarlier()
+			return append(items, overlapItem{entry: rest, original: -1})
+		}
+		trimEarlier()
+	case DropShorter:
+		if earlierEnd.Sub(earlierStart) < laterEnd.Sub(laterStart) {
+			earlier.deleted = true
+		} else {
+			later.deleted = true
+		}
+	}
+	return items
+}
+
+// formatInterval formats the interval covered by an entry for previews.
+func formatInterval(e *TimeEntry) string {
+	const layout = "2006-01-02 15:04:05"
+	if e.IsRunning() {
+		return e.StartTime().Format(layout) + " - (running)"
+	}
+	start, end := bounds(e, time.Now())
+	return start.Format(layout) + " - " + end.Format(layout)
+}
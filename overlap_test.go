@@ -0,0 +1,130 @@
+package toggl
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPlanOverlapResolution(t *testing.T) {
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", "2026-10-12 "+clock)
+		return t
+	}
+	entry := func(id int, from, to string) TimeEntry {
+		start, stop := at(from), at(to)
+		return TimeEntry{ID: id, Description: fmt.Sprint("entry ", id), Start: &start, Stop: &stop, Duration: int64(stop.Sub(start) / time.Second)}
+	}
+	entries := []TimeEntry{
+		// 2 and 3 both fall within 1, so the rest of 1 that Split continues
+		// after 2 still overlaps 3
+		entry(1, "09:00", "12:00"),
+		entry(2, "10:00", "10:30"),
+		entry(3, "11:00", "11:30"),
+		// A plain overlap of 30 minutes
+		entry(4, "13:00", "14:00"),
+		entry(5, "13:30", "15:00"),
+		// 7 overlaps both 6 and 8, and still overlaps 8 once started later
+		entry(6, "16:00", "17:00"),
+		entry(7, "16:30", "17:30"),
+		entry(8, "17:15", "18:00"),
+	}
+
+	tests := []struct {
+		strategy OverlapStrategy
+		want     []string
+	}{
+		{TrimEarlier, []string{
+			"1 09:00-10:00", "2 10:00-10:30", "3 11:00-11:30",
+			"4 13:00-13:30", "5 13:30-15:00",
+			"6 16:00-16:30", "7 16:30-17:15", "8 17:15-18:00",
+		}},
+		{TrimLater, []string{
+			"1 09:00-12:00",
+			"4 13:00-14:00", "5 14:00-15:00",
+			"6 16:00-17:00", "7 17:00-17:30", "8 17:30-18:00",
+		}},
+		{Split, []string{
+			"1 09:00-10:00", "2 10:00-10:30", "new 1 10:30-11:00", "3 11:00-11:30", "new 1 11:30-12:00",
+			"4 13:00-13:30", "5 13:30-15:00",
+			"6 16:00-16:30", "7 16:30-17:15", "8 17:15-18:00",
+		}},
+		{DropShorter, []string{
+			"1 09:00-12:00",
+			"5 13:30-15:00",
+			// 6 and 7 are as long, and the later one goes
+			"6 16:00-17:00", "8 17:15-18:00",
+		}},
+	}
+
+	for _, test := range tests {
+		plan := PlanOverlapResolution(entries, test.strategy)
+		result := append([]TimeEntry(nil), entries...)
+		created := false
+		for _, action := range plan.Actions {
+			switch action.Kind {
+			case OverlapCreate:
+				created = true
+				result = append(result, action.Entry)
+				continue
+			case OverlapUpdate, OverlapDelete:
+				if created {
+					t.Errorf("strategy %d: %s %d after a creation", test.strategy, action.Kind, action.Entry.ID)
+				}
+			}
+			for i := range result {
+				if result[i].ID == action.Entry.ID {
+					if action.Kind == OverlapDelete {
+						result = append(result[:i], result[i+1:]...)
+					} else {
+						result[i] = action.Entry
+					}
+					break
+				}
+			}
+		}
+
+		if overlaps := FindOverlaps(result); len(overlaps) > 0 {
+			t.Errorf("strategy %d leaves %d overlaps", test.strategy, len(overlaps))
+		}
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Start.Before(*result[j].Start) })
+		var got []string
+		for _, e := range result {
+			name := fmt.Sprint(e.ID)
+			if e.ID == 0 {
+				name = "new " + e.Description[len("entry "):]
+			}
+			got = append(got, fmt.Sprintf("%s %s-%s", name, e.Start.Format("15:04"), e.Stop.Format("15:04")))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("strategy %d: got %q, want %q", test.strategy, got, test.want)
+		}
+	}
+
+	if !entries[0].Stop.Equal(at("12:00")) {
+		t.Errorf("planning changed entry 1 to stop at %v", entries[0].Stop)
+	}
+}
+
+func TestPlanOverlapResolutionRunning(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-time.Hour)
+	running := TimeEntry{ID: 1, Start: &start, Duration: -start.Unix()}
+	laterStart, laterStop := now.Add(-30*time.Minute), now.Add(-20*time.Minute)
+	later := TimeEntry{ID: 2, Start: &laterStart, Stop: &laterStop, Duration: 600}
+
+	// The earlier entry is trimmed and stops, so it's no longer running
+	plan := PlanOverlapResolution([]TimeEntry{running, later}, TrimEarlier)
+	if len(plan.Actions) != 1 || plan.Actions[0].Entry.IsRunning() || !plan.Actions[0].Entry.Stop.Equal(laterStart) {
+		t.Errorf("TrimEarlier = %+v", plan.Actions)
+	}
+
+	// The rest of it continues after the later entry, and keeps running
+	plan = PlanOverlapResolution([]TimeEntry{running, later}, Split)
+	if len(plan.Actions) != 2 || plan.Actions[1].Kind != OverlapCreate || !plan.Actions[1].Entry.IsRunning() ||
+		!plan.Actions[1].Entry.Start.Equal(laterStop) {
+		t.Errorf("Split = %+v", plan.Actions)
+	}
+}
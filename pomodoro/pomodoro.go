@@ -0,0 +1,415 @@
+/*
+Package pomodoro drives Toggl timers through pomodoro cycles: periods of work
+separated by short breaks, with a long break after every few work periods.
+
+Every period is a real time entry. Work entries are copies of the task being
+worked on, tagged with the work tag; breaks are separate entries tagged with
+the break tag. Because the state lives in Toggl, a timer can be reconstructed
+after a restart from the running entry and the entries tracked today:
+
+	timer := pomodoro.New(&session, pomodoro.DefaultConfig(), pomodoro.WriterNotifier{W: os.Stdout})
+	err := timer.Run(ctx, toggl.TimeEntry{Description: "Write report", Pid: 123})
+*/
+package pomodoro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// Phase is a period of a pomodoro cycle.
+type Phase string
+
+// Phases of a pomodoro cycle. Idle means no pomodoro entry is running.
+const (
+	Idle       Phase = "idle"
+	Work       Phase = "work"
+	ShortBreak Phase = "short break"
+	LongBreak  Phase = "long break"
+)
+
+var phaseTitles = map[Phase]string{
+	Idle:       "Idle",
+	Work:       "Work",
+	ShortBreak: "Short break",
+	LongBreak:  "Long break",
+}
+
+// ErrInterrupted is returned by Run when the pomodoro entry was stopped or
+// replaced outside the timer.
+var ErrInterrupted = errors.New("Pomodoro timer was interrupted")
+
+// Config sets the lengths of the periods of a cycle and how their entries are
+// tagged. Lengths that aren't positive and empty tags and descriptions get
+// the values of DefaultConfig; a zero LongBreakEvery means no long breaks.
+type Config struct {
+	Work       time.Duration
+	ShortBreak time.Duration
+	LongBreak  time.Duration
+	// LongBreakEvery is the number of work periods before a long break.
+	LongBreakEvery int
+	// WorkTag and BreakTag identify pomodoro entries.
+	WorkTag  string
+	BreakTag string
+	// BreakDescription is the description of break entries.
+	BreakDescription string
+	// PollInterval is how often Run checks that the pomodoro entry is still
+	// running.
+	PollInterval time.Duration
+}
+
+// DefaultConfig returns the classic pomodoro configuration: 25 minutes of
+// work, 5 minute breaks and a 15 minute break after every 4 work periods.
+func DefaultConfig() Config {
+	return Config{
+		Work:             25 * time.Minute,
+		ShortBreak:       5 * time.Minute,
+		LongBreak:        15 * time.Minute,
+		LongBreakEvery:   4,
+		WorkTag:          "pomodoro",
+		BreakTag:         "break",
+		BreakDescription: "Break",
+		PollInterval:     time.Minute,
+	}
+}
+
+// State is the state of a pomodoro timer.
+type State struct {
+	Phase Phase
+	// Entry is the running entry for the current phase.
+	Entry toggl.TimeEntry
+	// Task is the entry work periods are copies of.
+	Task toggl.TimeEntry
+	// Completed is the number of work periods completed today.
+	Completed int
+	// Ends is the time the current phase ends.
+	Ends time.Time
+}
+
+// Remaining returns the time left in the current phase.
+func (s State) Remaining(now time.Time) time.Duration {
+	if s.Phase == Idle || now.After(s.Ends) {
+		return 0
+	}
+	return s.Ends.Sub(now)
+}
+
+// Notification describes the end of a phase.
+type Notification struct {
+	// Finished is the phase that ended and Next the one that started.
+	Finished Phase
+	Next     Phase
+	State    State
+	Message  string
+}
+
+// Notifier delivers notifications, for example as desktop notifications or
+// terminal bells. Notify is called synchronously, so it should not block for
+// long.
+type Notifier interface {
+	Notify(Notification)
+}
+
+// NotifierFunc adapts a function to the Notifier interface.
+type NotifierFunc func(Notification)
+
+// Notify calls f.
+func (f NotifierFunc) Notify(n Notification) {
+	f(n)
+}
+
+// WriterNotifier writes notification messages to a writer, one per line.
+type WriterNotifier struct {
+	W io.Writer
+}
+
+// Notify writes the notification's message.
+func (w WriterNotifier) Notify(n Notification) {
+	fmt.Fprintln(w.W, n.Message)
+}
+
+// Timer runs pomodoro cycles.
+type Timer struct {
+	Session  *toggl.Session
+	Config   Config
+	Notifier Notifier
+
+	tracker *toggl.Tracker
+	state   State
+}
+
+// New creates a pomodoro timer. The notifier may be nil.
+func New(session *toggl.Session, config Config, notifier Notifier) *Timer {
+	return &Timer{
+		Session:  session,
+		Config:   config,
+		Notifier: notifier,
+		tracker:  toggl.NewTracker(session),
+		state:    State{Phase: Idle},
+	}
+}
+
+// State returns the timer's last known state.
+func (t *Timer) State() State {
+	return t.state
+}
+
+// Restore reconstructs the timer's state from the running entry and the
+// entries tracked today, so that a cycle can be picked up after a restart.
+// Work periods count as completed if they lasted at least 90% of the work
+// length.
+func (t *Timer) Restore() (State, error) {
+	current, err := t.tracker.Current()
+	if err != nil {
+		return t.state, err
+	}
+
+	now := time.Now()
+	calendar := toggl.Calendar{Location: t.Session.Location()}
+	entries, err := t.Session.GetTimeEntries(calendar.DayStart(now), now)
+	if err != nil {
+		return t.state, err
+	}
+
+	config := t.config()
+	state := State{Phase: Idle}
+	var lastWork *toggl.TimeEntry
+	for i := range entries {
+		e := &entries[i]
+		if e.ServerDeletedAt != nil || !e.HasTag(config.WorkTag) {
+			continue
+		}
+		if lastWork == nil || e.StartTime().After(lastWork.StartTime()) {
+			lastWork = e
+		}
+		if !e.IsRunning() && e.LiveDuration() >= t.length(Work)*9/10 {
+			state.Completed++
+		}
+	}
+	if lastWork != nil {
+		state.Task = t.task(*lastWork)
+	}
+
+	if current != nil {
+		switch {
+		case current.HasTag(config.WorkTag):
+			state.Phase = Work
+			state.Task = t.task(*current)
+		case current.HasTag(config.BreakTag):
+			state.Phase = t.breakPhase(state.Completed)
+		}
+		if state.Phase != Idle {
+			state.Entry = *current
+			state.Ends = current.StartTime().Add(t.length(state.Phase))
+		}
+	}
+
+	t.state = state
+	return state, nil
+}
+
+// StartWork starts a work period on a task, stopping any running timer.
+func (t *Timer) StartWork(task toggl.TimeEntry) (State, error) {
+	t.state.Task = t.task(task)
+	return t.begin(Work)
+}
+
+// Advance ends the current phase and starts the next one: a break after work
+// and work after a break. The task of the last work period is resumed after a
+// break.
+func (t *Timer) Advance() (State, error) {
+	finished := t.state.Phase
+	next := Work
+	if finished == Work {
+		next = t.breakPhase(t.state.Completed + 1)
+	}
+
+	state, err := t.begin(next)
+	if err != nil {
+		return state, err
+	}
+	if finished == Work {
+		t.state.Completed++
+		state = t.state
+	}
+	t.notify(finished, next)
+	return state, nil
+}
+
+// Stop stops the running pomodoro entry and ends the cycle.
+func (t *Timer) Stop() (State, error) {
+	if t.state.Phase != Idle {
+		if _, err := t.tracker.Stop(); err != nil && err != toggl.ErrNoRunningTimer {
+			return t.state, err
+		}
+	}
+	t.state.Phase = Idle
+	t.state.Entry = toggl.TimeEntry{}
+	t.state.Ends = time.Time{}
+	return t.state, nil
+}
+
+// Run restores the timer's state and runs cycles until ctx is done. If no
+// pomodoro entry is running, a work period on the given task is started. Run
+// returns ErrInterrupted if the pomodoro entry is stopped or replaced by
+// another client, and ctx.Err() when ctx is done; the running entry is left
+// running in that case.
+func (t *Timer) Run(ctx context.Context, task toggl.TimeEntry) error {
+	state, err := t.Restore()
+	if err != nil {
+		return err
+	}
+	if state.Phase == Idle {
+		if _, err := t.StartWork(task); err != nil {
+			return err
+		}
+	}
+
+	poll := t.config().PollInterval
+
+	for {
+		wait := t.state.Remaining(time.Now())
+		if wait > poll {
+			wait = poll
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		current, err := t.tracker.Current()
+		if err != nil {
+			return err
+		}
+		if current == nil || current.ID != t.state.Entry.ID {
+			t.state.Phase = Idle
+			return ErrInterrupted
+		}
+
+		if !time.Now().Before(t.state.Ends) {
+			if _, err := t.Advance(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// support /////////////////////////////////////////////////////////////
+
+// begin switches the running timer to an entry for the given phase.
+func (t *Timer) begin(phase Phase) (State, error) {
+	config := t.config()
+	var entry toggl.TimeEntry
+	if phase == Work {
+		entry = t.state.Task.Copy()
+		entry.AddTag(config.WorkTag)
+	} else {
+		entry = toggl.TimeEntry{
+			Wid:         t.state.Task.Wid,
+			Description: config.BreakDescription,
+			Tags:        []string{config.BreakTag},
+		}
+	}
+
+	started, err := t.tracker.Switch(entry)
+	if err != nil {
+		return t.state, err
+	}
+	t.state.Phase = phase
+	t.state.Entry = started
+	t.state.Ends = started.StartTime().Add(t.length(phase))
+	return t.state, nil
+}
+
+// task returns the task a work entry is a copy of.
+func (t *Timer) task(entry toggl.TimeEntry) toggl.TimeEntry {
+	task := toggl.TimeEntry{
+		Wid:         entry.Wid,
+		Pid:         entry.Pid,
+		Tid:         entry.Tid,
+		Description: entry.Description,
+		Billable:    entry.Billable,
+	}
+	for _, tag := range entry.Tags {
+		if tag != t.config().WorkTag {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+	return task
+}
+
+func (t *Timer) breakPhase(completed int) Phase {
+	if t.Config.LongBreakEvery > 0 && completed > 0 && completed%t.Config.LongBreakEvery == 0 {
+		return LongBreak
+	}
+	return ShortBreak
+}
+
+func (t *Timer) length(phase Phase) time.Duration {
+	config := t.config()
+	switch phase {
+	case Work:
+		return config.Work
+	case ShortBreak:
+		return config.ShortBreak
+	case LongBreak:
+		return config.LongBreak
+	}
+	return 0
+}
+
+// config returns the timer's configuration with defaults for the fields that
+// aren't set. A period that isn't positive would end as soon as it starts and
+// make Run start entries in a loop, and an empty tag would send an empty tag
+// and match every entry in Restore.
+func (t *Timer) config() Config {
+	config, defaults := t.Config, DefaultConfig()
+	if config.Work <= 0 {
+		config.Work = defaults.Work
+	}
+	if config.ShortBreak <= 0 {
+		config.ShortBreak = defaults.ShortBreak
+	}
+	if config.LongBreak <= 0 {
+		config.LongBreak = defaults.LongBreak
+	}
+	if config.WorkTag == "" {
+		config.WorkTag = defaults.WorkTag
+	}
+	if config.BreakTag == "" {
+		config.BreakTag = defaults.BreakTag
+	}
+	if config.BreakDescription == "" {
+		config.BreakDescription = defaults.BreakDescription
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	return config
+}
+
+func (t *Timer) notify(finished, next Phase) {
+	if t.Notifier == nil || finished == Idle {
+		return
+	}
+
+	length := toggl.FormatDuration(t.length(next), toggl.DurationUnits)
+	message := fmt.Sprintf("%s finished, time for a %s (%s)", phaseTitles[finished], next, length)
+	if next == Work {
+		message = fmt.Sprintf("%s finished, back to %q (%s)", phaseTitles[finished], t.state.Task.Description, length)
+	}
+
+	t.Notifier.Notify(Notification{
+		Finished: finished,
+		Next:     next,
+		State:    t.state,
+		Message:  message,
+	})
+}
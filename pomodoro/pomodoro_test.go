@@ -0,0 +1,229 @@
+package pomodoro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+func TestLength(t *testing.T) {
+	timer := New(nil, Config{Work: 50 * time.Minute, ShortBreak: -time.Minute}, nil)
+	tests := []struct {
+		phase Phase
+		want  time.Duration
+	}{
+		{Work, 50 * time.Minute},
+		{ShortBreak, 5 * time.Minute},
+		{LongBreak, 15 * time.Minute},
+		{Idle, 0},
+	}
+	for _, test := range tests {
+		if got := timer.length(test.phase); got != test.want {
+			t.Errorf("length(%s) = %v, want %v", test.phase, got, test.want)
+		}
+	}
+
+	timer.Config.Work = 0
+	if got := timer.length(Work); got != DefaultConfig().Work {
+		t.Errorf("length(work) with no work length = %v, want %v", got, DefaultConfig().Work)
+	}
+}
+
+// fakeToggl serves the current timer, the list of entries and entry creation
+// and stopping from memory, in place of the Toggl API.
+type fakeToggl struct {
+	t       *testing.T
+	entries []toggl.TimeEntry
+}
+
+func newFakeToggl(t *testing.T, entries ...toggl.TimeEntry) *fakeToggl {
+	f := &fakeToggl{t: t, entries: entries}
+	saved := http.DefaultTransport
+	t.Cleanup(func() { http.DefaultTransport = saved })
+	http.DefaultTransport = f
+	toggl.DisableLog()
+	return f
+}
+
+func (f *fakeToggl) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/api/v8")
+	var data interface{}
+	switch {
+	case req.Method == "GET" && path == "/time_entries/current":
+		for i := range f.entries {
+			if f.entries[i].IsRunning() {
+				data = f.entries[i]
+			}
+		}
+		data = map[string]interface{}{"data": data}
+	case req.Method == "GET" && path == "/time_entries":
+		data = f.entries
+	case req.Method == "POST" && path == "/time_entries":
+		var sent struct {
+			TimeEntry toggl.TimeEntry `json:"time_entry"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			f.t.Fatal(err)
+		}
+		sent.TimeEntry.ID = len(f.entries) + 1
+		f.entries = append(f.entries, sent.TimeEntry)
+		data = map[string]interface{}{"data": sent.TimeEntry}
+	case req.Method == "PUT" && strings.HasSuffix(path, "/stop"):
+		for i := range f.entries {
+			if e := &f.entries[i]; fmt.Sprintf("/time_entries/%d/stop", e.ID) == path {
+				stop := time.Now()
+				e.Stop = &stop
+				e.Duration = int64(stop.Sub(e.StartTime()) / time.Second)
+				data = map[string]interface{}{"data": *e}
+			}
+		}
+	default:
+		f.t.Fatalf("unexpected %s %s", req.Method, path)
+	}
+	body, _ := json.Marshal(data)
+	return &http.Response{StatusCode: 200, Status: "200 OK", Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func newTimer(config Config, notifier Notifier) *Timer {
+	session := toggl.OpenSession("token")
+	session.SetLocation(time.UTC)
+	return New(&session, config, notifier)
+}
+
+func TestRestore(t *testing.T) {
+	now := time.Now()
+	entry := func(id int, description string, minutes int, ago time.Duration, tags ...string) toggl.TimeEntry {
+		start := now.Add(-ago)
+		e := toggl.TimeEntry{ID: id, Wid: 1, Description: description, Start: &start, Tags: tags}
+		if minutes < 0 {
+			e.Duration = -start.Unix()
+		} else {
+			stop := start.Add(time.Duration(minutes) * time.Minute)
+			e.Stop, e.Duration = &stop, int64(minutes*60)
+		}
+		return e
+	}
+	history := []toggl.TimeEntry{
+		entry(1, "Report", 25, 3*time.Hour, "pomodoro", "writing"),
+		entry(2, "Break", 5, 150*time.Minute, "break"),
+		entry(3, "Report", 25, 2*time.Hour, "pomodoro", "writing"),
+		entry(4, "Break", 5, 90*time.Minute, "break"),
+		entry(5, "Email", 10, time.Hour, "pomodoro"),
+		entry(6, "Lunch", 30, 40*time.Minute),
+	}
+
+	tests := []struct {
+		name      string
+		config    Config
+		running   toggl.TimeEntry
+		phase     Phase
+		completed int
+		task      string
+		length    time.Duration
+	}{
+		{"work", DefaultConfig(), entry(7, "Slides", -1, 10*time.Minute, "pomodoro", "talk"), Work, 2, "Slides", 25 * time.Minute},
+		{"short break", DefaultConfig(), entry(7, "Break", -1, 2*time.Minute, "break"), ShortBreak, 2, "Email", 5 * time.Minute},
+		{"long break", Config{LongBreakEvery: 2}, entry(7, "Break", -1, 2*time.Minute, "break"), LongBreak, 2, "Email", 15 * time.Minute},
+		{"other timer", DefaultConfig(), entry(7, "Meeting", -1, 2*time.Minute), Idle, 2, "Email", 0},
+		{"partial config", Config{Work: 20 * time.Minute}, entry(7, "Meeting", -1, 2*time.Minute), Idle, 2, "Email", 0},
+		{"custom tags", Config{WorkTag: "focus", BreakTag: "rest"}, entry(7, "Break", -1, 2*time.Minute, "break"), Idle, 0, "", 0},
+	}
+
+	for _, test := range tests {
+		newFakeToggl(t, append(append([]toggl.TimeEntry{}, history...), test.running)...)
+		timer := newTimer(test.config, nil)
+		state, err := timer.Restore()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if state.Phase != test.phase || state.Completed != test.completed || state.Task.Description != test.task {
+			t.Errorf("%s: restored %s with %d completed on %q, want %s with %d on %q", test.name,
+				state.Phase, state.Completed, state.Task.Description, test.phase, test.completed, test.task)
+		}
+		if test.phase == Idle {
+			if state.Entry.ID != 0 {
+				t.Errorf("%s: idle with entry %d", test.name, state.Entry.ID)
+			}
+		} else if state.Entry.ID != 7 || !state.Ends.Equal(test.running.Start.Add(test.length)) {
+			t.Errorf("%s: entry %d ends at %v, want 7 ending after %v", test.name, state.Entry.ID, state.Ends, test.length)
+		}
+		for _, tag := range state.Task.Tags {
+			if tag == "pomodoro" {
+				t.Errorf("%s: task keeps the work tag: %q", test.name, state.Task.Tags)
+			}
+		}
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	f := newFakeToggl(t)
+	var messages []string
+	notifier := NotifierFunc(func(n Notification) {
+		messages = append(messages, fmt.Sprintf("%s->%s", n.Finished, n.Next))
+	})
+	// Only some fields set, so that the others get their defaults
+	timer := newTimer(Config{Work: 50 * time.Minute, LongBreakEvery: 2}, notifier)
+
+	task := toggl.TimeEntry{Wid: 1, Pid: 3, Description: "Report", Tags: []string{"writing"}}
+	state, err := timer.StartWork(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		phase       Phase
+		description string
+		tags        []string
+		length      time.Duration
+		completed   int
+	}{
+		{Work, "Report", []string{"writing", "pomodoro"}, 50 * time.Minute, 0},
+		{ShortBreak, "Break", []string{"break"}, 5 * time.Minute, 1},
+		{Work, "Report", []string{"writing", "pomodoro"}, 50 * time.Minute, 1},
+		{LongBreak, "Break", []string{"break"}, 15 * time.Minute, 2},
+		{Work, "Report", []string{"writing", "pomodoro"}, 50 * time.Minute, 2},
+	}
+	for i, step := range steps {
+		if i > 0 {
+			if state, err = timer.Advance(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		e := state.Entry
+		if state.Phase != step.phase || state.Completed != step.completed {
+			t.Errorf("step %d: %s with %d completed, want %s with %d", i, state.Phase, state.Completed, step.phase, step.completed)
+		}
+		if e.Description != step.description || !reflect.DeepEqual(e.Tags, step.tags) || !e.IsRunning() || e.Wid != 1 {
+			t.Errorf("step %d: running %+v, want %q tagged %q", i, e, step.description, step.tags)
+		}
+		if step.phase == Work && e.Pid != 3 {
+			t.Errorf("step %d: work entry in project %d", i, e.Pid)
+		}
+		if got := state.Ends.Sub(e.StartTime()); got != step.length {
+			t.Errorf("step %d: lasts %v, want %v", i, got, step.length)
+		}
+	}
+
+	// Every entry but the last was stopped when the next one started
+	for i, e := range f.entries {
+		if e.IsRunning() != (i == len(f.entries)-1) {
+			t.Errorf("entry %d (%s) running: %v", e.ID, e.Description, e.IsRunning())
+		}
+	}
+	want := []string{"work->short break", "short break->work", "work->long break", "long break->work"}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("notified %q, want %q", messages, want)
+	}
+
+	if state, err = timer.Stop(); err != nil || state.Phase != Idle || f.entries[len(f.entries)-1].IsRunning() {
+		t.Errorf("Stop = %+v, %v", state, err)
+	}
+}
@@ -0,0 +1,561 @@
+package toggl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Kinds of operations recorded in an offline queue.
+const (
+	QueueStart  = "start"
+	QueueStop   = "stop"
+	QueueUpdate = "update"
+	QueueDelete = "delete"
+	QueueTag    = "tag"
+)
+
+// QueuedOp is a time entry mutation that was journaled because the Toggl API
+// couldn't be reached. Entries created while offline have negative, client
+// generated IDs until the queue is replayed.
+type QueuedOp struct {
+	Seq    int       `json:"seq"`
+	Kind   string    `json:"kind"`
+	Entry  TimeEntry `json:"entry"`
+	Tag    string    `json:"tag,omitempty"`
+	AddTag bool      `json:"add_tag,omitempty"`
+	Queued time.Time `json:"queued"`
+}
+
+// ReplayConflict describes a queued operation that wasn't applied because the
+// time entry it targets was changed or deleted on the server after the
+// operation was queued.
+type ReplayConflict struct {
+	Op QueuedOp
+	// Server is the current server-side version of the entry, or nil if the
+	// entry no longer exists.
+	Server *TimeEntry
+}
+
+// Error describes the conflict.
+func (c *ReplayConflict) Error() string {
+	if c.Server == nil {
+		return fmt.Sprintf("Time entry %d was deleted on the server", c.Op.Entry.ID)
+	}
+	return fmt.Sprintf("Time entry %d was changed on the server at %v", c.Op.Entry.ID, c.Server.At)
+}
+
+// ReplayFailure describes a queued operation the Toggl API rejected.
+type ReplayFailure struct {
+	Op  QueuedOp
+	Err error
+}
+
+// ReplayResult describes the outcome of replaying an offline queue.
+type ReplayResult struct {
+	Applied   []QueuedOp
+	Conflicts []ReplayConflict
+	Failed    []ReplayFailure
+	// IDs maps the temporary IDs of entries created while offline to the IDs
+	// the server assigned to them.
+	IDs map[int]int
+}
+
+// Queue is a durable, file-backed journal of time entry mutations made while
+// the Toggl API was unreachable.
+type Queue struct {
+	path      string
+	mu        sync.Mutex
+	state     *queueState
+	replaying bool
+}
+
+// queueState is the persisted form of a Queue.
+type queueState struct {
+	Ops        []QueuedOp `json:"ops"`
+	LastSeq    int        `json:"last_seq"`
+	LastTempID int        `json:"last_temp_id"`
+	// IDs maps temporary IDs to server IDs for entries that have been replayed.
+	IDs map[int]int `json:"ids,omitempty"`
+	// Applied records the server's at timestamp for entries changed during
+	// the current replay, so that later operations on the same entry aren't
+	// mistaken for conflicts.
+	Applied map[int]time.Time `json:"applied,omitempty"`
+}
+
+// NewQueue creates an offline queue stored in the file at path.
+func NewQueue(path string) *Queue {
+	return &Queue{path: path}
+}
+
+// DefaultQueuePath returns the path of the offline queue in the user's
+// configuration directory.
+func DefaultQueuePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-toggl", "queue.json"), nil
+}
+
+// IsTempID returns true if id is a temporary ID assigned to a time entry that
+// was created while offline.
+func IsTempID(id int) bool {
+	return id < 0
+}
+
+// UseQueue enables offline mode for a session. When the Toggl API can't be
+// reached, starting, creating, stopping, updating, tagging and deleting time
+// entries are journaled to the queue and reported as successful, the running
+// timer is the one the queued operations leave running, and the queue is
+// replayed before the next mutation once the API is reachable again. Passing
+// nil disables offline mode.
+func (session *Session) UseQueue(queue *Queue) {
+	session.queue = queue
+}
+
+// Len returns the number of operations waiting to be replayed.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.load().Ops)
+}
+
+// Pending returns the operations waiting to be replayed, in order.
+func (q *Queue) Pending() []QueuedOp {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ops := make([]QueuedOp, len(q.load().Ops))
+	copy(ops, q.state.Ops)
+	return ops
+}
+
+// ServerID returns the server ID of an entry created while offline, or id
+// itself if it isn't a temporary ID or the entry hasn't been replayed yet.
+func (q *Queue) ServerID(id int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.serverID(id)
+}
+
+// Replay sends the operations in the session's offline queue to the Toggl
+// API in the order they were made. Temporary IDs are rewritten to the IDs
+// assigned by the server as entries are created. Operations on entries that
+// changed on the server after they were queued are dropped and reported as
+// conflicts, and operations the API rejects are dropped and reported as
+// failures. If the API can't be reached, replay stops and the remaining
+// operations stay queued.
+func (session *Session) Replay() (result ReplayResult, err error) {
+	q := session.queue
+	result.IDs = make(map[int]int)
+	if q == nil {
+		return
+	}
+
+	if !q.beginReplay() {
+		return result, fmt.Errorf("Queue is already being replayed")
+	}
+	defer q.endReplay()
+
+	for {
+		op, expected, ok := q.next()
+		if !ok {
+			break
+		}
+
+		dlog.Printf("Replaying %s of time entry %d", op.Kind, op.Entry.ID)
+		entry, err := session.replay(op, expected)
+		switch e := err.(type) {
+		case nil:
+			result.Applied = append(result.Applied, op)
+			if op.Kind == QueueStart {
+				result.IDs[op.Entry.ID] = entry.ID
+			}
+		case *url.Error:
+			return result, err
+		case *ReplayConflict:
+			result.Conflicts = append(result.Conflicts, *e)
+		default:
+			result.Failed = append(result.Failed, ReplayFailure{Op: op, Err: err})
+		}
+
+		if err = q.done(op, entry); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// replay applies a single queued operation. expected is the at timestamp the
+// server-side entry should have if nobody else has changed it.
+func (session *Session) replay(op QueuedOp, expected *time.Time) (TimeEntry, error) {
+	entry := op.Entry
+
+	if op.Kind == QueueStart {
+		entry.ID = 0
+		return session.CreateTimeEntry(entry)
+	}
+
+	if IsTempID(entry.ID) {
+		return TimeEntry{}, fmt.Errorf("Time entry %d was never created", entry.ID)
+	}
+
+	server, err := session.GetTimeEntry(entry.ID)
+	if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == http.StatusNotFound {
+		return TimeEntry{}, &ReplayConflict{Op: op}
+	} else if err != nil {
+		return TimeEntry{}, err
+	}
+	if server.ID == 0 || server.ServerDeletedAt != nil {
+		return TimeEntry{}, &ReplayConflict{Op: op}
+	}
+	if expected != nil && server.At != nil && server.At.After(*expected) {
+		return TimeEntry{}, &ReplayConflict{Op: op, Server: &server}
+	}
+
+	switch op.Kind {
+	case QueueStop:
+		// Only the stop, so that changes queued since the entry was read
+		// are kept
+		server.Stop, server.Duration = entry.Stop, entry.Duration
+		return session.UpdateTimeEntry(server)
+	case QueueUpdate:
+		return session.UpdateTimeEntry(entry)
+	case QueueDelete:
+		_, err = session.DeleteTimeEntry(entry)
+		return TimeEntry{}, err
+	case QueueTag:
+		return session.AddRemoveTag(entry.ID, op.Tag, op.AddTag)
+	}
+
+	return TimeEntry{}, fmt.Errorf("Unknown queued operation %q", op.Kind)
+}
+
+// offline returns true if a mutation has to be journaled instead of being
+// sent to the API, either because the target entry only exists locally or
+// because earlier operations are still waiting to be replayed. If id is
+// non-nil, a temporary ID is rewritten to its server ID when one is known.
+func (session *Session) offline(id *int) bool {
+	q := session.queue
+	if q == nil || q.isReplaying() {
+		return false
+	}
+
+	if q.Len() > 0 {
+		if _, err := session.Replay(); err != nil {
+			dlog.Printf("Unable to replay offline queue: %v", err)
+		}
+	}
+
+	local := false
+	if id != nil {
+		*id = q.ServerID(*id)
+		local = IsTempID(*id)
+	}
+	return local || q.Len() > 0
+}
+
+// canQueue returns true if a failed mutation should be journaled because the
+// API couldn't be reached.
+func (session *Session) canQueue(err error) bool {
+	if session.queue == nil || session.queue.isReplaying() {
+		return false
+	}
+	_, ok := err.(*url.Error)
+	return ok
+}
+
+// start journals the creation of a time entry running since now.
+func (q *Queue) start(entry TimeEntry) TimeEntry {
+	now := time.Now().Truncate(time.Second)
+	entry.Start = &now
+	entry.Stop = nil
+	entry.Duration = -now.Unix()
+	return q.create(entry)
+}
+
+// create journals the creation of a time entry, giving it a temporary ID.
+func (q *Queue) create(entry TimeEntry) TimeEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state := q.load()
+	state.LastTempID--
+	entry.ID = state.LastTempID
+	q.push(QueuedOp{Kind: QueueStart, Entry: entry})
+	return entry
+}
+
+// stop journals stopping a running time entry.
+func (q *Queue) stop(entry TimeEntry) TimeEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if entry.Start == nil {
+		entry.Start = q.startOf(entry.ID)
+	}
+	now := time.Now().Truncate(time.Second)
+	entry.Stop = &now
+	if entry.Start != nil {
+		entry.Duration = now.Unix() - entry.Start.Unix()
+	}
+	q.push(QueuedOp{Kind: QueueStop, Entry: entry})
+	return entry
+}
+
+// update journals a change to a time entry.
+func (q *Queue) update(entry TimeEntry) TimeEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.push(QueuedOp{Kind: QueueUpdate, Entry: entry})
+	return entry
+}
+
+// remove journals the deletion of a time entry.
+func (q *Queue) remove(entry TimeEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.push(QueuedOp{Kind: QueueDelete, Entry: entry})
+}
+
+// tag journals adding or removing a tag. at is the entry's at timestamp, if
+// known, so that changes made on the server meanwhile are detected.
+func (q *Queue) tag(entryID int, at *time.Time, tag string, add bool) TimeEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry := TimeEntry{ID: entryID, At: at}
+	if add {
+		entry.Tags = []string{tag}
+	}
+	q.push(QueuedOp{Kind: QueueTag, Entry: entry, Tag: tag, AddTag: add})
+	return entry
+}
+
+// current returns the timer the queued operations leave running, given the
+// timer that was running before them, or a zero entry if none is.
+func (q *Queue) current(running TimeEntry) TimeEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, op := range q.load().Ops {
+		same := running.ID != 0 && q.serverID(op.Entry.ID) == q.serverID(running.ID)
+		switch {
+		case op.Kind == QueueStart && op.Entry.IsRunning():
+			// Starting a timer stops the running one
+			running = op.Entry
+		case op.Kind == QueueUpdate && same:
+			running = op.Entry
+		case (op.Kind == QueueStop || op.Kind == QueueDelete) && same:
+			running = TimeEntry{}
+		case op.Kind == QueueTag && same:
+			running = running.Copy()
+			if op.AddTag {
+				running.AddTag(op.Tag)
+			} else {
+				running.RemoveTag(op.Tag)
+			}
+		}
+	}
+	if !running.IsRunning() {
+		return TimeEntry{}
+	}
+	return running
+}
+
+// at returns the at timestamp of the latest queued operation on an entry
+// that has one, or nil.
+func (q *Queue) at(id int) (at *time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, op := range q.load().Ops {
+		if op.Entry.ID == id && op.Entry.At != nil {
+			at = op.Entry.At
+		}
+	}
+	return at
+}
+
+// offlineCurrent returns the running timer while the API can't be reached:
+// the running timer in the cached account data, as changed by the queued
+// operations.
+func (session *Session) offlineCurrent() TimeEntry {
+	var running TimeEntry
+	if session.cache != nil {
+		if account, ok := session.cache.Cached(); ok {
+			for _, e := range account.Data.TimeEntries {
+				if e.IsRunning() {
+					running = e
+				}
+			}
+		}
+	}
+	return session.queue.current(running)
+}
+
+// entryAt returns the at timestamp of a time entry as last seen by the
+// session, from the queued operations or the cached account data, or nil.
+func (session *Session) entryAt(id int) *time.Time {
+	if at := session.queue.at(id); at != nil {
+		return at
+	}
+	if session.cache != nil {
+		if account, ok := session.cache.Cached(); ok {
+			if e, ok := findTimeEntry(account.Data.TimeEntries, id); ok {
+				return e.At
+			}
+		}
+	}
+	return nil
+}
+
+// push appends an operation to the queue and persists it. The caller must
+// hold q.mu.
+func (q *Queue) push(op QueuedOp) {
+	state := q.load()
+	state.LastSeq++
+	op.Seq = state.LastSeq
+	op.Queued = time.Now()
+	state.Ops = append(state.Ops, op)
+	dlog.Printf("Queued %s of time entry %d", op.Kind, op.Entry.ID)
+
+	if err := q.save(); err != nil {
+		dlog.Printf("Error saving offline queue: %v", err)
+	}
+}
+
+// startOf returns the start time of a time entry created while offline. The
+// caller must hold q.mu.
+func (q *Queue) startOf(id int) *time.Time {
+	for _, op := range q.load().Ops {
+		if op.Kind == QueueStart && op.Entry.ID == id {
+			return op.Entry.Start
+		}
+	}
+	return nil
+}
+
+// next returns the first queued operation with temporary IDs rewritten, and
+// the at timestamp its target entry is expected to have on the server.
+func (q *Queue) next() (op QueuedOp, expected *time.Time, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state := q.load()
+	if len(state.Ops) == 0 {
+		return op, nil, false
+	}
+
+	op = state.Ops[0]
+	if op.Kind != QueueStart {
+		op.Entry.ID = q.serverID(op.Entry.ID)
+	}
+
+	expected = op.Entry.At
+	if at, ok := state.Applied[op.Entry.ID]; ok && (expected == nil || at.After(*expected)) {
+		expected = &at
+	}
+	return op, expected, true
+}
+
+// done removes the first operation from the queue after it has been replayed,
+// recording the server's version of the entry it produced.
+func (q *Queue) done(op QueuedOp, entry TimeEntry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state := q.load()
+	state.Ops = state.Ops[1:]
+
+	if op.Kind == QueueStart && entry.ID != 0 {
+		if state.IDs == nil {
+			state.IDs = make(map[int]int)
+		}
+		state.IDs[op.Entry.ID] = entry.ID
+	}
+	if entry.ID != 0 && entry.At != nil {
+		if state.Applied == nil {
+			state.Applied = make(map[int]time.Time)
+		}
+		state.Applied[entry.ID] = *entry.At
+	}
+	if len(state.Ops) == 0 {
+		state.Applied = nil
+	}
+
+	return q.save()
+}
+
+func (q *Queue) beginReplay() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.replaying {
+		return false
+	}
+	q.replaying = true
+	return true
+}
+
+func (q *Queue) endReplay() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.replaying = false
+}
+
+func (q *Queue) isReplaying() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.replaying
+}
+
+// serverID maps a temporary ID to a server ID. The caller must hold q.mu.
+func (q *Queue) serverID(id int) int {
+	if serverID, ok := q.load().IDs[id]; ok {
+		return serverID
+	}
+	return id
+}
+
+// load returns the queue state, reading it from disk the first time it's
+// needed. The caller must hold q.mu.
+func (q *Queue) load() *queueState {
+	if q.state != nil {
+		return q.state
+	}
+
+	q.state = &queueState{}
+	data, err := ioutil.ReadFile(q.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			dlog.Printf("Error reading offline queue: %v", err)
+		}
+		return q.state
+	}
+
+	if err = json.Unmarshal(data, q.state); err != nil {
+		// Keep the unreadable journal around rather than overwriting it
+		dlog.Printf("Error decoding offline queue: %v", err)
+		if err = os.Rename(q.path, q.path+".corrupt"); err != nil {
+			dlog.Printf("Error moving offline queue aside: %v", err)
+		}
+		q.state = &queueState{}
+	}
+	return q.state
+}
+
+// save writes the queue state to disk. The caller must hold q.mu.
+func (q *Queue) save() error {
+	data, err := json.Marshal(q.state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(q.path, data)
+}
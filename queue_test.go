@@ -0,0 +1,385 @@
+package toggl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeToggl serves the time entry endpoints of the Toggl API from memory, or
+// fails every request as unreachable while offline.
+type fakeToggl struct {
+	t        *testing.T
+	offline  bool
+	entries  map[int]*TimeEntry
+	lastID   int
+	clock    time.Time
+	requests []string
+}
+
+func newFakeToggl(t *testing.T) *fakeToggl {
+	f := &fakeToggl{t: t, entries: make(map[int]*TimeEntry), lastID: 100, clock: time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)}
+	saved := client
+	t.Cleanup(func() { client = saved })
+	client = &http.Client{Transport: f}
+	DisableLog()
+	return f
+}
+
+// add stores an entry changed on the server at the given time.
+func (f *fakeToggl) add(entry TimeEntry, at time.Time) {
+	entry.At = &at
+	f.entries[entry.ID] = &entry
+}
+
+// touch changes an entry on the server, as another client would.
+func (f *fakeToggl) touch(id int) {
+	at := f.tick()
+	f.entries[id].At = &at
+}
+
+func (f *fakeToggl) tick() time.Time {
+	f.clock = f.clock.Add(time.Minute)
+	return f.clock
+}
+
+func (f *fakeToggl) RoundTrip(req *http.Request) (*http.Response, error) {
+	if f.offline {
+		return nil, errors.New("network is unreachable")
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/api/v8")
+	f.requests = append(f.requests, req.Method+" "+path)
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+	}
+	var sent struct {
+		TimeEntry TimeEntry `json:"time_entry"`
+	}
+	var action struct {
+		TimeEntry struct {
+			Tags      []string `json:"tags"`
+			TagAction string   `json:"tag_action"`
+		} `json:"time_entry"`
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &sent); err != nil {
+			f.t.Fatalf("%s %s: %v", req.Method, path, err)
+		}
+		json.Unmarshal(body, &action)
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var entry *TimeEntry
+	if path == "/time_entries/current" {
+		for _, e := range f.entries {
+			if e.IsRunning() {
+				entry = e
+			}
+		}
+		return f.respond(http.StatusOK, entry), nil
+	}
+	if len(parts) > 1 {
+		id, err := strconv.Atoi(parts[1])
+		if err != nil {
+			f.t.Fatalf("%s %s", req.Method, path)
+		}
+		if entry = f.entries[id]; entry == nil {
+			return f.respond(http.StatusNotFound, nil), nil
+		}
+	}
+
+	switch {
+	case req.Method == "POST" && len(parts) == 1:
+		created := sent.TimeEntry
+		f.lastID++
+		created.ID = f.lastID
+		f.add(created, f.tick())
+		entry = f.entries[created.ID]
+	case req.Method == "POST" && action.TimeEntry.TagAction != "":
+		for _, tag := range action.TimeEntry.Tags {
+			if action.TimeEntry.TagAction == "add" {
+				entry.AddTag(tag)
+			} else {
+				entry.RemoveTag(tag)
+			}
+		}
+		f.touch(entry.ID)
+	case req.Method == "POST":
+		updated := sent.TimeEntry
+		f.add(updated, f.tick())
+		entry = f.entries[updated.ID]
+	case req.Method == "PUT" && len(parts) == 3 && parts[2] == "stop":
+		stop := f.clock
+		entry.Stop = &stop
+		entry.Duration = stop.Unix() + entry.Duration
+		f.touch(entry.ID)
+	case req.Method == "DELETE":
+		delete(f.entries, entry.ID)
+		return f.respond(http.StatusOK, nil), nil
+	case req.Method != "GET":
+		f.t.Fatalf("unexpected %s %s", req.Method, path)
+	}
+	return f.respond(http.StatusOK, entry), nil
+}
+
+func (f *fakeToggl) respond(status int, entry *TimeEntry) *http.Response {
+	body, _ := json.Marshal(map[string]interface{}{"data": entry})
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: ioutil.NopCloser(bytes.NewReader(body))}
+}
+
+func offlineSession(t *testing.T) (*Session, *Queue) {
+	session := OpenSession("token")
+	queue := NewQueue(filepath.Join(t.TempDir(), "queue.json"))
+	session.UseQueue(queue)
+	return &session, queue
+}
+
+func queuedKinds(ops []QueuedOp) (kinds []string) {
+	for _, op := range ops {
+		kinds = append(kinds, fmt.Sprintf("%s %d", op.Kind, op.Entry.ID))
+	}
+	return kinds
+}
+
+func TestQueueReplay(t *testing.T) {
+	f := newFakeToggl(t)
+	session, queue := offlineSession(t)
+	f.offline = true
+
+	started, err := session.StartTimeEntry("Writing")
+	if err != nil || started.ID != -1 || !started.IsRunning() {
+		t.Fatalf("StartTimeEntry offline = %+v, %v", started, err)
+	}
+	if _, err := session.AddRemoveTag(started.ID, "draft", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.StopTimeEntry(started); err != nil {
+		t.Fatal(err)
+	}
+	start := f.clock.Add(-2 * time.Hour)
+	stop := start.Add(time.Hour)
+	created, err := session.CreateTimeEntry(TimeEntry{Description: "Call", Start: &start, Stop: &stop, Duration: 3600})
+	if err != nil || created.ID != -2 {
+		t.Fatalf("CreateTimeEntry offline = %+v, %v", created, err)
+	}
+	created.Description = "Client call"
+	if _, err := session.UpdateTimeEntry(created); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"start -1", "tag -1", "stop -1", "start -2", "update -2"}
+	if got := queuedKinds(queue.Pending()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("queued %q, want %q", got, want)
+	}
+	if len(f.requests) != 0 {
+		t.Errorf("requests sent while offline: %q", f.requests)
+	}
+
+	// Replaying again from disk sees the same journal
+	reloaded := NewQueue(queue.path)
+	if got := queuedKinds(reloaded.Pending()); !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded queue has %q, want %q", got, want)
+	}
+
+	f.offline = false
+	result, err := session.Replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := []string{"start -1", "tag 101", "stop 101", "start -2", "update 102"}
+	if got := queuedKinds(result.Applied); !reflect.DeepEqual(got, applied) {
+		t.Errorf("applied %q, want %q", got, applied)
+	}
+	if len(result.Conflicts) != 0 || len(result.Failed) != 0 {
+		t.Errorf("replay had conflicts %+v and failures %+v", result.Conflicts, result.Failed)
+	}
+	if ids := map[int]int{-1: 101, -2: 102}; !reflect.DeepEqual(result.IDs, ids) {
+		t.Errorf("replay mapped IDs %v, want %v", result.IDs, ids)
+	}
+	wantRequests := []string{
+		"POST /time_entries",
+		"GET /time_entries/101", "POST /time_entries/101",
+		"GET /time_entries/101", "POST /time_entries/101",
+		"POST /time_entries",
+		"GET /time_entries/102", "POST /time_entries/102",
+	}
+	if !reflect.DeepEqual(f.requests, wantRequests) {
+		t.Errorf("replay sent %q, want %q", f.requests, wantRequests)
+	}
+
+	if e := f.entries[101]; e.Description != "Writing" || !e.HasTag("draft") || e.IsRunning() {
+		t.Errorf("entry started offline is %+v on the server", e)
+	}
+	if e := f.entries[102]; e.Description != "Client call" || !e.Start.Equal(start) || e.Duration != 3600 {
+		t.Errorf("entry created offline is %+v on the server", e)
+	}
+	if queue.Len() != 0 || queue.ServerID(-1) != 101 || queue.ServerID(-2) != 102 {
+		t.Errorf("queue has %d operations left and maps -1 to %d, -2 to %d", queue.Len(), queue.ServerID(-1), queue.ServerID(-2))
+	}
+
+	// Later operations on offline entries use their server IDs
+	f.requests = nil
+	if _, err := session.AddRemoveTag(-1, "final", true); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POST /time_entries/101"}; !reflect.DeepEqual(f.requests, want) {
+		t.Errorf("tagging a replayed entry sent %q, want %q", f.requests, want)
+	}
+}
+
+func TestQueueConflicts(t *testing.T) {
+	edited := TimeEntry{ID: 5, Description: "Edited", Duration: 60}
+	tests := []struct {
+		name string
+		// queue queues operations on entry 5 while offline
+		queue func(session *Session)
+		// server changes entry 5 before the queue is replayed
+		server    func(f *fakeToggl)
+		applied   []string
+		conflicts []string
+		deleted   bool
+	}{
+		{
+			name:    "unchanged",
+			queue:   func(s *Session) { s.UpdateTimeEntry(edited) },
+			server:  func(f *fakeToggl) {},
+			applied: []string{"update 5"},
+		},
+		{
+			name:      "changed",
+			queue:     func(s *Session) { s.UpdateTimeEntry(edited) },
+			server:    func(f *fakeToggl) { f.touch(5) },
+			conflicts: []string{"update 5"},
+		},
+		{
+			name:      "deleted",
+			queue:     func(s *Session) { s.DeleteTimeEntry(edited) },
+			server:    func(f *fakeToggl) { delete(f.entries, 5) },
+			conflicts: []string{"delete 5"},
+			deleted:   true,
+		},
+		{
+			name:      "tag changed",
+			queue:     func(s *Session) { s.AddRemoveTag(5, "bug", true) },
+			server:    func(f *fakeToggl) { f.touch(5) },
+			conflicts: []string{"tag 5"},
+		},
+		{
+			name: "own changes",
+			queue: func(s *Session) {
+				s.UpdateTimeEntry(edited)
+				s.AddRemoveTag(5, "bug", true)
+				s.StopTimeEntry(edited)
+			},
+			server:  func(f *fakeToggl) {},
+			applied: []string{"update 5", "tag 5", "stop 5"},
+		},
+		{
+			name: "changed between",
+			queue: func(s *Session) {
+				s.AddRemoveTag(5, "bug", true)
+				s.DeleteTimeEntry(edited)
+			},
+			server:    func(f *fakeToggl) { f.touch(5) },
+			conflicts: []string{"tag 5", "delete 5"},
+		},
+	}
+
+	for _, test := range tests {
+		f := newFakeToggl(t)
+		at := f.tick()
+		f.add(TimeEntry{ID: 5, Description: "Original", Duration: 60}, at)
+
+		// The cache knows the entry as the server had it before going offline
+		session, _ := offlineSession(t)
+		cache := NewCache(filepath.Join(t.TempDir(), "account.json"), time.Hour)
+		cache.state = &cacheState{Updated: time.Now()}
+		cache.state.Account.Data.ID = 1
+		cache.state.Account.Data.TimeEntries = []TimeEntry{*f.entries[5]}
+		session.UseCache(cache)
+		edited.At = &at
+
+		f.offline = true
+		test.queue(session)
+		f.offline = false
+		test.server(f)
+
+		result, err := session.Replay()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := queuedKinds(result.Applied); !reflect.DeepEqual(got, test.applied) {
+			t.Errorf("%s: applied %q, want %q", test.name, got, test.applied)
+		}
+		var conflicts []string
+		for _, c := range result.Conflicts {
+			conflicts = append(conflicts, fmt.Sprintf("%s %d", c.Op.Kind, c.Op.Entry.ID))
+			if (c.Server == nil) != test.deleted {
+				t.Errorf("%s: conflict %v has server entry %v", test.name, c.Error(), c.Server)
+			}
+		}
+		if !reflect.DeepEqual(conflicts, test.conflicts) {
+			t.Errorf("%s: conflicts %q, want %q", test.name, conflicts, test.conflicts)
+		}
+		if len(result.Failed) != 0 {
+			t.Errorf("%s: failures %+v", test.name, result.Failed)
+		}
+	}
+}
+
+func TestTrackerOffline(t *testing.T) {
+	f := newFakeToggl(t)
+	session, queue := offlineSession(t)
+	tracker := NewTracker(session)
+	f.offline = true
+
+	started, err := tracker.Start(TimeEntry{Description: "Writing", Tags: []string{"draft"}})
+	if err != nil {
+		t.Fatalf("Start offline: %v", err)
+	}
+	if current, err := tracker.Current(); err != nil || current == nil || current.ID != started.ID {
+		t.Errorf("Current offline = %+v, %v, want the started timer %d", current, err, started.ID)
+	}
+	if _, err := tracker.Start(TimeEntry{Description: "Other"}); err == nil {
+		t.Errorf("Start with a timer running offline succeeded")
+	}
+
+	switched, err := tracker.Switch(TimeEntry{Description: "Review"})
+	if err != nil {
+		t.Fatalf("Switch offline: %v", err)
+	}
+	if current, _ := tracker.Current(); current == nil || current.ID != switched.ID || current.Description != "Review" {
+		t.Errorf("Current after Switch offline = %+v, want %+v", current, switched)
+	}
+	want := []string{fmt.Sprintf("start %d", started.ID), fmt.Sprintf("stop %d", started.ID), fmt.Sprintf("start %d", switched.ID)}
+	if got := queuedKinds(queue.Pending()); !reflect.DeepEqual(got, want) {
+		t.Errorf("queued %q, want %q", got, want)
+	}
+
+	if _, err := tracker.Stop(); err != nil {
+		t.Fatalf("Stop offline: %v", err)
+	}
+	if current, err := tracker.Current(); err != nil || current != nil {
+		t.Errorf("Current after Stop offline = %+v, %v", current, err)
+	}
+
+	f.offline = false
+	if current, err := tracker.Current(); err != nil || current != nil {
+		t.Errorf("Current once online = %+v, %v", current, err)
+	}
+	if queue.Len() != 0 || len(f.entries) != 2 {
+		t.Errorf("%d operations left and %d entries on the server after going online", queue.Len(), len(f.entries))
+	}
+}
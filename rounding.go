@@ -0,0 +1,107 @@
+package toggl
+
+import (
+	"time"
+)
+
+// RoundingMode is the direction durations are rounded in. The values match
+// the rounding setting of a Toggl workspace.
+type RoundingMode int
+
+// Rounding modes.
+const (
+	RoundDown    RoundingMode = -1
+	RoundNearest RoundingMode = 0
+	RoundUp      RoundingMode = 1
+)
+
+// Rounding describes how tracked time is rounded, matching the rounding rules
+// Toggl applies to reports when rounding is enabled.
+type Rounding struct {
+	Mode RoundingMode
+	// Minutes is the interval durations are rounded to. Zero disables
+	// rounding.
+	Minutes int
+	// PerEntry rounds every entry's duration before adding them up, as Toggl
+	// does. Otherwise only totals are rounded.
+	PerEntry bool
+}
+
+// RoundingRule returns the rounding rule configured for a workspace.
+func (w *Workspace) RoundingRule() Rounding {
+	return Rounding{
+		Mode:     RoundingMode(w.Rounding),
+		Minutes:  w.RoundingMinutes,
+		PerEntry: true,
+	}
+}
+
+// Enabled returns true if the rule changes durations at all.
+func (r Rounding) Enabled() bool {
+	return r.Minutes > 0
+}
+
+// Round rounds a single duration. Negative durations, such as those of
+// running entries, are returned unchanged.
+func (r Rounding) Round(d time.Duration) time.Duration {
+	if !r.Enabled() || d < 0 {
+		return d
+	}
+
+	unit := time.Duration(r.Minutes) * time.Minute
+	rounded := d - d%unit
+	if rem := d % unit; rem != 0 {
+		switch {
+		case r.Mode > RoundNearest:
+			rounded += unit
+		case r.Mode == RoundNearest && rem*2 >= unit:
+			rounded += unit
+		}
+	}
+	return rounded
+}
+
+// RoundSeconds rounds a duration given in seconds, the unit used by
+// TimeEntry.Duration.
+func (r Rounding) RoundSeconds(seconds int64) int64 {
+	return int64(r.Round(time.Duration(seconds)*time.Second) / time.Second)
+}
+
+// Total adds up a set of durations, rounding every one of them or only the
+// sum depending on the rule.
+func (r Rounding) Total(durations []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range durations {
+		if r.PerEntry {
+			d = r.Round(d)
+		}
+		total += d
+	}
+	if !r.PerEntry {
+		total = r.Round(total)
+	}
+	return total
+}
+
+// Accumulator adds durations up one at a time according to a rounding rule,
+// for aggregations that can't collect every duration first.
+type Accumulator struct {
+	Rule  Rounding
+	total time.Duration
+}
+
+// Add adds a duration to the accumulated total.
+func (a *Accumulator) Add(d time.Duration) {
+	if a.Rule.PerEntry {
+		d = a.Rule.Round(d)
+	}
+	a.total += d
+}
+
+// Total returns the accumulated total, rounded according to the rule.
+func (a *Accumulator) Total() time.Duration {
+	if a.Rule.PerEntry {
+		return a.total
+	}
+	return a.Rule.Round(a.total)
+}
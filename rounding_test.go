@@ -0,0 +1,75 @@
+package toggl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRound(t *testing.T) {
+	m := time.Minute
+	tests := []struct {
+		mode     RoundingMode
+		minutes  int
+		in, want time.Duration
+	}{
+		// Exact multiples stay as they are in every mode
+		{RoundDown, 15, 30 * m, 30 * m},
+		{RoundNearest, 15, 30 * m, 30 * m},
+		{RoundUp, 15, 30 * m, 30 * m},
+		{RoundDown, 15, 0, 0},
+		{RoundNearest, 15, 0, 0},
+		{RoundUp, 15, 0, 0},
+
+		{RoundDown, 15, 44*m + 59*time.Second, 30 * m},
+		{RoundNearest, 15, 37*m + 29*time.Second, 30 * m},
+		// Halfway rounds up
+		{RoundNearest, 15, 37*m + 30*time.Second, 45 * m},
+		{RoundUp, 15, 30*m + time.Second, 45 * m},
+		{RoundUp, 1, 59 * time.Second, m},
+		{RoundNearest, 60, 90 * m, 120 * m},
+
+		// Running entries and disabled rounding are left alone
+		{RoundUp, 15, -5 * m, -5 * m},
+		{RoundUp, 0, 7 * m, 7 * m},
+	}
+	for _, test := range tests {
+		r := Rounding{Mode: test.mode, Minutes: test.minutes}
+		if got := r.Round(test.in); got != test.want {
+			t.Errorf("Round(%v) to %d minutes in mode %d = %v, want %v", test.in, test.minutes, test.mode, got, test.want)
+		}
+	}
+
+	if got := (Rounding{Mode: RoundUp, Minutes: 6}).RoundSeconds(361); got != 720 {
+		t.Errorf("RoundSeconds(361) = %d, want 720", got)
+	}
+	w := Workspace{Rounding: -1, RoundingMinutes: 15}
+	if r := w.RoundingRule(); r.Mode != RoundDown || r.Minutes != 15 || !r.PerEntry {
+		t.Errorf("RoundingRule = %+v", r)
+	}
+}
+
+func TestRoundingTotal(t *testing.T) {
+	durations := []time.Duration{10 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	tests := []struct {
+		rule Rounding
+		want time.Duration
+	}{
+		{Rounding{Mode: RoundUp, Minutes: 15, PerEntry: true}, 45 * time.Minute},
+		{Rounding{Mode: RoundUp, Minutes: 15}, 30 * time.Minute},
+		{Rounding{Mode: RoundDown, Minutes: 15, PerEntry: true}, 0},
+		{Rounding{Mode: RoundDown, Minutes: 15}, 30 * time.Minute},
+		{Rounding{}, 30 * time.Minute},
+	}
+	for _, test := range tests {
+		if got := test.rule.Total(durations); got != test.want {
+			t.Errorf("%+v: Total = %v, want %v", test.rule, got, test.want)
+		}
+		a := Accumulator{Rule: test.rule}
+		for _, d := range durations {
+			a.Add(d)
+		}
+		if got := a.Total(); got != test.want {
+			t.Errorf("%+v: Accumulator total = %v, want %v", test.rule, got, test.want)
+		}
+	}
+}
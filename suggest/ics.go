@@ -0,0 +1,349 @@
+package suggest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is an event of an iCalendar file, or an occurrence of a recurring
+// event.
+type Event struct {
+	UID         string    `json:"uid,omitempty"`
+	Summary     string    `json:"summary"`
+	Description string    `json:"description,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	// AllDay is true for events that last whole days, whose times are
+	// midnight in the calendar's time zone.
+	AllDay    bool     `json:"all_day,omitempty"`
+	Organizer Person   `json:"organizer"`
+	Attendees []Person `json:"attendees,omitempty"`
+	// Status is CONFIRMED, TENTATIVE or CANCELLED, if given.
+	Status string `json:"status,omitempty"`
+	// Transparent is true for events that don't make their time busy.
+	Transparent bool `json:"transparent,omitempty"`
+
+	rule         *recurrence
+	exdates      []time.Time
+	recurrenceID time.Time
+}
+
+// Duration returns how long the event lasts.
+func (e Event) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+// Person is the organizer or an attendee of an event.
+type Person struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	// Status is the attendee's PARTSTAT, such as ACCEPTED or DECLINED.
+	Status string `json:"status,omitempty"`
+}
+
+// Domain returns the domain of the person's email address.
+func (p Person) Domain() string {
+	if i := strings.LastIndex(p.Email, "@"); i != -1 {
+		return strings.ToLower(p.Email[i+1:])
+	}
+	return ""
+}
+
+// Calendar is the contents of an iCalendar file.
+type Calendar struct {
+	Events []Event
+	// Warnings describe what couldn't be read, such as unknown time zones
+	// or recurrence rules. The events affected are read as well as
+	// possible.
+	Warnings []string
+}
+
+// property is a content line of an iCalendar file.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// ParseICS reads an iCalendar (RFC 5545) file. Times without a time zone,
+// and those in time zones unknown to the time package, are read in loc,
+// which defaults to the local time zone.
+func ParseICS(r io.Reader, loc *time.Location) (*Calendar, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unfold lines continued on lines starting with white space
+	text := strings.Replace(string(data), "\r\n", "\n", -1)
+	text = strings.Replace(text, "\n ", "", -1)
+	text = strings.Replace(text, "\n\t", "", -1)
+
+	p := &parser{loc: loc, cal: &Calendar{}}
+	var stack []string
+	var event *Event
+	for n, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prop, ok := parseProperty(line)
+		if !ok {
+			return nil, fmt.Errorf("Line %d: invalid iCalendar content line", n+1)
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(prop.value))
+			if len(stack) == 2 && stack[0] == "VCALENDAR" && stack[1] == "VEVENT" {
+				event = &Event{}
+			}
+			continue
+		case "END":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("Line %d: END without BEGIN", n+1)
+			}
+			if len(stack) == 2 && event != nil {
+				p.finish(event)
+				event = nil
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		// Only the event's own properties, not those of its alarms
+		if event != nil && len(stack) == 2 {
+			p.set(event, prop)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("Unterminated %s", stack[len(stack)-1])
+	}
+	return p.cal, nil
+}
+
+// parseProperty parses a content line of the form NAME;PARAM=VALUE:VALUE.
+func parseProperty(line string) (property, bool) {
+	prop := property{params: make(map[string]string)}
+
+	// The value starts at the first colon outside of quotes
+	quoted, colon := false, -1
+	for i := 0; i < len(line) && colon == -1; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon == -1 {
+		return prop, false
+	}
+	prop.value = line[colon+1:]
+
+	parts := splitQuoted(line[:colon], ';')
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		if i := strings.Index(param, "="); i != -1 {
+			prop.params[strings.ToUpper(param[:i])] = strings.Trim(param[i+1:], `"`)
+		}
+	}
+	return prop, prop.name != ""
+}
+
+// splitQuoted splits a string at separators outside of quotes.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";")
+
+// parser holds the state of ParseICS.
+type parser struct {
+	loc      *time.Location
+	cal      *Calendar
+	duration time.Duration
+	hasEnd   bool
+}
+
+func (p *parser) warn(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	for _, w := range p.cal.Warnings {
+		if w == warning {
+			return
+		}
+	}
+	p.cal.Warnings = append(p.cal.Warnings, warning)
+}
+
+// set sets a property of an event.
+func (p *parser) set(e *Event, prop property) {
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SUMMARY":
+		e.Summary = textUnescaper.Replace(prop.value)
+	case "DESCRIPTION":
+		e.Description = textUnescaper.Replace(prop.value)
+	case "LOCATION":
+		e.Location = textUnescaper.Replace(prop.value)
+	case "STATUS":
+		e.Status = strings.ToUpper(prop.value)
+	case "TRANSP":
+		e.Transparent = strings.EqualFold(prop.value, "TRANSPARENT")
+	case "DTSTART":
+		e.Start, e.AllDay = p.parseTime(prop, prop.value)
+	case "DTEND":
+		e.End, _ = p.parseTime(prop, prop.value)
+		p.hasEnd = true
+	case "DURATION":
+		d, err := parseDuration(prop.value)
+		if err != nil {
+			p.warn("Event %q: %v", e.Summary, err)
+		}
+		p.duration = d
+	case "ORGANIZER":
+		e.Organizer = person(prop)
+	case "ATTENDEE":
+		e.Attendees = append(e.Attendees, person(prop))
+	case "RRULE":
+		rule, err := parseRecurrence(prop.value)
+		if err != nil {
+			p.warn("Event %q: %v; only its first occurrence is used", e.Summary, err)
+			return
+		}
+		e.rule = rule
+	case "EXDATE":
+		for _, v := range strings.Split(prop.value, ",") {
+			t, _ := p.parseTime(prop, v)
+			e.exdates = append(e.exdates, t)
+		}
+	case "RECURRENCE-ID":
+		e.recurrenceID, _ = p.parseTime(prop, prop.value)
+	}
+}
+
+// finish completes an event once all its properties are read.
+func (p *parser) finish(e *Event) {
+	switch {
+	case p.hasEnd:
+	case p.duration != 0:
+		e.End = e.Start.Add(p.duration)
+	case e.AllDay:
+		e.End = e.Start.AddDate(0, 0, 1)
+	default:
+		e.End = e.Start
+	}
+	p.duration, p.hasEnd = 0, false
+
+	if e.Start.IsZero() {
+		p.warn("Event %q has no start time and is skipped", e.Summary)
+		return
+	}
+	p.cal.Events = append(p.cal.Events, *e)
+}
+
+// parseTime parses a DATE or DATE-TIME value, returning true for dates.
+func (p *parser) parseTime(prop property, value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	loc := p.loc
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		} else {
+			p.warn("Unknown time zone %q; its times are read in %s", tzid, p.loc)
+		}
+	}
+
+	if prop.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			p.warn("Invalid date %q", value)
+		}
+		return t, true
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			p.warn("Invalid time %q", value)
+		}
+		return t, false
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		p.warn("Invalid time %q", value)
+	}
+	return t, false
+}
+
+// person reads an ORGANIZER or ATTENDEE.
+func person(prop property) Person {
+	email := prop.value
+	if strings.HasPrefix(strings.ToLower(email), "mailto:") {
+		email = email[len("mailto:"):]
+	}
+	return Person{
+		Name:   prop.params["CN"],
+		Email:  strings.TrimSpace(email),
+		Status: strings.ToUpper(prop.params["PARTSTAT"]),
+	}
+}
+
+// parseDuration parses a DURATION value such as PT1H30M or P1D.
+func parseDuration(s string) (time.Duration, error) {
+	input := strings.ToUpper(strings.TrimSpace(s))
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(input, "-"):
+		sign, input = -1, input[1:]
+	case strings.HasPrefix(input, "+"):
+		input = input[1:]
+	}
+	if !strings.HasPrefix(input, "P") || len(input) < 3 {
+		return 0, fmt.Errorf("Invalid duration %q", s)
+	}
+
+	var d time.Duration
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	number := ""
+	for i := 1; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T':
+			units = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		default:
+			unit, ok := units[c]
+			n, err := strconv.Atoi(number)
+			if !ok || err != nil {
+				return 0, fmt.Errorf("Invalid duration %q", s)
+			}
+			d += time.Duration(n) * unit
+			number = ""
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("Invalid duration %q", s)
+	}
+	return sign * d, nil
+}
@@ -0,0 +1,233 @@
+package suggest
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readCalendar(t *testing.T) *Calendar {
+	file, err := os.Open("testdata/calendar.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	cal, err := ParseICS(file, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestOccurrences(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skip(err)
+	}
+	cal := readCalendar(t)
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     []string
+	}{
+		{
+			name: "all",
+			from: "2026-07-01T00:00:00Z", to: "2026-11-01T00:00:00Z",
+			want: []string{
+				"2026-07-31T17:00:00Z 1h0m0s Month end",
+				"2026-08-28T15:00:00Z 1h0m0s Retro",
+				"2026-08-31T17:00:00Z 1h0m0s Month end",
+				"2026-09-01T10:00:00Z 30m0s One on one",
+				"2026-09-15T10:00:00Z 30m0s One on one",
+				"2026-09-25T15:00:00Z 1h0m0s Retro",
+				"2026-09-29T10:00:00Z 30m0s One on one",
+				"2026-10-05T07:30:00Z 15m0s Standup",
+				"2026-10-12T09:00:00Z 15m0s Standup (moved)",
+				"2026-10-14T07:30:00Z 15m0s Standup",
+				"2026-10-15T13:00:00Z 2h0m0s Quarterly planning, budget review",
+				"2026-10-16T00:00:00Z 24h0m0s Offsite",
+				"2026-10-20T12:00:00Z 1h0m0s Unknown zone",
+				"2026-10-21T07:30:00Z 15m0s Standup",
+				"2026-10-24T07:00:00Z 30m0s Daily check",
+				"2026-10-25T08:00:00Z 30m0s Daily check",
+				"2026-10-26T08:00:00Z 30m0s Daily check",
+				"2026-10-30T15:00:00Z 1h0m0s Retro",
+				"2026-10-31T17:00:00Z 1h0m0s Month end",
+			},
+		},
+		{
+			name: "moved occurrence at its original time",
+			from: "2026-10-12T07:00:00Z", to: "2026-10-12T08:00:00Z",
+		},
+		{
+			name: "excluded occurrence",
+			from: "2026-10-19T00:00:00Z", to: "2026-10-20T00:00:00Z",
+		},
+		{
+			name: "count from the first occurrence",
+			from: "2026-10-25T00:00:00Z", to: "2026-11-01T00:00:00Z",
+			want: []string{
+				"2026-10-25T08:00:00Z 30m0s Daily check",
+				"2026-10-26T08:00:00Z 30m0s Daily check",
+				"2026-10-30T15:00:00Z 1h0m0s Retro",
+				"2026-10-31T17:00:00Z 1h0m0s Month end",
+			},
+		},
+		{
+			name: "overlapping the start",
+			from: "2026-10-15T14:00:00Z", to: "2026-10-15T18:00:00Z",
+			want: []string{"2026-10-15T13:00:00Z 2h0m0s Quarterly planning, budget review"},
+		},
+		{
+			name: "after the last occurrences",
+			from: "2026-11-01T00:00:00Z", to: "2027-03-01T00:00:00Z",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, e := range cal.Occurrences(date(test.from), date(test.to)) {
+				got = append(got, fmt.Sprintf("%s %s %s", e.Start.UTC().Format(time.RFC3339), e.Duration(), e.Summary))
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseICS(t *testing.T) {
+	cal := readCalendar(t)
+	var planning, offsite *Event
+	for i, e := range cal.Events {
+		switch e.UID {
+		case "planning@example.com":
+			planning = &cal.Events[i]
+		case "offsite@example.com":
+			offsite = &cal.Events[i]
+		}
+	}
+	if planning == nil || offsite == nil {
+		t.Fatalf("events missing: %+v", cal.Events)
+	}
+
+	if planning.Description != "Agenda:\n1. Budget\n2. Roadmap" {
+		t.Errorf("description = %q; the alarm's or a folded line's", planning.Description)
+	}
+	if want := (Person{Name: "Ann Example", Email: "ann@example.com"}); planning.Organizer != want {
+		t.Errorf("organizer = %+v", planning.Organizer)
+	}
+	want := []Person{
+		{Name: "Doe, Jane", Email: "jane@acme.com", Status: "DECLINED"},
+		{Name: "Bob", Email: "bob@Globex.com", Status: "ACCEPTED"},
+	}
+	if !reflect.DeepEqual(planning.Attendees, want) {
+		t.Errorf("attendees = %+v", planning.Attendees)
+	}
+	if planning.Attendees[1].Domain() != "globex.com" {
+		t.Errorf("domain = %q", planning.Attendees[1].Domain())
+	}
+	if planning.Status != "CONFIRMED" || planning.Transparent {
+		t.Errorf("planning is %s, transparent %t", planning.Status, planning.Transparent)
+	}
+	if !offsite.AllDay || !offsite.Transparent || !offsite.End.Equal(date("2026-10-17T00:00:00Z")) {
+		t.Errorf("offsite = %+v", offsite)
+	}
+
+	if len(cal.Warnings) != 1 || !strings.Contains(cal.Warnings[0], `"Mars/Olympus"`) {
+		t.Errorf("warnings = %q", cal.Warnings)
+	}
+}
+
+func TestParseICSErrors(t *testing.T) {
+	tests := []struct {
+		ics, err string
+	}{
+		{"BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VEVENT\n", "Unterminated VCALENDAR"},
+		{"END:VCALENDAR\n", "Line 1: END without BEGIN"},
+		{"BEGIN:VCALENDAR\nno colon\nEND:VCALENDAR\n", "Line 2: invalid iCalendar content line"},
+	}
+	for _, test := range tests {
+		if _, err := ParseICS(strings.NewReader(test.ics), time.UTC); err == nil || err.Error() != test.err {
+			t.Errorf("ParseICS(%q) = %v, want %s", test.ics, err, test.err)
+		}
+	}
+
+	cal, err := ParseICS(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Weird\nDTSTART:20261012T090000Z\nRRULE:FREQ=HOURLY\nEND:VEVENT\nBEGIN:VEVENT\nSUMMARY:No start\nEND:VEVENT\nEND:VCALENDAR\n"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cal.Occurrences(date("2026-10-01T00:00:00Z"), date("2026-11-01T00:00:00Z"))); n != 1 {
+		t.Errorf("an event with an unsupported rule has %d occurrences, want its first", n)
+	}
+	want := []string{
+		`Event "Weird": Unsupported recurrence frequency HOURLY; only its first occurrence is used`,
+		`Event "No start" has no start time and is skipped`,
+	}
+	if !reflect.DeepEqual(cal.Warnings, want) {
+		t.Errorf("warnings = %q", cal.Warnings)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"PT15M", 15 * time.Minute},
+		{"PT1H30M", 90 * time.Minute},
+		{"P1D", 24 * time.Hour},
+		{"P1W", 7 * 24 * time.Hour},
+		{"P1DT2H", 26 * time.Hour},
+		{"-PT10M", -10 * time.Minute},
+		{"+PT45S", 45 * time.Second},
+	}
+	for _, test := range tests {
+		if got, err := parseDuration(test.in); err != nil || got != test.want {
+			t.Errorf("parseDuration(%q) = %v, %v, want %v", test.in, got, err, test.want)
+		}
+	}
+	for _, s := range []string{"", "P", "PT", "1H", "PT1X", "P1H", "PT15"} {
+		if _, err := parseDuration(s); err == nil {
+			t.Errorf("parseDuration(%q) succeeded", s)
+		}
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	r, err := parseRecurrence("FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR,2MO,TU;COUNT=4;WKST=MO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &recurrence{freq: "MONTHLY", interval: 2, count: 4,
+		byDay: []weekdayNum{{-1, time.Friday}, {2, time.Monday}, {0, time.Tuesday}}}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("parseRecurrence = %+v", r)
+	}
+
+	for _, s := range []string{
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;COUNT=x",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=0FR",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYSETPOS=1",
+	} {
+		if _, err := parseRecurrence(s); err == nil {
+			t.Errorf("parseRecurrence(%q) succeeded", s)
+		}
+	}
+}
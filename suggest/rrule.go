@@ -0,0 +1,301 @@
+package suggest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds the expansion of a recurring event, in case of
+// rules that never reach the end of the time range.
+const maxOccurrences = 100000
+
+// recurrence is the subset of RRULEs that meetings use: a frequency, an
+// interval, an end given by COUNT or UNTIL, and BYDAY for weekly and monthly
+// rules.
+type recurrence struct {
+	freq     string
+	interval int
+	count    int
+	until    string
+	byDay    []weekdayNum
+}
+
+// weekdayNum is a day of BYDAY, such as MO, or -1FR for the last Friday of
+// the month.
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRecurrence(s string) (*recurrence, error) {
+	r := &recurrence{interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		i := strings.Index(part, "=")
+		if i == -1 {
+			continue
+		}
+		name, value := strings.ToUpper(part[:i]), strings.ToUpper(part[i+1:])
+		switch name {
+		case "FREQ":
+			switch value {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				r.freq = value
+			default:
+				return nil, fmt.Errorf("Unsupported recurrence frequency %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("Invalid recurrence interval %q", value)
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("Invalid recurrence count %q", value)
+			}
+			r.count = n
+		case "UNTIL":
+			r.until = value
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				if len(d) < 2 {
+					return nil, fmt.Errorf("Invalid recurrence day %q", d)
+				}
+				day, ok := weekdays[d[len(d)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("Invalid recurrence day %q", d)
+				}
+				n := 0
+				if len(d) > 2 {
+					var err error
+					if n, err = strconv.Atoi(d[:len(d)-2]); err != nil || n == 0 {
+						return nil, fmt.Errorf("Invalid recurrence day %q", d)
+					}
+				}
+				r.byDay = append(r.byDay, weekdayNum{n: n, day: day})
+			}
+		case "WKST":
+			// Weeks are taken to start on Monday, the default
+		default:
+			return nil, fmt.Errorf("Unsupported recurrence rule %s", name)
+		}
+	}
+	if r.freq == "" {
+		return nil, fmt.Errorf("The recurrence rule has no frequency")
+	}
+	if r.freq == "DAILY" || r.freq == "YEARLY" {
+		if len(r.byDay) > 0 {
+			return nil, fmt.Errorf("Unsupported recurrence rule BYDAY with FREQ=%s", r.freq)
+		}
+	}
+	return r, nil
+}
+
+// untilTime returns the last time an occurrence of a rule starting at start
+// can start, or a zero time if the rule has no UNTIL.
+func (r *recurrence) untilTime(start time.Time) (time.Time, error) {
+	switch {
+	case r.until == "":
+		return time.Time{}, nil
+	case len(r.until) == 8:
+		// The whole day is included
+		t, err := time.ParseInLocation("20060102", r.until, start.Location())
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), err
+	case strings.HasSuffix(r.until, "Z"):
+		return time.Parse("20060102T150405Z", r.until)
+	}
+	return time.ParseInLocation("20060102T150405", r.until, start.Location())
+}
+
+// starts returns the start times of a rule's occurrences from the first,
+// start, until one starts at or after to.
+func (r *recurrence) starts(start, to time.Time) ([]time.Time, error) {
+	until, err := r.untilTime(start)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid recurrence end %q", r.until)
+	}
+
+	var times []time.Time
+	for period := 0; period < maxOccurrences && r.periodStart(start, period).Before(to); period++ {
+		for _, t := range r.period(start, period) {
+			if t.Before(start) {
+				continue
+			}
+			if !t.Before(to) || (!until.IsZero() && t.After(until)) || (r.count > 0 && len(times) == r.count) {
+				return times, nil
+			}
+			times = append(times, t)
+		}
+	}
+	return times, nil
+}
+
+// periodStart returns the first day of a period of a rule.
+func (r *recurrence) periodStart(start time.Time, period int) time.Time {
+	y, m, d := start.Date()
+	loc := start.Location()
+	k := period * r.interval
+	switch r.freq {
+	case "DAILY":
+		return time.Date(y, m, d+k, 0, 0, 0, 0, loc)
+	case "WEEKLY":
+		monday := d - (int(start.Weekday())+6)%7
+		return time.Date(y, m, monday+7*k, 0, 0, 0, 0, loc)
+	case "MONTHLY":
+		return time.Date(y, m+time.Month(k), 1, 0, 0, 0, 0, loc)
+	}
+	return time.Date(y+k, 1, 1, 0, 0, 0, 0, loc)
+}
+
+// period returns the starts of the occurrences in a period of a rule, in
+// order. Dates that don't exist, such as February 30, are skipped.
+func (r *recurrence) period(start time.Time, period int) []time.Time {
+	_, m, d := start.Date()
+	hh, mm, ss := start.Clock()
+	loc := start.Location()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, 0, loc)
+	}
+	first := r.periodStart(start, period)
+	fy, fm, fd := first.Date()
+
+	var times []time.Time
+	switch r.freq {
+	case "DAILY":
+		times = append(times, at(fy, fm, fd))
+	case "WEEKLY":
+		days := r.byDay
+		if len(days) == 0 {
+			days = []weekdayNum{{day: start.Weekday()}}
+		}
+		for _, wd := range days {
+			times = append(times, at(fy, fm, fd+(int(wd.day)+6)%7))
+		}
+	case "MONTHLY":
+		if len(r.byDay) == 0 {
+			if t := at(fy, fm, d); t.Month() == fm {
+				times = append(times, t)
+			}
+		}
+		for _, wd := range r.byDay {
+			times = append(times, monthDays(fy, fm, wd, at)...)
+		}
+	case "YEARLY":
+		if t := at(fy, m, d); t.Month() == m {
+			times = append(times, t)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// monthDays returns the days of a month matching a BYDAY day: every such
+// weekday, or the nth from the start or, if n is negative, from the end.
+func monthDays(y int, m time.Month, wd weekdayNum, at func(int, time.Month, int) time.Time) []time.Time {
+	var days []int
+	for d := 1; d <= 31; d++ {
+		t := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+		if t.Month() != m {
+			break
+		}
+		if t.Weekday() == wd.day {
+			days = append(days, d)
+		}
+	}
+
+	var times []time.Time
+	switch {
+	case wd.n == 0:
+		for _, d := range days {
+			times = append(times, at(y, m, d))
+		}
+	case wd.n > 0 && wd.n <= len(days):
+		times = append(times, at(y, m, days[wd.n-1]))
+	case wd.n < 0 && -wd.n <= len(days):
+		times = append(times, at(y, m, days[len(days)+wd.n]))
+	}
+	return times
+}
+
+// Occurrences returns the events, and the occurrences of recurring events,
+// that overlap the time range from from to to, in order of their start.
+// Occurrences excluded by EXDATE are left out, and those that were changed
+// are replaced by their changed versions.
+func (c *Calendar) Occurrences(from, to time.Time) []Event {
+	// Changed occurrences by the UID of their event
+	changed := make(map[string][]Event)
+	for _, e := range c.Events {
+		if !e.recurrenceID.IsZero() {
+			changed[e.UID] = append(changed[e.UID], e)
+		}
+	}
+	hasRule := make(map[string]bool)
+	for _, e := range c.Events {
+		if e.rule != nil && e.recurrenceID.IsZero() {
+			hasRule[e.UID] = true
+		}
+	}
+
+	var events []Event
+	add := func(e Event) {
+		if (e.End.After(from) || e.Start.Equal(from)) && e.Start.Before(to) {
+			e.rule, e.exdates, e.recurrenceID = nil, nil, time.Time{}
+			events = append(events, e)
+		}
+	}
+	for _, e := range c.Events {
+		switch {
+		case !e.recurrenceID.IsZero():
+			if !hasRule[e.UID] {
+				add(e)
+			}
+		case e.rule == nil:
+			add(e)
+		default:
+			starts, err := e.rule.starts(e.Start, to)
+			if err != nil {
+				c.Warnings = append(c.Warnings, fmt.Sprintf("Event %q: %v", e.Summary, err))
+				add(e)
+				continue
+			}
+			duration := e.Duration()
+		occurrences:
+			for _, start := range starts {
+				for _, ex := range e.exdates {
+					if ex.Equal(start) {
+						continue occurrences
+					}
+				}
+				for _, ch := range changed[e.UID] {
+					if ch.recurrenceID.Equal(start) {
+						add(ch)
+						continue occurrences
+					}
+				}
+				occurrence := e
+				occurrence.Start, occurrence.End = start, start.Add(duration)
+				add(occurrence)
+			}
+			// Occurrences moved into the range from after it
+			for _, ch := range changed[e.UID] {
+				if !ch.recurrenceID.Before(to) {
+					add(ch)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events
+}
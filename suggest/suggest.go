@@ -0,0 +1,288 @@
+/*
+Package suggest suggests time entries for the events of a calendar, so that
+meetings get tracked.
+
+ParseICS reads an iCalendar file, and Calendar.Occurrences lists the events
+of a time range, with recurring events expanded. Suggest matches the events
+to projects with Rules, and suggests an entry for every event the user's
+entries don't already cover:
+
+	cal, err := suggest.ParseICS(file, loc)
+	rules := []suggest.Rule{
+		{Domains: []string{"acme.com"}, Project: "Acme/Website"},
+		{Title: "(?i)standup", Project: "Internal", Tags: []string{"meeting"}},
+		{Title: "(?i)lunch", Ignore: true},
+	}
+	result, err := suggest.Suggest(&account, cal.Occurrences(from, to), existing, rules, suggest.Options{Me: "ann@example.com"})
+
+The suggested entries can be reviewed and created with Session.CreateTimeEntry.
+*/
+package suggest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// DefaultCoverage is the part of an event that entries must cover for it to
+// count as tracked, when Options.Coverage is zero.
+const DefaultCoverage = 0.5
+
+// Rule matches events and says which project their entries get. A rule
+// matches an event if all of its conditions do, so a rule without
+// conditions matches every event.
+type Rule struct {
+	// Organizer matches the email address of the event's organizer, as for
+	// path.Match and ignoring case, such as "*@acme.com".
+	Organizer string `json:"organizer,omitempty"`
+	// Title is a regular expression matching the event's title.
+	Title string `json:"title,omitempty"`
+	// Domains match events with an attendee, other than the user, whose
+	// email address is in one of the domains.
+	Domains []string `json:"domains,omitempty"`
+
+	// Project is the project of the entries, as for Account.FindProject.
+	Project string   `json:"project,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	// Billable makes the entries billable. Entries are also billable if
+	// their project is.
+	Billable bool `json:"billable,omitempty"`
+	// Description is the description of the entries. It defaults to the
+	// event's title.
+	Description string `json:"description,omitempty"`
+	// Ignore skips matching events, such as lunch breaks.
+	Ignore bool `json:"ignore,omitempty"`
+}
+
+// ParseRules reads rules from a JSON array.
+func ParseRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("Invalid rules: %v", err)
+	}
+	for i, rule := range rules {
+		if _, err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("Rule %d: %v", i+1, err)
+		}
+	}
+	return rules, nil
+}
+
+// compile returns the rule's title expression.
+func (rule Rule) compile() (*regexp.Regexp, error) {
+	if rule.Title == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(rule.Title)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid title expression %q: %v", rule.Title, err)
+	}
+	return re, nil
+}
+
+// match returns true if the rule matches an event, given its compiled
+// title expression and the user's email address.
+func (rule Rule) match(e Event, title *regexp.Regexp, me string) bool {
+	if rule.Organizer != "" {
+		ok, _ := path.Match(strings.ToLower(rule.Organizer), strings.ToLower(e.Organizer.Email))
+		if !ok {
+			return false
+		}
+	}
+	if title != nil && !title.MatchString(e.Summary) {
+		return false
+	}
+	if len(rule.Domains) > 0 {
+		found := false
+		for _, p := range append([]Person{e.Organizer}, e.Attendees...) {
+			if p.Email == "" || strings.EqualFold(p.Email, me) {
+				continue
+			}
+			for _, domain := range rule.Domains {
+				domain = strings.ToLower(strings.TrimPrefix(domain, "@"))
+				found = found || p.Domain() == domain
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Options controls which events get suggestions.
+type Options struct {
+	// Me is the user's email address. Events the user declined are
+	// skipped, and the user isn't counted as an attendee by Rule.Domains.
+	Me string
+	// Workspace is the ID of the workspace of entries without a project.
+	// Zero means the account's first workspace.
+	Workspace int
+	// Coverage is the part of an event, from 0 to 1, that existing entries
+	// must cover for it to be skipped as tracked.
+	Coverage float64
+}
+
+// Suggestion is an entry suggested for an event.
+type Suggestion struct {
+	Event Event           `json:"event"`
+	Entry toggl.TimeEntry `json:"entry"`
+	// Project is the title of the entry's project.
+	Project string `json:"project,omitempty"`
+	// Rule is the index of the rule that matched the event.
+	Rule int `json:"rule"`
+}
+
+// Skipped is an event without a suggestion, and why.
+type Skipped struct {
+	Event  Event  `json:"event"`
+	Reason string `json:"reason"`
+}
+
+// Result lists the suggestions for events, and the events skipped.
+type Result struct {
+	Suggestions []Suggestion `json:"suggestions"`
+	Skipped     []Skipped    `json:"skipped"`
+}
+
+// Entries returns the suggested entries.
+func (r *Result) Entries() []toggl.TimeEntry {
+	entries := make([]toggl.TimeEntry, len(r.Suggestions))
+	for i, s := range r.Suggestions {
+		entries[i] = s.Entry
+	}
+	return entries
+}
+
+// Suggest suggests entries for events. Events are skipped if they're
+// cancelled, declined, free, last all day, match no rule or a rule that
+// ignores them, or are covered by existing entries or the suggestions for
+// earlier events. An error is returned if a rule is invalid or names a
+// project that can't be found.
+func Suggest(account *toggl.Account, events []Event, existing []toggl.TimeEntry, rules []Rule, options Options) (*Result, error) {
+	if options.Coverage <= 0 {
+		options.Coverage = DefaultCoverage
+	}
+	if options.Workspace == 0 && len(account.Data.Workspaces) > 0 {
+		options.Workspace = account.Data.Workspaces[0].ID
+	}
+
+	titles := make([]*regexp.Regexp, len(rules))
+	projects := make([]*toggl.Project, len(rules))
+	for i, rule := range rules {
+		var err error
+		if titles[i], err = rule.compile(); err != nil {
+			return nil, fmt.Errorf("Rule %d: %v", i+1, err)
+		}
+		if rule.Project != "" && !rule.Ignore {
+			if projects[i], err = account.FindProject(rule.Project); err != nil {
+				return nil, fmt.Errorf("Rule %d: %v", i+1, err)
+			}
+		}
+	}
+
+	covered := toggl.NewCoverage(existing, time.Now())
+
+	result := &Result{}
+	skip := func(e Event, reason string) {
+		result.Skipped = append(result.Skipped, Skipped{Event: e, Reason: reason})
+	}
+	for _, e := range events {
+		if reason := excluded(e, options.Me); reason != "" {
+			skip(e, reason)
+			continue
+		}
+
+		matched := -1
+		for i, rule := range rules {
+			if rule.match(e, titles[i], options.Me) {
+				matched = i
+				break
+			}
+		}
+		if matched == -1 {
+			skip(e, "No rule matches")
+			continue
+		}
+		if rules[matched].Ignore {
+			skip(e, fmt.Sprintf("Ignored by rule %d", matched+1))
+			continue
+		}
+
+		if names := covered.CoveredBy(e.Start, e.End, options.Coverage); len(names) > 0 {
+			skip(e, "Tracked by "+strings.Join(names, ", "))
+			continue
+		}
+
+		s, err := suggestion(account, e, rules[matched], projects[matched], options)
+		if err != nil {
+			return nil, fmt.Errorf("Rule %d: %v", matched+1, err)
+		}
+		s.Rule = matched
+		result.Suggestions = append(result.Suggestions, s)
+		covered.Add(e.Start, e.End, fmt.Sprintf("the suggestion for %q", e.Summary))
+	}
+	return result, nil
+}
+
+// excluded returns why an event can't have an entry, or an empty string.
+func excluded(e Event, me string) string {
+	switch {
+	case e.Status == "CANCELLED":
+		return "Cancelled"
+	case e.AllDay:
+		return "All day"
+	case e.Transparent:
+		return "Free"
+	case !e.End.After(e.Start):
+		return "No duration"
+	}
+	if me != "" {
+		for _, a := range e.Attendees {
+			if strings.EqualFold(a.Email, me) && a.Status == "DECLINED" {
+				return "Declined"
+			}
+		}
+	}
+	return ""
+}
+
+// suggestion returns the entry for an event matched by a rule.
+func suggestion(account *toggl.Account, e Event, rule Rule, project *toggl.Project, options Options) (Suggestion, error) {
+	start, stop := e.Start, e.End
+	entry := toggl.TimeEntry{
+		Wid:         options.Workspace,
+		Description: rule.Description,
+		Start:       &start,
+		Stop:        &stop,
+		Duration:    int64(e.Duration() / time.Second),
+	}
+	if entry.Description == "" {
+		entry.Description = e.Summary
+	}
+	if rule.Billable {
+		entry.Billable = 1
+	}
+
+	s := Suggestion{Event: e}
+	if project != nil {
+		entry.Wid, entry.Pid = project.Wid, project.ID
+		s.Project = account.ProjectTitle(*project)
+		if project.Billable != 0 {
+			entry.Billable = project.Billable
+		}
+	}
+
+	if err := account.AddTags(&entry, rule.Tags); err != nil {
+		return s, err
+	}
+	s.Entry = entry
+	return s, nil
+}
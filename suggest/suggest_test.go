@@ -0,0 +1,62 @@
+package suggest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+func TestSuggest(t *testing.T) {
+	var account toggl.Account
+	account.Data.Workspaces = []toggl.Workspace{{ID: 1, Name: "Work"}}
+	account.Data.Clients = []toggl.Client{{ID: 3, Wid: 1, Name: "Acme"}}
+	account.Data.Projects = []toggl.Project{{ID: 10, Wid: 1, Cid: 3, Name: "Website", Active: true}}
+	account.Data.Tags = []toggl.Tag{{ID: 1, Wid: 1, Name: "debug"}, {ID: 2, Wid: 1, Name: "Meeting"}}
+
+	event := func(summary, start string, minutes int, attendees ...string) Event {
+		e := Event{Summary: summary, Start: date(start)}
+		e.End = e.Start.Add(time.Duration(minutes) * time.Minute)
+		for _, a := range attendees {
+			e.Attendees = append(e.Attendees, Person{Email: a})
+		}
+		return e
+	}
+	events := []Event{
+		event("Acme sync", "2026-10-12T09:00:00Z", 60, "me@example.com", "jane@acme.com"),
+		event("Lunch", "2026-10-12T12:00:00Z", 60),
+		event("Bug triage", "2026-10-12T14:00:00Z", 30),
+		event("Tracked", "2026-10-12T15:00:00Z", 60),
+		event("Same time", "2026-10-12T14:00:00Z", 30),
+		event("Unmatched", "2026-10-12T17:00:00Z", 30),
+	}
+	start, stop := date("2026-10-12T15:10:00Z"), date("2026-10-12T15:45:00Z")
+	existing := []toggl.TimeEntry{{ID: 99, Wid: 1, Start: &start, Stop: &stop, Duration: 35 * 60}}
+	rules := []Rule{
+		{Domains: []string{"@acme.com"}, Project: "Acme/Website", Tags: []string{"meeting"}},
+		{Title: "(?i)lunch", Ignore: true},
+		{Title: "(?i)bug|tracked|same", Tags: []string{"bug"}, Description: "Triage"},
+	}
+
+	result, err := Suggest(&account, events, existing, rules, Options{Me: "me@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range result.Suggestions {
+		got = append(got, s.Entry.Description+" "+s.Project+" "+strings.Join(s.Entry.Tags, ","))
+	}
+	if want := "Acme sync Acme/Website Meeting; Triage  bug"; strings.Join(got, "; ") != want {
+		t.Errorf("suggestions = %q, want %q", strings.Join(got, "; "), want)
+	}
+
+	got = nil
+	for _, s := range result.Skipped {
+		got = append(got, s.Event.Summary+": "+s.Reason)
+	}
+	want := `Lunch: Ignored by rule 2; Tracked: Tracked by entry 99; Same time: Tracked by the suggestion for "Bug triage"; Unmatched: No rule matches`
+	if strings.Join(got, "; ") != want {
+		t.Errorf("skipped = %q, want %q", strings.Join(got, "; "), want)
+	}
+}
@@ -0,0 +1,179 @@
+package toggl
+
+// AccountEntities groups the entities that make up a user's account data.
+type AccountEntities struct {
+	Workspaces  []Workspace `json:"workspaces,omitempty"`
+	Clients     []Client    `json:"clients,omitempty"`
+	Projects    []Project   `json:"projects,omitempty"`
+	Tasks       []Task      `json:"tasks,omitempty"`
+	Tags        []Tag       `json:"tags,omitempty"`
+	TimeEntries []TimeEntry `json:"time_entries,omitempty"`
+}
+
+// AccountDelta describes the changes to a user's account since the previous
+// call to Sync. Entities with a server_deleted_at timestamp are reported as
+// deleted; other entities are reported as added the first time a session sees
+// them and as updated afterwards.
+type AccountDelta struct {
+	// Since is the server timestamp the next Sync will request changes from.
+	Since int `json:"since"`
+	// Full is true when the delta was built from a complete account fetch
+	// rather than an incremental one.
+	Full    bool            `json:"full"`
+	Added   AccountEntities `json:"added"`
+	Updated AccountEntities `json:"updated"`
+	Deleted AccountEntities `json:"deleted"`
+}
+
+// IsEmpty returns true if the delta contains no changes.
+func (d *AccountDelta) IsEmpty() bool {
+	return d.Added.isEmpty() && d.Updated.isEmpty() && d.Deleted.isEmpty()
+}
+
+// Sync retrieves the changes made to a user's account since the last call to
+// Sync on this session. The first call fetches the complete account data and
+// reports every entity as added; later calls only request entities changed
+// since the timestamp returned by the previous call.
+func (session *Session) Sync() (AccountDelta, error) {
+	if session.sync == nil {
+		session.sync = newSyncState()
+	}
+
+	account, err := session.fetchAccount(session.sync.since)
+	if err != nil {
+		return AccountDelta{}, err
+	}
+
+	delta := session.sync.apply(&account)
+	dlog.Printf("Synced account since %d", delta.Since)
+	return delta, nil
+}
+
+// SyncSince returns the server timestamp the next call to Sync will request
+// changes from, or 0 if the session has not been synced.
+func (session *Session) SyncSince() int {
+	if session.sync == nil {
+		return 0
+	}
+	return session.sync.since
+}
+
+// ResetSync discards the session's sync state, so that the next call to Sync
+// fetches the complete account data again.
+func (session *Session) ResetSync() {
+	session.sync = nil
+}
+
+// support /////////////////////////////////////////////////////////////
+
+const (
+	syncAdded = iota
+	syncUpdated
+	syncDeleted
+)
+
+// syncState records what a session has seen through Sync.
+type syncState struct {
+	since int
+	known map[string]map[int]bool
+}
+
+func newSyncState() *syncState {
+	return &syncState{known: make(map[string]map[int]bool)}
+}
+
+// classify determines whether an entity of the given kind is new, changed or
+// deleted, and records it accordingly.
+func (s *syncState) classify(kind string, id int, deleted bool) int {
+	ids, ok := s.known[kind]
+	if !ok {
+		ids = make(map[int]bool)
+		s.known[kind] = ids
+	}
+
+	if deleted {
+		delete(ids, id)
+		return syncDeleted
+	}
+
+	if ids[id] {
+		return syncUpdated
+	}
+	ids[id] = true
+	return syncAdded
+}
+
+func (s *syncState) apply(account *Account) (delta AccountDelta) {
+	delta.Full = s.since == 0
+	delta.Since = account.Since
+	s.since = account.Since
+
+	data := &account.Data
+	for _, w := range data.Workspaces {
+		switch s.classify(CacheWorkspaces, w.ID, w.ServerDeletedAt != nil) {
+		case syncAdded:
+			delta.Added.Workspaces = append(delta.Added.Workspaces, w)
+		case syncUpdated:
+			delta.Updated.Workspaces = append(delta.Updated.Workspaces, w)
+		case syncDeleted:
+			delta.Deleted.Workspaces = append(delta.Deleted.Workspaces, w)
+		}
+	}
+	for _, c := range data.Clients {
+		switch s.classify(CacheClients, c.ID, c.ServerDeletedAt != nil) {
+		case syncAdded:
+			delta.Added.Clients = append(delta.Added.Clients, c)
+		case syncUpdated:
+			delta.Updated.Clients = append(delta.Updated.Clients, c)
+		case syncDeleted:
+			delta.Deleted.Clients = append(delta.Deleted.Clients, c)
+		}
+	}
+	for _, p := range data.Projects {
+		switch s.classify(CacheProjects, p.ID, p.ServerDeletedAt != nil) {
+		case syncAdded:
+			delta.Added.Projects = append(delta.Added.Projects, p)
+		case syncUpdated:
+			delta.Updated.Projects = append(delta.Updated.Projects, p)
+		case syncDeleted:
+			delta.Deleted.Projects = append(delta.Deleted.Projects, p)
+		}
+	}
+	for _, t := range data.Tasks {
+		switch s.classify(CacheTasks, t.ID, t.ServerDeletedAt != nil) {
+		case syncAdded:
+			delta.Added.Tasks = append(delta.Added.Tasks, t)
+		case syncUpdated:
+			delta.Updated.Tasks = append(delta.Updated.Tasks, t)
+		case syncDeleted:
+			delta.Deleted.Tasks = append(delta.Deleted.Tasks, t)
+		}
+	}
+	for _, t := range data.Tags {
+		switch s.classify(CacheTags, t.ID, t.ServerDeletedAt != nil) {
+		case syncAdded:
+			delta.Added.Tags = append(delta.Added.Tags, t)
+		case syncUpdated:
+			delta.Updated.Tags = append(delta.Updated.Tags, t)
+		case syncDeleted:
+			delta.Deleted.Tags = append(delta.Deleted.Tags, t)
+		}
+	}
+	for _, e := range data.TimeEntries {
+		switch s.classify(CacheTimeEntries, e.ID, e.ServerDeletedAt != nil) {
+		case syncAdded:
+			delta.Added.TimeEntries = append(delta.Added.TimeEntries, e)
+		case syncUpdated:
+			delta.Updated.TimeEntries = append(delta.Updated.TimeEntries, e)
+		case syncDeleted:
+			delta.Deleted.TimeEntries = append(delta.Deleted.TimeEntries, e)
+		}
+	}
+
+	return
+}
+
+func (e *AccountEntities) isEmpty() bool {
+	return len(e.Workspaces) == 0 && len(e.Clients) == 0 && len(e.Projects) == 0 &&
+		len(e.Tasks) == 0 && len(e.Tags) == 0 && len(e.TimeEntries) == 0
+}
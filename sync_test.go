@@ -0,0 +1,94 @@
+package toggl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSync(t *testing.T) {
+	// The account as a whole, then the changes since each timestamp
+	responses := map[string]string{
+		"": `{"since": 100, "data": {"id": 1, "workspaces": [{"id": 1, "name": "Work"}],
+			"projects": [{"id": 10, "wid": 1, "name": "Website"}, {"id": 11, "wid": 1, "name": "Old"}],
+			"tags": [{"id": 20, "wid": 1, "name": "bug"}],
+			"time_entries": [{"id": 30, "wid": 1, "description": "Design"}]}}`,
+		"100": `{"since": 200, "data": {"id": 1,
+			"projects": [{"id": 10, "wid": 1, "name": "Web"}, {"id": 11, "wid": 1, "name": "Old", "server_deleted_at": "2026-10-14T09:00:00Z"}, {"id": 12, "wid": 1, "name": "App"}],
+			"time_entries": [{"id": 30, "wid": 1, "description": "Design", "server_deleted_at": "2026-10-14T09:00:00Z"}]}}`,
+		"200": `{"since": 300, "data": {"id": 1}}`,
+	}
+	var requested []string
+	saved := client
+	defer func() { client = saved }()
+	client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		since := req.URL.Query().Get("since")
+		requested = append(requested, since)
+		body := []byte(responses[since])
+		return &http.Response{StatusCode: 200, Status: "200 OK", Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+	})}
+	DisableLog()
+	session := OpenSession("token")
+
+	names := func(projects []Project) (names []string) {
+		for _, p := range projects {
+			names = append(names, p.Name)
+		}
+		return
+	}
+
+	delta, err := session.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !delta.Full || delta.Since != 100 || session.SyncSince() != 100 {
+		t.Errorf("first sync: full %v since %d", delta.Full, delta.Since)
+	}
+	if got := names(delta.Added.Projects); !reflect.DeepEqual(got, []string{"Website", "Old"}) {
+		t.Errorf("first sync added projects %q", got)
+	}
+	if len(delta.Added.Workspaces) != 1 || len(delta.Added.Tags) != 1 || len(delta.Added.TimeEntries) != 1 ||
+		!delta.Updated.isEmpty() || !delta.Deleted.isEmpty() {
+		t.Errorf("first sync: %+v", delta)
+	}
+
+	delta, err = session.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delta.Full || delta.Since != 200 {
+		t.Errorf("second sync: full %v since %d", delta.Full, delta.Since)
+	}
+	if got := names(delta.Added.Projects); !reflect.DeepEqual(got, []string{"App"}) {
+		t.Errorf("second sync added projects %q", got)
+	}
+	if got := names(delta.Updated.Projects); !reflect.DeepEqual(got, []string{"Web"}) {
+		t.Errorf("second sync updated projects %q", got)
+	}
+	if got := names(delta.Deleted.Projects); !reflect.DeepEqual(got, []string{"Old"}) {
+		t.Errorf("second sync deleted projects %q", got)
+	}
+	if len(delta.Deleted.TimeEntries) != 1 || delta.Deleted.TimeEntries[0].ID != 30 || len(delta.Updated.TimeEntries) != 0 {
+		t.Errorf("second sync: time entries %+v", delta)
+	}
+
+	if delta, err = session.Sync(); err != nil || !delta.IsEmpty() || delta.Since != 300 {
+		t.Errorf("third sync = %+v, %v, want no changes", delta, err)
+	}
+
+	// After a reset everything is new again, including what was deleted
+	// before
+	session.ResetSync()
+	if session.SyncSince() != 0 {
+		t.Errorf("SyncSince after a reset = %d", session.SyncSince())
+	}
+	if delta, err = session.Sync(); err != nil || !delta.Full || len(delta.Added.Projects) != 2 || !delta.Updated.isEmpty() {
+		t.Errorf("sync after a reset = %+v, %v", delta, err)
+	}
+
+	if want := []string{"", "100", "200", ""}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested changes since %q, want %q", requested, want)
+	}
+}
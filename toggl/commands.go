@@ -0,0 +1,764 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/aggregate"
+	"github.com/Jberlinsky/go-toggl/format"
+)
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:    "start",
+			args:    "[ENTRY]",
+			summary: "start a timer, or record a completed entry",
+			run:     runStart,
+		},
+		{
+			name:    "stop",
+			summary: "stop the running timer",
+			run:     runStop,
+		},
+		{
+			name:    "current",
+			summary: "show the running timer",
+			run:     runCurrent,
+		},
+		{
+			name:    "continue",
+			args:    "[ID]",
+			summary: "start a copy of an entry, by default the latest",
+			run:     runContinue,
+		},
+		{
+			name:    "switch",
+			args:    "[ENTRY]",
+			summary: "stop the running timer and start another",
+			run:     runSwitch,
+		},
+		{
+			name:    "edit",
+			args:    "[FLAGS] ID",
+			summary: "change an entry",
+			setup:   setupEdit,
+			run:     runEdit,
+		},
+		{
+			name:    "delete",
+			args:    "ID...",
+			summary: "delete entries",
+			run:     runDelete,
+		},
+		{
+			name:    "list",
+			args:    "[-since DATE] [-until DATE]",
+			summary: "list the entries of a time range",
+			setup:   setupRange,
+			run:     runList,
+		},
+		{
+			name:    "projects",
+			args:    "[-workspace NAME] [-client NAME] [-all]",
+			summary: "list projects",
+			setup:   setupProjects,
+			run:     runProjects,
+		},
+		{
+			name:    "clients",
+			summary: "list clients",
+			run:     runClients,
+		},
+		{
+			name:    "tags",
+			summary: "list tags",
+			run:     runTags,
+		},
+		{
+			name:    "workspaces",
+			summary: "list workspaces",
+			run:     runWorkspaces,
+		},
+		{
+			name:    "report",
+			args:    "[-since DATE] [-until DATE] [-by DIMENSIONS]",
+			summary: "total the entries of a time range",
+			setup:   setupReport,
+			run:     runReport,
+		},
+		{
+			name:    "import",
+			args:    "[FLAGS] FILE",
+			summary: "import entries from CSV, Harvest, Clockify or Timewarrior files",
+			setup:   setupImport,
+			run:     runImport,
+		},
+		{
+			name:    "export",
+			args:    "[-to FORMAT] [-since DATE] [-until DATE]",
+			summary: "write the entries of a time range as iCalendar, Timewarrior or timeclock",
+			setup:   setupExport,
+			run:     runExport,
+		},
+		{
+			name:    "suggest",
+			args:    "[FLAGS] FILE",
+			summary: "suggest entries for the events of an iCalendar file",
+			setup:   setupSuggest,
+			run:     runSuggest,
+		},
+		{
+			name:    "git",
+			args:    "[FLAGS] [REPO...]",
+			summary: "propose entries for the commits of git repositories",
+			setup:   setupGit,
+			run:     runGit,
+		},
+		{
+			name:   "git-checkout",
+			hidden: true,
+			run:    runGitCheckout,
+		},
+		{
+			name:    "tui",
+			summary: "manage timers and entries interactively",
+			run:     runTUI,
+		},
+		{
+			name:    "login",
+			summary: "log in and store the API token in a profile",
+			run:     runLogin,
+		},
+		{
+			name:    "logout",
+			summary: "remove the API token from a profile",
+			run:     runLogout,
+		},
+		{
+			name:    "profiles",
+			summary: "list profiles",
+			run:     runProfiles,
+		},
+		{
+			name:    "completion",
+			args:    "bash|zsh|fish",
+			summary: "write a shell completion script",
+			run:     runCompletion,
+		},
+		{
+			name:   "__complete",
+			hidden: true,
+			run:    runComplete,
+		},
+		{
+			name:    "help",
+			args:    "[COMMAND]",
+			summary: "show help for a command",
+			run:     runHelp,
+		},
+	}
+}
+
+// editFlags are the flags of the edit command.
+type editFlags struct {
+	description, project, tags string
+	start, stop, duration      string
+	billable                   string
+}
+
+// timer commands //////////////////////////////////////////////////////
+
+func runStart(app *app, args []string) error {
+	entry, err := app.parseEntry(args)
+	if err != nil {
+		return err
+	}
+
+	session, _ := app.getSession()
+	tracker := toggl.NewTracker(session)
+
+	if !entry.IsRunning() || time.Since(entry.StartTime()) > time.Minute {
+		// Entries with explicit times are recorded as given, but a timer
+		// started in the past mustn't replace a running one
+		if entry.IsRunning() {
+			current, err := tracker.Current()
+			if err != nil {
+				return err
+			}
+			if current != nil {
+				return &toggl.TimerRunningError{Entry: *current}
+			}
+		}
+		created, err := session.CreateTimeEntry(entry)
+		if err != nil {
+			return err
+		}
+		return app.printEntry(created)
+	}
+
+	started, err := tracker.Start(entry)
+	if err != nil {
+		return err
+	}
+	return app.printEntry(started)
+}
+
+func runStop(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	session, err := app.getSession()
+	if err != nil {
+		return err
+	}
+	stopped, err := toggl.NewTracker(session).Stop()
+	if err != nil {
+		return err
+	}
+	return app.printEntry(stopped)
+}
+
+func runCurrent(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	session, err := app.getSession()
+	if err != nil {
+		return err
+	}
+	current, err := toggl.NewTracker(session).Current()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		if app.structured() {
+			return app.write(nil)
+		}
+		fmt.Fprintln(app.stdout, "No timer is running")
+		return nil
+	}
+	return app.printEntry(*current)
+}
+
+func runContinue(app *app, args []string) error {
+	if len(args) > 1 {
+		return usagef("expected at most one entry ID")
+	}
+	session, err := app.getSession()
+	if err != nil {
+		return err
+	}
+	tracker := toggl.NewTracker(session)
+
+	if len(args) == 0 {
+		started, err := tracker.ContinueLast()
+		if err != nil {
+			return err
+		}
+		return app.printEntry(started)
+	}
+
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	entry, err := session.GetTimeEntry(id)
+	if err != nil {
+		return err
+	}
+	started, err := tracker.Start(entry)
+	if err != nil {
+		return err
+	}
+	return app.printEntry(started)
+}
+
+func runSwitch(app *app, args []string) error {
+	entry, err := app.parseEntry(args)
+	if err != nil {
+		return err
+	}
+	if !entry.IsRunning() {
+		return usagef("a switched-to entry can't have an end time")
+	}
+	session, _ := app.getSession()
+	started, err := toggl.NewTracker(session).Switch(entry)
+	if err != nil {
+		return err
+	}
+	return app.printEntry(started)
+}
+
+// entry commands //////////////////////////////////////////////////////
+
+func setupEdit(app *app, flags *flag.FlagSet) {
+	flags.StringVar(&app.edit.description, "description", "", "new `description`")
+	flags.StringVar(&app.edit.project, "project", "", "new `project`, as a name, Client/Project or ID; \"none\" removes it")
+	flags.StringVar(&app.edit.start, "start", "", "new start `time`")
+	flags.StringVar(&app.edit.stop, "stop", "", "new stop `time`")
+	flags.StringVar(&app.edit.duration, "duration", "", "new `duration`, keeping the start time")
+	flags.StringVar(&app.edit.tags, "tags", "", "comma-separated `tags` replacing the entry's tags")
+	flags.StringVar(&app.edit.billable, "billable", "", "`true` or false")
+}
+
+func runEdit(app *app, args []string) error {
+	if len(args) != 1 {
+		return usagef("expected one entry ID")
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	// The account sets the time zone times are given in
+	if _, err := app.getAccount(); err != nil {
+		return err
+	}
+	session, _ := app.getSession()
+	entry, err := session.GetTimeEntry(id)
+	if err != nil {
+		return err
+	}
+	if entry.ID == 0 {
+		return fmt.Errorf("No time entry with ID %d", id)
+	}
+
+	if app.edit.description != "" {
+		entry.Description = app.edit.description
+	}
+	switch app.edit.project {
+	case "":
+	case "none":
+		entry.Pid = 0
+	default:
+		pid, err := app.findProject(app.edit.project)
+		if err != nil {
+			return err
+		}
+		entry.Pid = pid
+	}
+	if app.edit.tags != "" {
+		entry.Tags = nil
+		for _, tag := range strings.Split(app.edit.tags, ",") {
+			if tag = strings.TrimSpace(tag); tag == "" {
+				continue
+			}
+			tag, err := app.findTag(tag, entry.Wid)
+			if err != nil {
+				return err
+			}
+			entry.AddTag(tag)
+		}
+	}
+	if app.edit.billable != "" {
+		billable, err := strconv.ParseBool(app.edit.billable)
+		if err != nil {
+			return usagef("invalid -billable value %q", app.edit.billable)
+		}
+		entry.Billable = 0
+		if billable {
+			entry.Billable = 1
+		}
+	}
+
+	loc := session.Location()
+	if app.edit.start != "" {
+		start, err := parseTime(app.edit.start, entry.StartTime().In(loc), loc)
+		if err != nil {
+			return err
+		}
+		if entry.IsRunning() {
+			entry.Duration = -start.Unix()
+		}
+		entry.SetStartTime(start, app.edit.stop == "" && app.edit.duration == "")
+	}
+	if app.edit.stop != "" {
+		if entry.IsRunning() {
+			return fmt.Errorf("Stop the timer before changing its stop time")
+		}
+		stop, err := parseTime(app.edit.stop, entry.StartTime().In(loc), loc)
+		if err != nil {
+			return err
+		}
+		if !stop.After(entry.StartTime()) {
+			return fmt.Errorf("Entry must end after it starts")
+		}
+		entry.SetStopTime(stop)
+	}
+	if app.edit.duration != "" {
+		if entry.IsRunning() {
+			return fmt.Errorf("Stop the timer before changing its duration")
+		}
+		d, err := toggl.ParseDuration(app.edit.duration)
+		if err != nil {
+			return usagef("%v", err)
+		}
+		entry.SetDuration(int64(d / time.Second))
+	}
+
+	updated, err := session.UpdateTimeEntry(entry)
+	if err != nil {
+		return err
+	}
+	return app.printEntry(updated)
+}
+
+func runDelete(app *app, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected entry IDs")
+	}
+	var ids []int
+	for _, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	session, err := app.getSession()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := session.DeleteTimeEntry(toggl.TimeEntry{ID: id}); err != nil {
+			return fmt.Errorf("Unable to delete entry %d: %v", id, err)
+		}
+	}
+
+	if app.structured() {
+		return app.write(map[string][]int{"deleted": ids})
+	}
+	return nil
+}
+
+func setupRange(app *app, flags *flag.FlagSet) {
+	flags.StringVar(&app.since, "since", "today", "first `date`: today, yesterday, a weekday or 2006-01-02")
+	flags.StringVar(&app.until, "until", "today", "last `date`, inclusive")
+}
+
+func runList(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	entries, err := app.entriesInRange()
+	if err != nil {
+		return err
+	}
+	return app.writeEntries(entries)
+}
+
+// account commands ////////////////////////////////////////////////////
+
+func setupProjects(app *app, flags *flag.FlagSet) {
+	flags.StringVar(&app.workspace, "workspace", "", "only list the projects of a `workspace`, given by name or ID; defaults to the profile's workspace")
+	flags.StringVar(&app.clientName, "client", "", "only list the projects of a `client`")
+	flags.BoolVar(&app.showAll, "all", false, "include archived projects")
+}
+
+func runProjects(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+	session, _ := app.getSession()
+	wid, err := app.findWorkspace(app.workspace)
+	if err != nil {
+		return err
+	}
+	cid := 0
+	if app.clientName != "" {
+		client, err := account.FindClient(app.clientName)
+		if err != nil {
+			return err
+		}
+		cid = client.ID
+	}
+
+	var projects []toggl.Project
+	for _, w := range account.Data.Workspaces {
+		if wid != 0 && w.ID != wid {
+			continue
+		}
+		list, err := session.GetProjects(w.ID)
+		if err != nil {
+			return err
+		}
+		for _, p := range list {
+			if (app.showAll || p.IsActive()) && (cid == 0 || p.Cid == cid) {
+				projects = append(projects, p)
+			}
+		}
+	}
+
+	return app.write(projects)
+}
+
+func runClients(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	if _, err := app.getAccount(); err != nil {
+		return err
+	}
+	session, _ := app.getSession()
+	clients, err := session.GetClients()
+	if err != nil {
+		return err
+	}
+	return app.write(clients)
+}
+
+func runTags(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+
+	var tags []toggl.Tag
+	for _, t := range account.Data.Tags {
+		if t.ServerDeletedAt == nil {
+			tags = append(tags, t)
+		}
+	}
+
+	return app.write(tags)
+}
+
+func runWorkspaces(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+
+	return app.write(account.Data.Workspaces)
+}
+
+// reports /////////////////////////////////////////////////////////////
+
+func setupReport(app *app, flags *flag.FlagSet) {
+	setupRange(app, flags)
+	flags.StringVar(&app.groupBy, "by", "project,description", "comma-separated `dimensions`: day, week, month, workspace, project, client, task, tag, description")
+	flags.StringVar(&app.workspace, "workspace", "", "round with the rules of a `workspace`, given by name or ID; defaults to the profile's workspace")
+}
+
+func runReport(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+
+	var dims []aggregate.Dimension
+	for _, name := range strings.Split(app.groupBy, ",") {
+		dim := aggregate.Dimension(strings.TrimSpace(name))
+		switch dim {
+		case "":
+			continue
+		case aggregate.Day, aggregate.Week, aggregate.Month, aggregate.Workspace, aggregate.Project,
+			aggregate.Client, aggregate.Task, aggregate.Tag, aggregate.Description:
+			dims = append(dims, dim)
+		default:
+			return usagef("unknown dimension %q", name)
+		}
+	}
+
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+	calendar, err := account.Calendar()
+	if err != nil {
+		return err
+	}
+	entries, err := app.entriesInRange()
+	if err != nil {
+		return err
+	}
+
+	wid, err := app.findWorkspace(app.workspace)
+	if err != nil {
+		return err
+	}
+	options := aggregate.Options{GroupBy: dims, Calendar: calendar}
+	for _, w := range account.Data.Workspaces {
+		if w.ID == wid {
+			options.Rounding = w.RoundingRule()
+		}
+	}
+	result := aggregate.Aggregate(account, entries, options)
+
+	return app.write(format.ReportRows(result))
+}
+
+func runHelp(app *app, args []string) error {
+	switch len(args) {
+	case 0:
+		app.usage(app.stdout)
+		return nil
+	case 1:
+		cmd := findCommand(args[0])
+		if cmd == nil {
+			return usagef("unknown command %q", args[0])
+		}
+		app.commandUsage(app.stdout, cmd)
+		return nil
+	}
+	return usagef("expected at most one command")
+}
+
+// support /////////////////////////////////////////////////////////////
+
+// parseEntry parses the quick entry given as arguments.
+func (app *app) parseEntry(args []string) (toggl.TimeEntry, error) {
+	account, err := app.getAccount()
+	if err != nil {
+		return toggl.TimeEntry{}, err
+	}
+	entry, err := account.ParseEntry(strings.Join(args, " "), time.Now())
+	if err != nil {
+		return entry, usagef("%v", err)
+	}
+	return entry, app.applyDefaults(&entry)
+}
+
+// findProject resolves a project given by ID or name.
+func (app *app) findProject(name string) (int, error) {
+	account, err := app.getAccount()
+	if err != nil {
+		return 0, err
+	}
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	project, err := account.FindProject(name)
+	if err != nil {
+		return 0, err
+	}
+	return project.ID, nil
+}
+
+// findWorkspace resolves a workspace given by ID or name. An empty name
+// means the profile's workspace, if it has one.
+func (app *app) findWorkspace(name string) (int, error) {
+	if name == "" {
+		return app.profile.Workspace, nil
+	}
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	account, err := app.getAccount()
+	if err != nil {
+		return 0, err
+	}
+	w, err := account.FindWorkspace(name)
+	if err != nil {
+		return 0, err
+	}
+	return w.ID, nil
+}
+
+// findTag returns the account's name for a tag. Tags that don't match any
+// existing tag are returned unchanged, so that they're created.
+func (app *app) findTag(name string, wid int) (string, error) {
+	account, err := app.getAccount()
+	if err != nil {
+		return "", err
+	}
+	return account.ResolveTag(name, wid)
+}
+
+// dateRange returns the time range from the start of the -since date to the
+// end of the -until date.
+func (app *app) dateRange() (start, end time.Time, err error) {
+	account, err := app.getAccount()
+	if err != nil {
+		return start, end, err
+	}
+	calendar, err := account.Calendar()
+	if err != nil {
+		return start, end, err
+	}
+
+	now := time.Now()
+	if start, err = parseDate(app.since, calendar, now); err != nil {
+		return start, end, err
+	}
+	if end, err = parseDate(app.until, calendar, now); err != nil {
+		return start, end, err
+	}
+	end = calendar.NextDay(end)
+	if !end.After(start) {
+		return start, end, usagef("-until is before -since")
+	}
+	return start, end, nil
+}
+
+// entriesInRange returns the entries between the -since and -until dates,
+// however long the range and however many entries it holds.
+func (app *app) entriesInRange() ([]toggl.TimeEntry, error) {
+	start, end, err := app.dateRange()
+	if err != nil {
+		return nil, err
+	}
+
+	session, _ := app.getSession()
+	entries, err := session.GetAllTimeEntries(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var live []toggl.TimeEntry
+	for _, e := range entries {
+		if e.ServerDeletedAt == nil {
+			live = append(live, e)
+		}
+	}
+	return live, nil
+}
+
+// parseDate parses a date flag into the start of that day.
+func parseDate(s string, calendar toggl.Calendar, now time.Time) (time.Time, error) {
+	day, ok := calendar.ParseDate(s, now)
+	if !ok {
+		return day, usagef("invalid date %q", s)
+	}
+	return day, nil
+}
+
+// parseTime parses a time flag. A time of day alone refers to the given day.
+func parseTime(s string, day time.Time, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("15:04", s, loc); err == nil {
+		y, m, d := day.Date()
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc), nil
+	}
+	return time.Time{}, usagef("invalid time %q; use 15:04, \"2006-01-02 15:04\" or RFC 3339", s)
+}
+
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id == 0 {
+		return 0, usagef("invalid entry ID %q", s)
+	}
+	return id, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jberlinsky/go-toggl"
+)
+
+func TestCommandFlags(t *testing.T) {
+	a, b := &app{}, &app{}
+	for _, args := range [][]string{{"-since", "monday", "-until", "2026-10-14"}, {}} {
+		flags := a.flags(findCommand("list"))
+		if err := flags.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		a, b = b, a
+	}
+	if a.since != "monday" || a.until != "2026-10-14" {
+		t.Errorf("first app's range is %s to %s", a.since, a.until)
+	}
+	if b.since != "today" || b.until != "today" {
+		t.Errorf("second app's range is %s to %s, want the defaults", b.since, b.until)
+	}
+
+	edit := &app{}
+	if err := edit.flags(findCommand("edit")).Parse([]string{"-tags", "bug,dev", "-billable", "true", "42"}); err != nil {
+		t.Fatal(err)
+	}
+	if edit.edit.tags != "bug,dev" || edit.edit.billable != "true" {
+		t.Errorf("edit flags = %+v", edit.edit)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	calendar := toggl.Calendar{Location: time.UTC}
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) // a Wednesday
+	tests := []struct {
+		in, want string
+	}{
+		{"today", "2026-10-14"},
+		{"Yesterday", "2026-10-13"},
+		{"monday", "2026-10-12"},
+		{"wednesday", "2026-10-14"},
+		{"thursday", "2026-10-08"},
+		{"2026-09-30", "2026-09-30"},
+	}
+	for _, test := range tests {
+		got, err := parseDate(test.in, calendar, now)
+		if err != nil || got.Format("2006-01-02 15:04") != test.want+" 00:00" {
+			t.Errorf("parseDate(%q) = %v, %v, want %s", test.in, got, err, test.want)
+		}
+	}
+	if _, err := parseDate("someday", calendar, now); err == nil {
+		t.Errorf("parseDate(someday) succeeded")
+	} else if _, ok := err.(*usageError); !ok {
+		t.Errorf("parseDate(someday) = %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/export"
+	"github.com/Jberlinsky/go-toggl/format"
+)
+
+// Completion scripts call "toggl __complete -- WORDS...", with the words of
+// the command line after "toggl" up to the cursor, and offer the lines it
+// writes. Bash splits words at characters such as @, so its script strips
+// the part of a completion before the word bash is completing.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for toggl
+_toggl() {
+	local line="${COMP_LINE:0:COMP_POINT}" cur="${COMP_WORDS[COMP_CWORD]}" words
+	read -r -a words <<< "$line"
+	[[ $line == *[[:space:]] ]] && words+=("")
+	local word="${words[${#words[@]}-1]}"
+	local strip="${word%"$cur"}"
+	local IFS=$'\n'
+	COMPREPLY=($(toggl __complete -- "${words[@]:1}" 2>/dev/null))
+	COMPREPLY=("${COMPREPLY[@]#"$strip"}")
+}
+complete -F _toggl toggl
+`,
+	"zsh": `#compdef toggl
+_toggl() {
+	local -a completions
+	completions=(${(f)"$(toggl __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+	compadd -U -Q -- "${completions[@]}"
+}
+compdef _toggl toggl
+`,
+	"fish": `# fish completion for toggl
+function __toggl_complete
+	set -l words (commandline -opc) (commandline -ct)
+	toggl __complete -- $words[2..-1] 2>/dev/null
+end
+complete -c toggl -f -a '(__toggl_complete)'
+`,
+}
+
+func runCompletion(app *app, args []string) error {
+	if len(args) != 1 {
+		return usagef("expected a shell: bash, zsh or fish")
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return usagef("unsupported shell %q", args[0])
+	}
+	fmt.Fprint(app.stdout, script)
+	return nil
+}
+
+// runComplete writes the completions of the last word.
+func runComplete(app *app, args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	for _, c := range app.complete(args) {
+		fmt.Fprintln(app.stdout, c)
+	}
+	return nil
+}
+
+// complete returns the completions of the last of the words following
+// "toggl" on a command line. Names come from the cached account data, so
+// completing never waits for the Toggl API.
+func (app *app) complete(words []string) []string {
+	word := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	// Find the command, skipping global flags and their values
+	flags := app.globalFlags()
+	var cmd *command
+	for len(words) > 0 && cmd == nil {
+		w := words[0]
+		words = words[1:]
+		if !strings.HasPrefix(w, "-") {
+			if cmd = findCommand(w); cmd == nil {
+				return nil
+			}
+			flags = app.flags(cmd)
+			break
+		}
+		if takesValue(flags, w) {
+			if len(words) == 0 {
+				return filterList(app.completeFlag(w), word)
+			}
+			words = words[1:]
+		}
+	}
+
+	// Skip the command's flags
+	for len(words) > 0 && cmd != nil {
+		w := words[0]
+		words = words[1:]
+		if takesValue(flags, w) {
+			if len(words) == 0 {
+				return filterList(app.completeFlag(w), word)
+			}
+			words = words[1:]
+		}
+	}
+
+	if strings.HasPrefix(word, "-") {
+		var names []string
+		flags.VisitAll(func(f *flag.Flag) {
+			names = append(names, "-"+f.Name)
+		})
+		return filter(names, word)
+	}
+	if cmd == nil {
+		var names []string
+		for _, c := range commands {
+			if !c.hidden {
+				names = append(names, c.name)
+			}
+		}
+		return filter(names, word)
+	}
+
+	switch cmd.name {
+	case "start", "switch":
+		switch {
+		case strings.HasPrefix(word, "@"):
+			return filterNames("@", app.completionNames("project"), word)
+		case strings.HasPrefix(word, "#"):
+			return filterNames("#", append(app.completionNames("tag"), "billable"), word)
+		}
+	case "continue", "edit", "delete":
+		return filter(app.completionNames("entry"), word)
+	case "help":
+		return app.complete([]string{word})
+	case "completion":
+		return filter([]string{"bash", "fish", "zsh"}, word)
+	}
+	return nil
+}
+
+// takesValue returns true if a word is a flag of the set that's followed by
+// a value.
+func takesValue(flags *flag.FlagSet, word string) bool {
+	name := strings.TrimLeft(word, "-")
+	if !strings.HasPrefix(word, "-") || strings.Contains(name, "=") {
+		return false
+	}
+	f := flags.Lookup(name)
+	if f == nil {
+		return false
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+// completeFlag returns the values of a flag.
+func (app *app) completeFlag(word string) []string {
+	switch strings.TrimLeft(word, "-") {
+	case "profile":
+		return app.completionNames("profile")
+	case "format":
+		return format.Formats
+	case "workspace":
+		return app.completionNames("workspace")
+	case "project":
+		return app.completionNames("project")
+	case "client":
+		return app.completionNames("client")
+	case "tags":
+		return app.completionNames("tag")
+	case "by":
+		return []string{"day", "week", "month", "workspace", "project", "client", "task", "tag", "description"}
+	case "since", "until":
+		return []string{"today", "yesterday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	case "billable":
+		return []string{"true", "false"}
+	case "to":
+		return export.Formats
+	case "source":
+		return importSources
+	}
+	return nil
+}
+
+// completionNames returns the names of a kind of entity from the cached
+// account data, or the profiles in the configuration file.
+func (app *app) completionNames(kind string) (names []string) {
+	if kind == "profile" {
+		if app.config == nil {
+			return nil
+		}
+		for name := range app.config.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	path, err := toggl.DefaultCachePath()
+	if err != nil {
+		return nil
+	}
+	account, ok := toggl.NewCache(path, 0).Cached()
+	if !ok || (app.apiToken() != "" && account.Data.APIToken != app.apiToken()) {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	switch kind {
+	case "project":
+		for _, p := range account.Data.Projects {
+			if p.IsActive() {
+				add(account.ProjectTitle(p))
+			}
+		}
+	case "client":
+		for _, c := range account.Data.Clients {
+			if c.ServerDeletedAt == nil {
+				add(c.Name)
+			}
+		}
+	case "tag":
+		for _, t := range account.Data.Tags {
+			if t.ServerDeletedAt == nil {
+				add(t.Name)
+			}
+		}
+	case "workspace":
+		for _, w := range account.Data.Workspaces {
+			if w.ServerDeletedAt == nil {
+				add(w.Name)
+			}
+		}
+	case "entry":
+		// Most recent entries first
+		entries := account.Data.TimeEntries
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].StartTime().After(entries[j].StartTime())
+		})
+		for _, e := range entries {
+			if e.ServerDeletedAt == nil {
+				add(strconv.Itoa(e.ID))
+			}
+		}
+		return names
+	}
+	sort.Strings(names)
+	return names
+}
+
+// filter returns the candidates starting with prefix, ignoring case.
+func filter(candidates []string, prefix string) (matches []string) {
+	for _, c := range candidates {
+		if len(c) >= len(prefix) && strings.EqualFold(c[:len(prefix)], prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// filterNames completes a project or tag of a quick entry, a word starting
+// with prefix. The word may have an opening quote, and names are quoted as
+// ParseEntry expects.
+func filterNames(prefix string, names []string, word string) []string {
+	word = strings.Replace(strings.TrimPrefix(word, prefix), `"`, "", -1)
+	matches := filter(names, word)
+	for i, name := range matches {
+		matches[i] = prefix + toggl.QuoteEntryName(name)
+	}
+	return matches
+}
+
+// filterList completes the last item of a comma-separated list.
+func filterList(candidates []string, word string) []string {
+	i := strings.LastIndex(word, ",")
+	if i == -1 {
+		return filter(candidates, word)
+	}
+	return prefixAll(word[:i+1], filter(candidates, word[i+1:]))
+}
+
+func prefixAll(prefix string, names []string) []string {
+	prefixed := make([]string, len(names))
+	for i, n := range names {
+		prefixed[i] = prefix + n
+	}
+	return prefixed
+}
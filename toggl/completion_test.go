@@ -0,0 +1,30 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterNames(t *testing.T) {
+	projects := []string{"Acme Corp/Website", "Acme/API", "Internal"}
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"@", []string{`@"Acme Corp/Website"`, "@Acme/API", "@Internal"}},
+		{"@acme", []string{`@"Acme Corp/Website"`, "@Acme/API"}},
+		{`@"Acme C`, []string{`@"Acme Corp/Website"`}},
+		{`@"Acme Corp/Website"`, []string{`@"Acme Corp/Website"`}},
+		{"@in", []string{"@Internal"}},
+		{"@x", nil},
+	}
+	for _, test := range tests {
+		if got := filterNames("@", projects, test.word); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("filterNames(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+
+	if got, want := filterNames("#", []string{"code review", "bug"}, "#c"), []string{`#"code review"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterNames(#c) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/format"
+	"github.com/Jberlinsky/go-toggl/git"
+	"github.com/Jberlinsky/go-toggl/suggest"
+)
+
+// Environment variables
+const (
+	envToken   = "TOGGL_API_TOKEN"
+	envProfile = "TOGGL_PROFILE"
+)
+
+const defaultProfile = "default"
+
+// profile holds the settings for one Toggl account.
+type profile struct {
+	Token string `json:"token,omitempty"`
+	// Workspace is the ID of the workspace used when none is given.
+	Workspace int `json:"workspace,omitempty"`
+	// Project is the name or ID of the project new entries get when they
+	// don't name one.
+	Project string `json:"project,omitempty"`
+	// Timezone overrides the time zone in the user's Toggl profile.
+	Timezone string `json:"timezone,omitempty"`
+	// Format is the default output format: a format name or a template.
+	Format string `json:"format,omitempty"`
+	// Email is the user's calendar address, used to skip the events they
+	// declined.
+	Email string `json:"email,omitempty"`
+	// Suggest holds the rules matching calendar events to projects.
+	Suggest []suggest.Rule `json:"suggest,omitempty"`
+	// Git configures the entries derived from git repositories.
+	Git *gitSettings `json:"git,omitempty"`
+}
+
+// gitSettings configures "toggl git" and the hooks it installs.
+type gitSettings struct {
+	// Rules match repositories and branches to projects. Without rules,
+	// every branch gets entries without a project.
+	Rules []git.Rule `json:"rules,omitempty"`
+	// Gap and Lead are durations such as "90m", overriding git.DefaultGap
+	// and git.DefaultLead.
+	Gap  string `json:"gap,omitempty"`
+	Lead string `json:"lead,omitempty"`
+	// Issue is a regular expression matching the issue keys in branch
+	// names.
+	Issue string `json:"issue,omitempty"`
+}
+
+// config is the contents of the configuration file.
+type config struct {
+	// Default is the profile used when none is selected.
+	Default  string              `json:"default,omitempty"`
+	Profiles map[string]*profile `json:"profiles"`
+
+	path string
+}
+
+// defaultConfigPath returns the path of the configuration file in the XDG
+// config directory.
+func defaultConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "go-toggl", "config.json"), nil
+}
+
+// loadConfig reads the configuration file. A missing file is an empty
+// configuration.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{Profiles: make(map[string]*profile), path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("Invalid config file %s: %v", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*profile)
+	}
+	return cfg, nil
+}
+
+// save writes the configuration file. The file holds API tokens, so it's only
+// readable by the user.
+func (cfg *config) save() error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(cfg.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".config-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cfg.path)
+}
+
+// profile returns the named profile, creating it if necessary.
+func (cfg *config) profile(name string) *profile {
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		p = &profile{}
+		cfg.Profiles[name] = p
+	}
+	return p
+}
+
+// profileName returns the name of the selected profile: the one given with
+// -profile, in TOGGL_PROFILE, or the configured default.
+func (app *app) profileName() string {
+	switch {
+	case app.profileFlag != "":
+		return app.profileFlag
+	case os.Getenv(envProfile) != "":
+		return os.Getenv(envProfile)
+	case app.config != nil && app.config.Default != "":
+		return app.config.Default
+	}
+	return defaultProfile
+}
+
+// loadProfile reads the configuration file and selects a profile.
+func (app *app) loadProfile() error {
+	path := app.configFlag
+	if path == "" {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	app.config = cfg
+
+	// A missing profile is created by logging in
+	app.profile = &profile{}
+	if p, ok := cfg.Profiles[app.profileName()]; ok {
+		app.profile = p
+	}
+
+	if app.profile.Format != "" {
+		if err := format.Valid(app.profile.Format); err != nil {
+			return fmt.Errorf("Profile %q: %v", app.profileName(), err)
+		}
+	}
+	return nil
+}
+
+// apiToken returns the API token given with -token, in TOGGL_API_TOKEN, or
+// stored in the selected profile.
+func (app *app) apiToken() string {
+	switch {
+	case app.token != "":
+		return app.token
+	case os.Getenv(envToken) != "":
+		return os.Getenv(envToken)
+	}
+	return app.profile.Token
+}
+
+// applyDefaults fills in the profile's default workspace and project for an
+// entry that doesn't have them.
+func (app *app) applyDefaults(entry *toggl.TimeEntry) error {
+	if entry.Pid == 0 && app.profile.Project != "" {
+		pid, err := app.findProject(app.profile.Project)
+		if err != nil {
+			return fmt.Errorf("Default project: %v", err)
+		}
+		entry.Pid = pid
+		for _, p := range app.account.Data.Projects {
+			if p.ID == pid {
+				entry.Wid = p.Wid
+			}
+		}
+	}
+	if entry.Wid == 0 {
+		entry.Wid = app.profile.Workspace
+	}
+	return nil
+}
+
+// login ///////////////////////////////////////////////////////////////
+
+func runLogin(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	fmt.Fprint(app.stderr, "Email: ")
+	username, err := in.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	username = strings.TrimSpace(username)
+
+	fmt.Fprint(app.stderr, "Password: ")
+	password, err := readPassword(in)
+	fmt.Fprintln(app.stderr)
+	if err != nil {
+		return err
+	}
+	if username == "" || password == "" {
+		return fmt.Errorf("Email and password are required")
+	}
+
+	session, err := toggl.NewSession(username, password)
+	if err != nil {
+		return fmt.Errorf("Unable to log in: %v", err)
+	}
+	if session.APIToken == "" {
+		return fmt.Errorf("Unable to log in: no API token received")
+	}
+
+	name := app.profileName()
+	app.config.profile(name).Token = session.APIToken
+	if app.config.Default == "" {
+		app.config.Default = name
+	}
+	if err := app.config.save(); err != nil {
+		return fmt.Errorf("Unable to save token: %v", err)
+	}
+
+	fmt.Fprintf(app.stderr, "Logged in as %s (profile %q)\n", username, name)
+	return nil
+}
+
+func runLogout(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+
+	name := app.profileName()
+	p, ok := app.config.Profiles[name]
+	if !ok || p.Token == "" {
+		return fmt.Errorf("Profile %q isn't logged in", name)
+	}
+
+	p.Token = ""
+	if err := app.config.save(); err != nil {
+		return err
+	}
+
+	// Cached account data belongs to the logged out user
+	if path, err := toggl.DefaultCachePath(); err == nil {
+		if err := toggl.NewCache(path, toggl.DefaultCacheTTL).Clear(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(app.stderr, "Logged out of profile %q\n", name)
+	return nil
+}
+
+// profileInfo describes a profile without its token.
+type profileInfo struct {
+	Name      string `json:"name"`
+	LoggedIn  bool   `json:"logged_in"`
+	Current   bool   `json:"current"`
+	Workspace int    `json:"workspace,omitempty"`
+	Project   string `json:"project,omitempty"`
+	Timezone  string `json:"timezone,omitempty"`
+	Format    string `json:"format,omitempty"`
+}
+
+func runProfiles(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+
+	var names []string
+	for name := range app.config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	infos := []profileInfo{}
+	for _, name := range names {
+		p := app.config.Profiles[name]
+		infos = append(infos, profileInfo{
+			Name:      name,
+			LoggedIn:  p.Token != "",
+			Current:   name == app.profileName(),
+			Workspace: p.Workspace,
+			Project:   p.Project,
+			Timezone:  p.Timezone,
+			Format:    p.Format,
+		})
+	}
+	return app.write(infos)
+}
+
+// readPassword reads a line from the terminal with echo turned off. If stdin
+// isn't a terminal the line is read as is.
+func readPassword(in *bufio.Reader) (string, error) {
+	if err := stty("-echo"); err == nil {
+		defer stty("echo")
+	}
+	line, err := in.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// stty changes the settings of the terminal on stdin.
+func stty(args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jberlinsky/go-toggl"
+)
+
+// testConfig points the XDG directories at a temporary directory and writes
+// a configuration file with a default and a work profile to it.
+func testConfig(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	t.Setenv(envToken, "")
+	t.Setenv(envProfile, "")
+
+	path, err := defaultConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "config", "go-toggl", "config.json"); path != want {
+		t.Errorf("config at %s, want %s", path, want)
+	}
+	cfg := &config{
+		Default: "home",
+		Profiles: map[string]*profile{
+			"home": {Token: "home-token", Workspace: 1},
+			"work": {Token: "work-token", Workspace: 2, Project: "Website"},
+		},
+		path: path,
+	}
+	if err := cfg.save(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProfileSelection(t *testing.T) {
+	tests := []struct {
+		name            string
+		flag, env       string
+		token, envToken string
+		profile         string
+		workspace       int
+		wantToken       string
+		removeDefault   bool
+	}{
+		{name: "configured default", profile: "home", workspace: 1, wantToken: "home-token"},
+		{name: "no default", removeDefault: true, profile: defaultProfile, wantToken: ""},
+		{name: "environment", env: "work", profile: "work", workspace: 2, wantToken: "work-token"},
+		{name: "flag over environment", flag: "home", env: "work", profile: "home", workspace: 1, wantToken: "home-token"},
+		{name: "missing profile", flag: "new", profile: "new", wantToken: ""},
+		{name: "token in environment", env: "work", envToken: "env-token", profile: "work", workspace: 2, wantToken: "env-token"},
+		{name: "token flag", token: "flag-token", envToken: "env-token", profile: "home", workspace: 1, wantToken: "flag-token"},
+	}
+
+	for _, test := range tests {
+		path := testConfig(t)
+		if test.removeDefault {
+			cfg, _ := loadConfig(path)
+			cfg.Default = ""
+			cfg.save()
+		}
+		t.Setenv(envProfile, test.env)
+		t.Setenv(envToken, test.envToken)
+
+		app := &app{profileFlag: test.flag, token: test.token}
+		if err := app.loadProfile(); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := app.profileName(); got != test.profile {
+			t.Errorf("%s: profile %q, want %q", test.name, got, test.profile)
+		}
+		if app.profile.Workspace != test.workspace {
+			t.Errorf("%s: workspace %d, want %d", test.name, app.profile.Workspace, test.workspace)
+		}
+		if got := app.apiToken(); got != test.wantToken {
+			t.Errorf("%s: token %q, want %q", test.name, got, test.wantToken)
+		}
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	path := testConfig(t)
+	for _, p := range []string{path, filepath.Dir(path)} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := os.FileMode(0600)
+		if info.IsDir() {
+			want = 0700
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s has mode %v, want %v", p, info.Mode().Perm(), want)
+		}
+	}
+
+	// Saving again keeps the file private, even if it was made readable
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Default != "home" || cfg.Profiles["work"].Project != "Website" {
+		t.Errorf("loaded %+v", cfg)
+	}
+	cfg.profile("other").Workspace = 3
+	if err := cfg.save(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("saved over a readable file: %v, %v", info.Mode(), err)
+	}
+	if files, _ := ioutil.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Errorf("%d files in the config directory", len(files))
+	}
+
+	if cfg, err := loadConfig(filepath.Join(filepath.Dir(path), "missing.json")); err != nil || len(cfg.Profiles) != 0 {
+		t.Errorf("missing config = %+v, %v", cfg, err)
+	}
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path); err == nil {
+		t.Error("loaded an invalid config")
+	}
+}
+
+func TestInvalidProfileFormat(t *testing.T) {
+	path := testConfig(t)
+	cfg, _ := loadConfig(path)
+	cfg.profile("home").Format = "{{.Description"
+	if err := cfg.save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&app{}).loadProfile(); err == nil {
+		t.Error("loaded a profile with an invalid format")
+	}
+}
+
+func TestLogout(t *testing.T) {
+	path := testConfig(t)
+	cachePath, err := toggl.DefaultCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(cachePath, []byte(`{"account": {"data": {"id": 1, "api_token": "work-token"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(envProfile, "work")
+	app := &app{stderr: ioutil.Discard}
+	if err := app.loadProfile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := runLogout(app, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profiles["work"].Token != "" || cfg.Profiles["home"].Token != "home-token" {
+		t.Errorf("tokens after logging out of work: %q and %q", cfg.Profiles["work"].Token, cfg.Profiles["home"].Token)
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Errorf("cache after logging out: %v", err)
+	}
+	if err := runLogout(app, nil); err == nil {
+		t.Error("logged out twice")
+	}
+}
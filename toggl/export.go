@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/Jberlinsky/go-toggl/export"
+)
+
+// Flags of the export command
+// exportFlags are the flags of the export command.
+type exportFlags struct {
+	format, calendar string
+}
+
+func setupExport(app *app, flags *flag.FlagSet) {
+	setupRange(app, flags)
+	flags.StringVar(&app.export.format, "to", export.ICal, "`format` to write: "+strings.Join(export.Formats, ", "))
+	flags.StringVar(&app.export.calendar, "calendar", "Toggl", "`name` of the iCalendar calendar")
+}
+
+func runExport(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	known := false
+	for _, f := range export.Formats {
+		known = known || f == app.export.format
+	}
+	if !known {
+		return usagef("unknown format %q; formats are %s", app.export.format, strings.Join(export.Formats, ", "))
+	}
+
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+	entries, err := app.entriesInRange()
+	if err != nil {
+		return err
+	}
+	session, _ := app.getSession()
+
+	return export.WriteAll(app.export.format, app.stdout, export.FromTimeEntries(account, entries), export.Options{
+		Location:     session.Location(),
+		CalendarName: app.export.calendar,
+	})
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/format"
+	"github.com/Jberlinsky/go-toggl/git"
+)
+
+func init() {
+	format.Register(git.Proposal{}, nil,
+		format.Column{Name: "start", Value: func(item interface{}, o *format.Options) string {
+			return sessionStart(item.(git.Proposal).Session, o)
+		}},
+		format.Column{Name: "duration", Value: func(item interface{}, o *format.Options) string {
+			return toggl.FormatDuration(item.(git.Proposal).Session.Duration(), o.Durations)
+		}},
+		format.Column{Name: "repo", Value: func(item interface{}, o *format.Options) string {
+			return item.(git.Proposal).Session.Repo
+		}},
+		format.Column{Name: "commits", Value: func(item interface{}, o *format.Options) string {
+			return fmt.Sprint(len(item.(git.Proposal).Session.Commits))
+		}},
+		format.Column{Name: "project", Value: func(item interface{}, o *format.Options) string {
+			return item.(git.Proposal).Project
+		}},
+		format.Column{Name: "description", Value: func(item interface{}, o *format.Options) string {
+			return item.(git.Proposal).Entry.Description
+		}},
+		format.Column{Name: "tags", Value: func(item interface{}, o *format.Options) string {
+			return strings.Join(item.(git.Proposal).Entry.Tags, ",")
+		}},
+	)
+	format.Register(git.Skipped{}, nil,
+		format.Column{Name: "start", Value: func(item interface{}, o *format.Options) string {
+			return sessionStart(item.(git.Skipped).Session, o)
+		}},
+		format.Column{Name: "duration", Value: func(item interface{}, o *format.Options) string {
+			return toggl.FormatDuration(item.(git.Skipped).Session.Duration(), o.Durations)
+		}},
+		format.Column{Name: "repo", Value: func(item interface{}, o *format.Options) string {
+			return item.(git.Skipped).Session.Repo
+		}},
+		format.Column{Name: "branch", Value: func(item interface{}, o *format.Options) string {
+			return item.(git.Skipped).Session.Branch
+		}},
+		format.Column{Name: "reason", Value: func(item interface{}, o *format.Options) string {
+			return item.(git.Skipped).Reason
+		}},
+	)
+}
+
+func sessionStart(s git.Session, o *format.Options) string {
+	start := s.Start
+	if o.Location != nil {
+		start = start.In(o.Location)
+	}
+	return start.Format("2006-01-02 15:04")
+}
+
+// gitFlags are the flags of the git command.
+type gitFlags struct {
+	gap, lead, author, rules string
+	skipped, create, ask     bool
+	installHook, removeHook  bool
+}
+
+func setupGit(app *app, flags *flag.FlagSet) {
+	setupRange(app, flags)
+	flags.StringVar(&app.git.gap, "gap", "", "longest `duration` between the commits of a session; defaults to the profile's, or 2h")
+	flags.StringVar(&app.git.lead, "lead", "", "`duration` of work before a session's first commit; defaults to the profile's, or 30m")
+	flags.StringVar(&app.git.author, "author", "", "only the commits of authors matching a `pattern`; defaults to each repository's user.email, and . means everyone")
+	flags.StringVar(&app.git.rules, "rules", "", "JSON `file` of rules matching repositories and branches to projects; defaults to the profile's")
+	flags.BoolVar(&app.git.skipped, "skipped", false, "list the sessions without proposed entries, and why")
+	flags.BoolVar(&app.git.create, "create", false, "create the proposed entries")
+	flags.BoolVar(&app.git.ask, "ask", false, "ask before creating each proposed entry")
+	flags.BoolVar(&app.git.installHook, "install-hook", false, "install a hook switching the timer when a branch is checked out")
+	flags.BoolVar(&app.git.removeHook, "remove-hook", false, "remove the hook installed with -install-hook")
+}
+
+func runGit(app *app, args []string) error {
+	repos := args
+	if len(repos) == 0 {
+		repos = []string{"."}
+	}
+	switch {
+	case app.git.installHook && app.git.removeHook:
+		return usagef("-install-hook and -remove-hook can't be combined")
+	case app.git.installHook:
+		return app.installHooks(repos)
+	case app.git.removeHook:
+		for _, dir := range repos {
+			path, err := git.RemoveHook(dir)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(app.stderr, "Removed %s\n", path)
+		}
+		return nil
+	}
+
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+	session, _ := app.getSession()
+	start, end, err := app.dateRange()
+	if err != nil {
+		return err
+	}
+	rules, options, err := app.gitSettings()
+	if err != nil {
+		return err
+	}
+
+	var commits []git.Commit
+	for _, dir := range repos {
+		author := app.git.author
+		if author == "" {
+			if author, err = git.UserEmail(dir); err != nil {
+				return err
+			}
+		}
+		log, err := git.Log(dir, git.LogOptions{Since: start, Until: end, Author: author})
+		if err != nil {
+			return err
+		}
+		commits = append(commits, log...)
+	}
+
+	existing, err := existingEntries(session, start, end)
+	if err != nil {
+		return err
+	}
+	result, err := git.Propose(account, git.Sessions(commits, options), existing, rules, options)
+	if err != nil {
+		return err
+	}
+
+	if app.git.skipped {
+		return app.write(result.Skipped)
+	}
+	if !app.git.create && !app.git.ask {
+		if err := app.write(result.Proposals); err != nil {
+			return err
+		}
+		if !app.structured() && len(result.Proposals) > 0 {
+			fmt.Fprintln(app.stdout, "\nRun with -create to create these entries, or -ask to choose them.")
+		}
+		return nil
+	}
+
+	entries := result.Entries()
+	if app.git.ask {
+		entries = app.chooseEntries(account, entries)
+	}
+	return app.createEntries(session, entries)
+}
+
+// gitSettings returns the rules given by -rules, or the profile's, and the
+// options given by flags or the profile.
+func (app *app) gitSettings() ([]git.Rule, git.Options, error) {
+	var options git.Options
+	settings := &gitSettings{}
+	if app.profile != nil && app.profile.Git != nil {
+		settings = app.profile.Git
+	}
+	options.Issue = settings.Issue
+	if wid, err := app.findWorkspace(""); err == nil {
+		options.Workspace = wid
+	}
+
+	gap, lead := settings.Gap, settings.Lead
+	if app.git.gap != "" {
+		gap = app.git.gap
+	}
+	if app.git.lead != "" {
+		lead = app.git.lead
+	}
+	if gap != "" {
+		d, err := toggl.ParseDuration(gap)
+		if err != nil {
+			return nil, options, fmt.Errorf("Gap: %v", err)
+		}
+		options.Gap = d
+	}
+	if lead != "" {
+		d, err := toggl.ParseDuration(lead)
+		if err != nil {
+			return nil, options, fmt.Errorf("Lead: %v", err)
+		}
+		options.Lead = d
+		if d == 0 {
+			// A lead of zero is no lead, not the default
+			options.Lead = -1
+		}
+	}
+
+	rules := settings.Rules
+	if app.git.rules != "" {
+		file, err := os.Open(app.git.rules)
+		if err != nil {
+			return nil, options, err
+		}
+		defer file.Close()
+		if rules, err = git.ParseRules(file); err != nil {
+			return nil, options, fmt.Errorf("%s: %v", app.git.rules, err)
+		}
+	}
+	if len(rules) == 0 {
+		// Without rules, every branch gets entries without a project
+		rules = []git.Rule{{}}
+	}
+	return rules, options, nil
+}
+
+// installHooks installs hooks in repositories that run "toggl git-checkout"
+// with the selected profile.
+func (app *app) installHooks(repos []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "toggl"
+	}
+	command := []string{exe}
+	if app.configFlag != "" {
+		path, err := filepath.Abs(app.configFlag)
+		if err != nil {
+			return err
+		}
+		command = append(command, "-config", path)
+	}
+	if app.profileFlag != "" {
+		command = append(command, "-profile", app.profileFlag)
+	}
+	command = append(command, "git-checkout")
+
+	for _, dir := range repos {
+		path, err := git.InstallHook(dir, command...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(app.stderr, "Installed %s\n", path)
+	}
+	return nil
+}
+
+// runGitCheckout is run by the post-checkout hook, and switches the running
+// timer, if any, to the entry for the branch checked out.
+func runGitCheckout(app *app, args []string) error {
+	branch, err := git.CurrentBranch(".")
+	if err != nil || branch == "" {
+		return err
+	}
+	root, err := git.TopLevel(".")
+	if err != nil {
+		return err
+	}
+
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+	rules, options, err := app.gitSettings()
+	if err != nil {
+		return err
+	}
+	entry, ok, err := git.EntryFor(account, filepath.Base(root), branch, rules, options)
+	if err != nil || !ok {
+		return err
+	}
+
+	session, _ := app.getSession()
+	tracker := toggl.NewTracker(session)
+	current, err := tracker.Current()
+	if err != nil {
+		return err
+	}
+	// Only a running timer is switched, so that checkouts while not working,
+	// such as by scripts, don't start one
+	if current == nil || (current.Description == entry.Description && current.Pid == entry.Pid) {
+		return nil
+	}
+	if _, err := tracker.Switch(entry); err != nil {
+		return err
+	}
+	fmt.Fprintf(app.stderr, "Switched the timer to %s\n", account.FormatEntry(entry, time.Now()))
+	return nil
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/format"
+	"github.com/Jberlinsky/go-toggl/importer"
+)
+
+func init() {
+	format.Register(importer.Item{}, nil,
+		format.Column{Name: "line", Value: func(item interface{}, o *format.Options) string {
+			if line := item.(importer.Item).Record.Line; line != 0 {
+				return fmt.Sprint(line)
+			}
+			return ""
+		}},
+		format.Column{Name: "action", Value: func(item interface{}, o *format.Options) string {
+			return item.(importer.Item).Action
+		}},
+		format.Column{Name: "start", Value: func(item interface{}, o *format.Options) string {
+			r := item.(importer.Item).Record
+			if r.Start.IsZero() {
+				return ""
+			}
+			start := r.Start
+			if o.Location != nil {
+				start = start.In(o.Location)
+			}
+			if r.DurationOnly {
+				return start.Format("2006-01-02")
+			}
+			return start.Format("2006-01-02 15:04")
+		}},
+		format.Column{Name: "duration", Value: func(item interface{}, o *format.Options) string {
+			if d := item.(importer.Item).Record.Duration; d != 0 {
+				return toggl.FormatDuration(d, o.Durations)
+			}
+			return ""
+		}},
+		format.Column{Name: "project", Value: func(item interface{}, o *format.Options) string {
+			return item.(importer.Item).Project
+		}},
+		format.Column{Name: "description", Value: func(item interface{}, o *format.Options) string {
+			return item.(importer.Item).Record.Description
+		}},
+		format.Column{Name: "tags", Value: func(item interface{}, o *format.Options) string {
+			return strings.Join(item.(importer.Item).Entry.Tags, ",")
+		}},
+		format.Column{Name: "reason", Value: func(item interface{}, o *format.Options) string {
+			return item.(importer.Item).Reason
+		}},
+	)
+}
+
+// importSources are the values of -source.
+var importSources = []string{"csv", "harvest", "clockify", "timewarrior"}
+
+// importFlags are the flags of the import command.
+type importFlags struct {
+	columns, dateFormat, timeFormat string
+	durationFormat, timezone, comma string
+	progress, source, rules         string
+	create, dryRun, skipErrors      bool
+	batch                           int
+}
+
+func setupImport(app *app, flags *flag.FlagSet) {
+	flags.StringVar(&app.imports.source, "source", "csv", "`format` of the file: csv, harvest, clockify or timewarrior")
+	flags.StringVar(&app.imports.rules, "rules", "", "`file` of rules mapping the file's project, client and tag names to Toggl's")
+	flags.StringVar(&app.imports.columns, "map", "", "comma-separated `FIELD=HEADER` pairs naming the columns of fields: "+strings.Join(importer.Fields, ", "))
+	flags.StringVar(&app.imports.dateFormat, "date-format", "", "`layout` of dates, as in Go's time package; defaults to 2006-01-02, or the source's")
+	flags.StringVar(&app.imports.timeFormat, "time-format", "", "`layout` of start and stop times; defaults to 15:04, or the source's")
+	flags.StringVar(&app.imports.durationFormat, "duration-format", "", "`unit` of durations given as plain numbers: seconds, minutes or hours; by default 1:30, 1.5 and 1h30m are all read")
+	flags.StringVar(&app.imports.timezone, "timezone", "", "time `zone` of the file's times; defaults to the profile's")
+	flags.StringVar(&app.imports.comma, "comma", ",", "field `separator`; \"tab\" for tabs")
+	flags.StringVar(&app.workspace, "workspace", "", "`workspace` of entries that don't name one; defaults to the profile's")
+	flags.BoolVar(&app.imports.create, "create", false, "create missing projects, clients and tags")
+	flags.BoolVar(&app.imports.dryRun, "dry-run", false, "show what would be imported without changing anything")
+	flags.BoolVar(&app.imports.skipErrors, "skip-errors", false, "import the valid entries even if others can't be imported")
+	flags.IntVar(&app.imports.batch, "batch", importer.DefaultBatchSize, "number of entries created between pauses")
+	flags.StringVar(&app.imports.progress, "progress", "", "progress log `file` used to resume an import; defaults to FILE.progress")
+}
+
+func runImport(app *app, args []string) error {
+	if len(args) != 1 {
+		return usagef("expected one file")
+	}
+	path := args[0]
+
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+	session, _ := app.getSession()
+
+	options, err := app.csvOptions(session.Location())
+	if err != nil {
+		return err
+	}
+	wid, err := app.findWorkspace(app.workspace)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := app.openReader(file, options)
+	if err != nil {
+		return err
+	}
+	records, invalid, err := importer.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	rules, err := readRules(app.imports.rules)
+	if err != nil {
+		return err
+	}
+
+	if app.imports.progress == "" {
+		app.imports.progress = path + ".progress"
+	}
+	var progress *importer.Progress
+	planOptions := importer.Options{Workspace: wid, Create: app.imports.create, Rules: rules}
+	if _, err := os.Stat(app.imports.progress); err == nil || !app.imports.dryRun {
+		if progress, err = importer.OpenProgress(app.imports.progress); err != nil {
+			return err
+		}
+		defer progress.Close()
+		planOptions.Imported = progress.Imported
+	}
+
+	var existing []toggl.TimeEntry
+	if len(records) > 0 {
+		start, end := importer.Range(records)
+		if existing, err = session.GetAllTimeEntries(start.Add(-time.Minute), end.Add(time.Minute)); err != nil {
+			return err
+		}
+	}
+
+	plan := importer.NewPlan(*account, existing, records, planOptions)
+	for _, e := range invalid {
+		plan.Items = append(plan.Items, importer.Item{
+			Record: importer.Record{Line: e.Line},
+			Action: importer.ActionError,
+			Reason: e.Err.Error(),
+		})
+	}
+
+	errors := plan.Count(importer.ActionError)
+	if app.imports.dryRun {
+		if err := app.write(plan.Items); err != nil {
+			return err
+		}
+		if !app.structured() {
+			app.printPlan(plan)
+		}
+		return nil
+	}
+
+	if errors > 0 && !app.imports.skipErrors {
+		var failed []importer.Item
+		for _, item := range plan.Items {
+			if item.Action == importer.ActionError {
+				failed = append(failed, item)
+			}
+		}
+		if err := app.write(failed); err != nil {
+			return err
+		}
+		if !app.structured() {
+			app.printUnmapped(plan)
+		}
+		return fmt.Errorf("%d entries can't be imported; fix them, or use -skip-errors to import the others", errors)
+	}
+
+	// Stop between requests when interrupted, so the progress log is
+	// accurate
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	total, done, counting := plan.Count(importer.ActionCreate), 0, false
+	im := &importer.Importer{
+		Session:   session,
+		Progress:  progress,
+		BatchSize: app.imports.batch,
+		OnItem: func(item importer.Item, created toggl.TimeEntry, err error) {
+			done++
+			if err != nil {
+				if counting {
+					fmt.Fprintln(app.stderr)
+				}
+				fmt.Fprintf(app.stderr, "line %d: %v\n", item.Record.Line, err)
+				counting = false
+				return
+			}
+			fmt.Fprintf(app.stderr, "\rImported %d of %d", done, total)
+			counting = true
+		},
+	}
+	result, err := im.Apply(ctx, plan)
+	if counting {
+		fmt.Fprintln(app.stderr)
+	}
+	if err != nil {
+		return fmt.Errorf("%v; run the import again to resume it", err)
+	}
+
+	fmt.Fprintf(app.stdout, "Created %d entries, %d clients, %d projects and %d tags",
+		result.Created, result.Clients, result.Projects, result.Tags)
+	if n := plan.Count(importer.ActionDuplicate) + plan.Count(importer.ActionImported); n > 0 {
+		fmt.Fprintf(app.stdout, "; skipped %d already in Toggl", n)
+	}
+	fmt.Fprintln(app.stdout)
+	if result.Failed > 0 || errors > 0 {
+		return fmt.Errorf("%d entries weren't imported", result.Failed+errors)
+	}
+	return nil
+}
+
+// openReader returns a reader of the format given by -source.
+func (app *app) openReader(file io.Reader, options importer.CSVOptions) (importer.Reader, error) {
+	switch app.imports.source {
+	case "csv":
+		return importer.NewCSVReader(file, options)
+	case "harvest":
+		return importer.NewHarvestReader(file, options)
+	case "clockify":
+		return importer.NewClockifyReader(file, options)
+	case "timewarrior", "timew":
+		return importer.NewTimewarriorReader(file)
+	}
+	return nil, usagef("unknown source %q; sources are csv, harvest, clockify and timewarrior", app.imports.source)
+}
+
+// readRules reads the rules file given by -rules.
+func readRules(path string) (importer.Rules, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	rules, err := importer.ParseRules(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return rules, nil
+}
+
+// csvOptions returns the CSV layout given by the import flags.
+func (app *app) csvOptions(loc *time.Location) (importer.CSVOptions, error) {
+	options := importer.CSVOptions{
+		Columns:        make(map[string]string),
+		DateFormat:     app.imports.dateFormat,
+		TimeFormat:     app.imports.timeFormat,
+		DurationFormat: app.imports.durationFormat,
+		Location:       loc,
+	}
+
+	if app.imports.timezone != "" {
+		tz, err := time.LoadLocation(app.imports.timezone)
+		if err != nil {
+			return options, usagef("invalid time zone %q", app.imports.timezone)
+		}
+		options.Location = tz
+	}
+
+	switch app.imports.comma {
+	case "tab", `\t`:
+		options.Comma = '\t'
+	default:
+		r, size := utf8.DecodeRuneInString(app.imports.comma)
+		if size == 0 || size != len(app.imports.comma) {
+			return options, usagef("the separator must be a single character")
+		}
+		options.Comma = r
+	}
+
+	for _, pair := range strings.Split(app.imports.columns, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		i := strings.Index(pair, "=")
+		if i == -1 {
+			return options, usagef("invalid column mapping %q; expected FIELD=HEADER", pair)
+		}
+		options.Columns[strings.TrimSpace(pair[:i])] = strings.TrimSpace(pair[i+1:])
+	}
+	return options, nil
+}
+
+// printPlan summarizes what an import will do.
+func (app *app) printPlan(plan *importer.Plan) {
+	fmt.Fprintf(app.stdout, "\n%d to create, %d duplicates, %d already imported, %d errors\n",
+		plan.Count(importer.ActionCreate), plan.Count(importer.ActionDuplicate),
+		plan.Count(importer.ActionImported), plan.Count(importer.ActionError))
+
+	var clients, projects, tags []string
+	for _, c := range plan.Clients {
+		clients = append(clients, c.Name)
+	}
+	for _, p := range plan.Projects {
+		projects = append(projects, p.Title())
+	}
+	for _, t := range plan.Tags {
+		tags = append(tags, t.Name)
+	}
+	if len(clients) > 0 {
+		fmt.Fprintf(app.stdout, "New clients: %s\n", strings.Join(clients, ", "))
+	}
+	if len(projects) > 0 {
+		fmt.Fprintf(app.stdout, "New projects: %s\n", strings.Join(projects, ", "))
+	}
+	if len(tags) > 0 {
+		fmt.Fprintf(app.stdout, "New tags: %s\n", strings.Join(tags, ", "))
+	}
+	app.printUnmapped(plan)
+}
+
+// printUnmapped lists the names of a plan that match nothing in Toggl.
+func (app *app) printUnmapped(plan *importer.Plan) {
+	if len(plan.Unmapped) == 0 {
+		return
+	}
+	fmt.Fprintln(app.stdout, "Names matching nothing in Toggl; map them with -rules, or create them with -create:")
+	for _, u := range plan.Unmapped {
+		entries := "entries"
+		if u.Records == 1 {
+			entries = "entry"
+		}
+		fmt.Fprintf(app.stdout, "    %s %s (%d %s)\n", u.Kind, u.Name, u.Records, entries)
+	}
+}
@@ -1,36 +1,371 @@
 /*
 
-The toggl command will display a user's Toggl account information.
+The toggl command manages a user's Toggl timers and time entries.
 
 Usage:
-    toggl API_TOKEN
+    toggl [-profile NAME] [-token API_TOKEN] [-format FORMAT] [-debug] COMMAND [FLAGS] [ARGS]
 
-The API token can be retrieved from a user's account information page at toggl.com.
+Commands:
+    start [ENTRY]          start a timer, or record a completed entry
+    stop                   stop the running timer
+    current                show the running timer
+    continue [ID]          start a copy of an entry, by default the latest
+    switch [ENTRY]         stop the running timer and start another
+    edit ID                change an entry
+    delete ID...           delete entries
+    list                   list the entries of a time range
+    projects               list projects
+    clients                list clients
+    tags                   list tags
+    workspaces             list workspaces
+    report                 total the entries of a time range
+    import FILE            import entries from CSV, Harvest, Clockify or Timewarrior files
+    export                 write entries as iCalendar, Timewarrior or timeclock
+    suggest FILE           suggest entries for the events of an iCalendar file
+    git [REPO...]          propose entries for the commits of git repositories
+    tui                    manage timers and entries interactively
+    login                  log in and store the API token in a profile
+    logout                 remove the API token from a profile
+    profiles               list profiles
+    completion SHELL       write a bash, zsh or fish completion script
+
+Entries are written in quick entry syntax, for example:
+    toggl start Fix login bug @Acme/Website #bug
+    toggl start yesterday 14:00-15:30 Review @Website #billable
+
+"toggl tui" shows the running timer and this week's entries, and starts,
+stops, continues, edits and deletes entries with single keys; press ? in it
+for the list of keys.
+
+Projects, clients, tags and workspaces are given by name. A project can be
+prefixed with its client's name, as in Acme/Website. A name given to a flag
+that matches nothing exactly is matched fuzzily, so -project web finds
+Acme/Website unless another project matches as well; in quick entries, @web
+lists the projects it matches instead of picking one. Tags are matched
+exactly, ignoring case, so that new tags can be added. Shell completion,
+including the names of projects, tags and workspaces from the local cache, is
+set up with:
+    source <(toggl completion bash)
+
+"toggl import" reads CSV files with common column names, or the layout given
+with -map, and the exports of Harvest, Clockify and Timewarrior, selected with
+-source. -rules names a file mapping their names to Toggl's, with lines like:
+    project Website Redesign = Acme/Website
+    tag acme-* = project Acme/Support
+-dry-run shows what would be imported and which names match nothing.
+"toggl export -to ical" writes entries as calendar events, and -to
+timewarrior and -to timeclock write them for Timewarrior and ledger.
+
+"toggl suggest calendar.ics" suggests entries for the meetings of a calendar
+that no entry covers yet, and creates them with -create or, one by one, with
+-ask. Events are matched to projects by the rules in the profile's "suggest"
+setting, tried in order, by organizer, title and the attendees' domains:
+    "email": "ann@example.com",
+    "suggest": [
+      {"domains": ["acme.com"], "project": "Acme/Website"},
+      {"title": "(?i)standup", "project": "Internal", "tags": ["meeting"]},
+      {"title": "(?i)lunch", "ignore": true}
+    ]
+
+"toggl git" clusters the commits in the current repository, or those given,
+into sessions of commits on a branch at most -gap apart, each starting -lead
+before its first commit, and proposes entries for those no entry covers yet.
+Repositories and branches are matched to projects by the rules in the
+profile's "git" setting, and issue keys in branch names, like ABC-123 in
+feature/ABC-123-login, become descriptions:
+    "git": {
+      "gap": "2h", "lead": "30m",
+      "rules": [
+        {"repo": "website", "project": "Acme/Website", "tags": ["dev"]},
+        {"branch": "hotfix/*", "description": "Hotfix {issue}", "project": "Support"},
+        {"repo": "dotfiles", "ignore": true}
+      ]
+    }
+"toggl git -install-hook" installs a post-checkout hook that switches the
+running timer to the entry of the branch checked out. Checkouts while no timer
+is running leave it stopped.
+
+Lists are written as tables by default. For use in scripts, -format selects
+csv, tsv, json, ndjson or yaml output, or a Go template executed for every
+item, such as '{{.Description}} {{duration .}}'; -json is short for
+-format json. -columns selects and limits the width of columns, as in
+-columns start,duration,description:40. The exit status is 0 on success, 1
+on errors and 2 on usage errors. Run "toggl help COMMAND" for the flags of a
+command.
+
+Settings are read from $XDG_CONFIG_HOME/go-toggl/config.json, which holds
+named profiles:
+    {
+      "default": "work",
+      "profiles": {
+        "work": {"token": "...", "workspace": 123, "project": "Acme/Website",
+                 "timezone": "Europe/Berlin", "format": "table"}
+      }
+    }
+
+The profile is selected with -profile, the TOGGL_PROFILE environment variable
+or the file's default. The API token is taken from -token, the
+TOGGL_API_TOKEN environment variable or the profile, where "toggl login"
+stores it. The API token can also be retrieved from a user's account
+information page at toggl.com.
 
 */
 package main
 
 import (
-	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/jason0x43/go-toggl"
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/format"
 )
 
+// Exit statuses
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a toggl subcommand.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// hidden commands aren't listed in the usage.
+	hidden bool
+	// setup registers the command's flags, bound to fields of the app.
+	setup func(app *app, flags *flag.FlagSet)
+	run   func(app *app, args []string) error
+}
+
+// usageError is an error in the way the command was invoked.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// app holds the state shared by commands.
+type app struct {
+	token       string
+	json        bool
+	format      string
+	columns     string
+	debug       bool
+	profileFlag string
+	configFlag  string
+
+	config  *config
+	profile *profile
+
+	// Flags of commands
+	since, until string
+	workspace    string
+	clientName   string
+	showAll      bool
+	groupBy      string
+	edit         editFlags
+	imports      importFlags
+	export       exportFlags
+	suggest      suggestFlags
+	git          gitFlags
+
+	stdout  io.Writer
+	stderr  io.Writer
+	session *toggl.Session
+	account *toggl.Account
+}
+
 func main() {
-	if len(os.Args) != 2 {
-		println("usage:", os.Args[0], "API_TOKEN")
-		return
+	app := &app{stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(app.main(os.Args[1:]))
+}
+
+func (app *app) main(args []string) int {
+	flags := app.globalFlags()
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		app.usage(app.stdout)
+		return exitOK
+	} else if err != nil {
+		fmt.Fprintf(app.stderr, "toggl: %v\n", err)
+		app.usage(app.stderr)
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		app.usage(app.stderr)
+		return exitUsage
+	}
+
+	if err := app.loadProfile(); err != nil {
+		fmt.Fprintf(app.stderr, "toggl: %v\n", err)
+		return exitError
+	}
+
+	if app.debug {
+		toggl.EnableLog()
+	} else {
+		toggl.DisableLog()
+	}
+
+	name := flags.Arg(0)
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(app.stderr, "toggl: unknown command %q\n", name)
+		app.usage(app.stderr)
+		return exitUsage
 	}
 
-	session := toggl.OpenSession(os.Args[1])
+	err := app.runCommand(cmd, flags.Args()[1:])
+	switch err.(type) {
+	case nil:
+		return exitOK
+	case *usageError:
+		fmt.Fprintf(app.stderr, "toggl %s: %v\n", cmd.name, err)
+		fmt.Fprintln(app.stderr, strings.TrimSpace("usage: toggl "+cmd.name+" "+cmd.args))
+		return exitUsage
+	}
+	fmt.Fprintf(app.stderr, "toggl %s: %v\n", cmd.name, err)
+	return exitError
+}
+
+// runCommand parses a command's flags and runs it.
+func (app *app) runCommand(cmd *command, args []string) error {
+	flags := app.flags(cmd)
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		app.commandUsage(app.stdout, cmd)
+		return nil
+	} else if err != nil {
+		return usagef("%v", err)
+	}
+	if err := format.Valid(app.outputFormat()); err != nil {
+		return usagef("%v", err)
+	}
+	return cmd.run(app, flags.Args())
+}
+
+// globalFlags returns the flags given before the command.
+func (app *app) globalFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("toggl", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.StringVar(&app.profileFlag, "profile", "", "configuration profile")
+	flags.StringVar(&app.configFlag, "config", "", "configuration file")
+	flags.StringVar(&app.token, "token", "", "Toggl API token")
+	flags.BoolVar(&app.json, "json", false, "write output as JSON; the same as -format json")
+	flags.StringVar(&app.format, "format", "", "output `format`: table, csv, tsv, json, ndjson, yaml or a Go template")
+	flags.StringVar(&app.columns, "columns", "", "comma-separated `columns` to write, each optionally followed by :WIDTH")
+	flags.BoolVar(&app.debug, "debug", false, "log API requests to stderr")
+	return flags
+}
 
+// flags returns the flag set of a command. Every command accepts the output
+// flags.
+func (app *app) flags(cmd *command) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.BoolVar(&app.json, "json", app.json, "write output as JSON; the same as -format json")
+	flags.StringVar(&app.format, "format", app.format, "output `format`: table, csv, tsv, json, ndjson, yaml or a Go template")
+	flags.StringVar(&app.columns, "columns", app.columns, "comma-separated `columns` to write, each optionally followed by :WIDTH")
+	if cmd.setup != nil {
+		cmd.setup(app, flags)
+	}
+	return flags
+}
+
+func (app *app) usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: toggl [-profile NAME] [-token API_TOKEN] [-format FORMAT] [-debug] COMMAND [FLAGS] [ARGS]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		if cmd.hidden {
+			continue
+		}
+		fmt.Fprintf(w, "    %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "toggl help COMMAND" for the flags of a command.`)
+}
+
+func (app *app) commandUsage(w io.Writer, cmd *command) {
+	fmt.Fprintln(w, strings.TrimSpace("usage: toggl "+cmd.name+" "+cmd.args))
+	fmt.Fprintf(w, "\n%s\n", cmd.summary)
+
+	flags := app.flags(cmd)
+	var lines []string
+	flags.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		line := "    -" + f.Name
+		if name != "" {
+			line += " " + name
+		}
+		lines = append(lines, fmt.Sprintf("%-24s %s", line, usage))
+	})
+	if len(lines) > 0 {
+		fmt.Fprintf(w, "\nFlags:\n%s\n", strings.Join(lines, "\n"))
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// getSession returns a session for the selected API token.
+func (app *app) getSession() (*toggl.Session, error) {
+	if app.session != nil {
+		return app.session, nil
+	}
+	token := app.apiToken()
+	if token == "" {
+		return nil, fmt.Errorf("Profile %q isn't logged in; run \"toggl login\", or use -token or %s", app.profileName(), envToken)
+	}
+
+	session := toggl.OpenSession(token)
+	if path, err := toggl.DefaultCachePath(); err == nil {
+		session.UseCache(toggl.NewCache(path, toggl.DefaultCacheTTL))
+	}
+	app.session = &session
+	return app.session, nil
+}
+
+// getAccount returns the user's account, which also sets the session's time
+// zone to the one in the user's Toggl profile or the configuration profile.
+func (app *app) getAccount() (*toggl.Account, error) {
+	if app.account != nil {
+		return app.account, nil
+	}
+	session, err := app.getSession()
+	if err != nil {
+		return nil, err
+	}
 	account, err := session.GetAccount()
 	if err != nil {
-		println("error:", err)
-		return
+		return nil, err
 	}
-
-	data, err := json.MarshalIndent(&account, "", "    ")
-	println("account:", string(data))
+	if app.profile.Timezone != "" {
+		loc, err := time.LoadLocation(app.profile.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid time zone in profile: %v", err)
+		}
+		account.Data.Timezone = app.profile.Timezone
+		session.SetLocation(loc)
+	}
+	app.account = &account
+	return app.account, nil
 }
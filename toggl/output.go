@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/format"
+)
+
+func init() {
+	format.Register(profileInfo{}, nil,
+		format.Column{Name: "current", Value: func(item interface{}, o *format.Options) string {
+			if item.(profileInfo).Current {
+				return "*"
+			}
+			return ""
+		}},
+		format.Column{Name: "profile", Value: func(item interface{}, o *format.Options) string {
+			return item.(profileInfo).Name
+		}},
+		format.Column{Name: "logged_in", Value: func(item interface{}, o *format.Options) string {
+			if item.(profileInfo).LoggedIn {
+				return "yes"
+			}
+			return "no"
+		}},
+		format.Column{Name: "workspace", Value: func(item interface{}, o *format.Options) string {
+			if w := item.(profileInfo).Workspace; w != 0 {
+				return strconv.Itoa(w)
+			}
+			return ""
+		}},
+		format.Column{Name: "project", Value: func(item interface{}, o *format.Options) string {
+			return item.(profileInfo).Project
+		}},
+		format.Column{Name: "timezone", Value: func(item interface{}, o *format.Options) string {
+			return item.(profileInfo).Timezone
+		}},
+		format.Column{Name: "format", Value: func(item interface{}, o *format.Options) string {
+			return item.(profileInfo).Format
+		}},
+	)
+}
+
+// outputFormat returns the selected output format: the one given with -json
+// or -format, or the profile's.
+func (app *app) outputFormat() string {
+	switch {
+	case app.json:
+		return format.JSON
+	case app.format != "":
+		return app.format
+	case app.profile != nil && app.profile.Format != "":
+		return app.profile.Format
+	}
+	return format.Table
+}
+
+// structured returns true if output is meant for programs rather than people.
+func (app *app) structured() bool {
+	return app.outputFormat() != format.Table
+}
+
+// write writes values in the selected output format.
+func (app *app) write(values interface{}) error {
+	options := format.Options{
+		Format:    app.outputFormat(),
+		Account:   app.account,
+		Durations: toggl.DurationClockSeconds,
+	}
+	if app.columns != "" {
+		options.Columns = strings.Split(app.columns, ",")
+	}
+	if app.session != nil {
+		options.Location = app.session.Location()
+	}
+
+	return format.Write(app.stdout, values, options)
+}
+
+// printEntry writes a single entry, in quick entry syntax unless another
+// format was requested.
+func (app *app) printEntry(entry toggl.TimeEntry) error {
+	if app.structured() || app.columns != "" {
+		return app.write(entry)
+	}
+
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+	status := "stopped"
+	if entry.IsRunning() {
+		status = "running"
+	}
+	fmt.Fprintf(app.stdout, "%d  %s  (%s, %s)\n", entry.ID, account.FormatEntry(entry, time.Now()),
+		entry.FormatDuration(toggl.DurationClockSeconds), status)
+	return nil
+}
+
+// writeEntries writes a list of entries, followed by their total duration in
+// tables.
+func (app *app) writeEntries(entries []toggl.TimeEntry) error {
+	if err := app.write(entries); err != nil {
+		return err
+	}
+	if app.structured() || len(entries) == 0 {
+		return nil
+	}
+
+	var total time.Duration
+	for _, e := range entries {
+		total += e.LiveDuration()
+	}
+	fmt.Fprintf(app.stdout, "\nTotal: %s\n", toggl.FormatDuration(total, toggl.DurationClockSeconds))
+	return nil
+}
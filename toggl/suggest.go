@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/format"
+	"github.com/Jberlinsky/go-toggl/importer"
+	"github.com/Jberlinsky/go-toggl/suggest"
+)
+
+func init() {
+	eventStart := func(e suggest.Event, o *format.Options) string {
+		start := e.Start
+		if o.Location != nil {
+			start = start.In(o.Location)
+		}
+		return start.Format("2006-01-02 15:04")
+	}
+	format.Register(suggest.Suggestion{}, nil,
+		format.Column{Name: "start", Value: func(item interface{}, o *format.Options) string {
+			return eventStart(item.(suggest.Suggestion).Event, o)
+		}},
+		format.Column{Name: "duration", Value: func(item interface{}, o *format.Options) string {
+			return toggl.FormatDuration(item.(suggest.Suggestion).Event.Duration(), o.Durations)
+		}},
+		format.Column{Name: "project", Value: func(item interface{}, o *format.Options) string {
+			return item.(suggest.Suggestion).Project
+		}},
+		format.Column{Name: "description", Value: func(item interface{}, o *format.Options) string {
+			return item.(suggest.Suggestion).Entry.Description
+		}},
+		format.Column{Name: "tags", Value: func(item interface{}, o *format.Options) string {
+			return strings.Join(item.(suggest.Suggestion).Entry.Tags, ",")
+		}},
+	)
+	format.Register(suggest.Skipped{}, nil,
+		format.Column{Name: "start", Value: func(item interface{}, o *format.Options) string {
+			return eventStart(item.(suggest.Skipped).Event, o)
+		}},
+		format.Column{Name: "duration", Value: func(item interface{}, o *format.Options) string {
+			return toggl.FormatDuration(item.(suggest.Skipped).Event.Duration(), o.Durations)
+		}},
+		format.Column{Name: "event", Value: func(item interface{}, o *format.Options) string {
+			return item.(suggest.Skipped).Event.Summary
+		}},
+		format.Column{Name: "reason", Value: func(item interface{}, o *format.Options) string {
+			return item.(suggest.Skipped).Reason
+		}},
+	)
+}
+
+// suggestFlags are the flags of the suggest command.
+type suggestFlags struct {
+	rules, me            string
+	skipped, create, ask bool
+}
+
+func setupSuggest(app *app, flags *flag.FlagSet) {
+	setupRange(app, flags)
+	flags.StringVar(&app.suggest.rules, "rules", "", "JSON `file` of rules matching events to projects; defaults to the profile's \"suggest\" rules")
+	flags.StringVar(&app.suggest.me, "me", "", "your calendar `address`, to skip events you declined; defaults to the profile's email")
+	flags.BoolVar(&app.suggest.skipped, "skipped", false, "list the events without suggestions, and why")
+	flags.BoolVar(&app.suggest.create, "create", false, "create the suggested entries")
+	flags.BoolVar(&app.suggest.ask, "ask", false, "ask before creating each suggested entry")
+}
+
+func runSuggest(app *app, args []string) error {
+	if len(args) != 1 {
+		return usagef("expected one iCalendar file")
+	}
+
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+	session, _ := app.getSession()
+	start, end, err := app.dateRange()
+	if err != nil {
+		return err
+	}
+
+	rules, err := app.suggestRules()
+	if err != nil {
+		return err
+	}
+	me := app.suggest.me
+	if me == "" && app.profile != nil {
+		me = app.profile.Email
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	cal, err := suggest.ParseICS(file, session.Location())
+	if err != nil {
+		return err
+	}
+	events := cal.Occurrences(start, end)
+	for _, w := range cal.Warnings {
+		fmt.Fprintf(app.stderr, "warning: %s\n", w)
+	}
+
+	existing, err := existingEntries(session, start, end)
+	if err != nil {
+		return err
+	}
+	wid, err := app.findWorkspace("")
+	if err != nil {
+		return err
+	}
+	result, err := suggest.Suggest(account, events, existing, rules, suggest.Options{Me: me, Workspace: wid})
+	if err != nil {
+		return err
+	}
+
+	if app.suggest.skipped {
+		return app.write(result.Skipped)
+	}
+	if !app.suggest.create && !app.suggest.ask {
+		if err := app.write(result.Suggestions); err != nil {
+			return err
+		}
+		if !app.structured() && len(result.Suggestions) > 0 {
+			fmt.Fprintln(app.stdout, "\nRun with -create to create these entries, or -ask to choose them.")
+		}
+		return nil
+	}
+
+	entries := result.Entries()
+	if app.suggest.ask {
+		entries = app.chooseEntries(account, entries)
+	}
+	return app.createEntries(session, entries)
+}
+
+// app.suggest.rules returns the rules given by -rules, or the profile's.
+func (app *app) suggestRules() ([]suggest.Rule, error) {
+	if app.suggest.rules == "" {
+		if app.profile == nil || len(app.profile.Suggest) == 0 {
+			return nil, usagef("no rules; give a file with -rules, or add them to the profile's \"suggest\" setting")
+		}
+		return app.profile.Suggest, nil
+	}
+	file, err := os.Open(app.suggest.rules)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	rules, err := suggest.ParseRules(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", app.suggest.rules, err)
+	}
+	return rules, nil
+}
+
+// existingEntries returns the entries that can cover the events or sessions
+// of a time range, including those started the day before.
+func existingEntries(session *toggl.Session, start, end time.Time) ([]toggl.TimeEntry, error) {
+	return session.GetAllTimeEntries(start.AddDate(0, 0, -1), end)
+}
+
+// chooseEntries asks which of the entries to create.
+func (app *app) chooseEntries(account *toggl.Account, entries []toggl.TimeEntry) []toggl.TimeEntry {
+	in := bufio.NewReader(os.Stdin)
+	now := time.Now()
+	var chosen []toggl.TimeEntry
+	for _, e := range entries {
+		fmt.Fprintf(app.stderr, "Create %s? [y/N] ", account.FormatEntry(e, now))
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(app.stderr)
+			return chosen
+		}
+		if answer := strings.ToLower(strings.TrimSpace(line)); answer == "y" || answer == "yes" {
+			chosen = append(chosen, e)
+		}
+	}
+	return chosen
+}
+
+// createEntries creates entries at the pace of an import.
+func (app *app) createEntries(session *toggl.Session, entries []toggl.TimeEntry) error {
+	plan := &importer.Plan{}
+	for i, e := range entries {
+		plan.Items = append(plan.Items, importer.Item{
+			Record: importer.Record{Line: i + 1, Start: *e.Start, Duration: time.Duration(e.Duration) * time.Second, Description: e.Description},
+			Action: importer.ActionCreate,
+			Entry:  e,
+		})
+	}
+
+	done, counting := 0, false
+	im := &importer.Importer{
+		Session: session,
+		OnItem: func(item importer.Item, created toggl.TimeEntry, err error) {
+			done++
+			if err != nil {
+				if counting {
+					fmt.Fprintln(app.stderr)
+				}
+				fmt.Fprintf(app.stderr, "%s: %v\n", item.Record.Description, err)
+				counting = false
+				return
+			}
+			fmt.Fprintf(app.stderr, "\rCreated %d of %d", done, len(plan.Items))
+			counting = true
+		},
+	}
+	result, err := im.Apply(context.Background(), plan)
+	if counting {
+		fmt.Fprintln(app.stderr)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(app.stdout, "Created %d entries\n", result.Created)
+	if result.Failed > 0 {
+		return fmt.Errorf("%d entries weren't created", result.Failed)
+	}
+	return nil
+}
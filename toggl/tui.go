@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/toggl/tui"
+)
+
+// profileBackend serves the account with the profile's settings applied.
+type profileBackend struct {
+	tui.Backend
+	account *toggl.Account
+}
+
+func (b profileBackend) Account() (toggl.Account, error) {
+	return *b.account, nil
+}
+
+func runTUI(app *app, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments")
+	}
+	session, err := app.getSession()
+	if err != nil {
+		return err
+	}
+	account, err := app.getAccount()
+	if err != nil {
+		return err
+	}
+
+	saved, err := sttyOutput("-g")
+	if err != nil {
+		return fmt.Errorf("The interactive interface needs a terminal")
+	}
+	if err := stty("raw", "-echo"); err != nil {
+		return fmt.Errorf("Unable to set up the terminal: %v", err)
+	}
+	defer stty(strings.TrimSpace(saved))
+
+	ui := tui.New(profileBackend{Backend: tui.NewSessionBackend(session), account: account}, os.Stdin, app.stdout)
+	ui.Height, ui.Width = terminalSize()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ui.Run(ctx)
+}
+
+// terminalSize returns the number of rows and columns of the terminal on
+// stdin, or zeros if they can't be determined.
+func terminalSize() (rows, cols int) {
+	out, err := sttyOutput("size")
+	if err != nil {
+		return 0, 0
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0
+	}
+	rows, _ = strconv.Atoi(fields[0])
+	cols, _ = strconv.Atoi(fields[1])
+	return rows, cols
+}
+
+// sttyOutput runs stty on the terminal on stdin and returns its output.
+func sttyOutput(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
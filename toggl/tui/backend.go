@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/Jberlinsky/go-toggl"
+)
+
+// Backend is the set of Toggl operations the UI uses. The session backend
+// talks to the Toggl API; tests can substitute a fake.
+type Backend interface {
+	Account() (toggl.Account, error)
+	// Current returns the running timer, or nil if none is running.
+	Current() (*toggl.TimeEntry, error)
+	Entries(start, end time.Time) ([]toggl.TimeEntry, error)
+	// Start stops the running timer, if any, and starts a timer that's a
+	// copy of the given entry.
+	Start(entry toggl.TimeEntry) (toggl.TimeEntry, error)
+	// Create records a completed entry.
+	Create(entry toggl.TimeEntry) (toggl.TimeEntry, error)
+	Stop() (toggl.TimeEntry, error)
+	Update(entry toggl.TimeEntry) (toggl.TimeEntry, error)
+	Delete(entry toggl.TimeEntry) error
+}
+
+// sessionBackend implements Backend with a session. Timers are managed by a
+// Tracker, and the account is served from the session's cache if it has one.
+type sessionBackend struct {
+	session *toggl.Session
+	tracker *toggl.Tracker
+}
+
+// NewSessionBackend returns a backend that uses a session.
+func NewSessionBackend(session *toggl.Session) Backend {
+	return &sessionBackend{session: session, tracker: toggl.NewTracker(session)}
+}
+
+func (b *sessionBackend) Account() (toggl.Account, error) {
+	return b.session.GetAccount()
+}
+
+func (b *sessionBackend) Current() (*toggl.TimeEntry, error) {
+	return b.tracker.Current()
+}
+
+func (b *sessionBackend) Entries(start, end time.Time) ([]toggl.TimeEntry, error) {
+	return b.session.GetTimeEntries(start, end)
+}
+
+func (b *sessionBackend) Start(entry toggl.TimeEntry) (toggl.TimeEntry, error) {
+	return b.tracker.Switch(entry)
+}
+
+func (b *sessionBackend) Create(entry toggl.TimeEntry) (toggl.TimeEntry, error) {
+	return b.session.CreateTimeEntry(entry)
+}
+
+func (b *sessionBackend) Stop() (toggl.TimeEntry, error) {
+	return b.tracker.Stop()
+}
+
+func (b *sessionBackend) Update(entry toggl.TimeEntry) (toggl.TimeEntry, error) {
+	return b.session.UpdateTimeEntry(entry)
+}
+
+func (b *sessionBackend) Delete(entry toggl.TimeEntry) error {
+	_, err := b.session.DeleteTimeEntry(entry)
+	return err
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"unicode/utf8"
+)
+
+// KeyCode identifies a special key. Printable characters have the code
+// KeyRune.
+type KeyCode int
+
+// Key codes.
+const (
+	KeyRune KeyCode = iota
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyTab
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyCtrlC
+	KeyCtrlU
+)
+
+// Key is a key press.
+type Key struct {
+	Code KeyCode
+	// Rune is the character typed, for KeyRune.
+	Rune rune
+}
+
+// escapeSequences maps the sequences terminals send for special keys,
+// without the leading escape character.
+var escapeSequences = map[string]KeyCode{
+	"[A":  KeyUp,
+	"[B":  KeyDown,
+	"[C":  KeyRight,
+	"[D":  KeyLeft,
+	"OA":  KeyUp,
+	"OB":  KeyDown,
+	"OC":  KeyRight,
+	"OD":  KeyLeft,
+	"[5~": KeyPageUp,
+	"[6~": KeyPageDown,
+	"[H":  KeyHome,
+	"[F":  KeyEnd,
+	"[1~": KeyHome,
+	"[4~": KeyEnd,
+	"OH":  KeyHome,
+	"OF":  KeyEnd,
+}
+
+// ParseKeys converts the bytes read from a terminal in raw mode into key
+// presses. An escape character followed by a known sequence is a special
+// key; an escape character on its own is the escape key, so a chunk of input
+// should contain whole sequences, as terminals send them.
+func ParseKeys(data []byte) (keys []Key) {
+	for len(data) > 0 {
+		b := data[0]
+		switch {
+		case b == 0x1b:
+			code, n := parseEscape(data[1:])
+			keys = append(keys, Key{Code: code})
+			data = data[1+n:]
+			continue
+		case b == '\r' || b == '\n':
+			keys = append(keys, Key{Code: KeyEnter})
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, Key{Code: KeyBackspace})
+		case b == '\t':
+			keys = append(keys, Key{Code: KeyTab})
+		case b == 0x03:
+			keys = append(keys, Key{Code: KeyCtrlC})
+		case b == 0x15:
+			keys = append(keys, Key{Code: KeyCtrlU})
+		case b < 0x20:
+			// Other control characters are ignored
+		default:
+			r, size := utf8.DecodeRune(data)
+			keys = append(keys, Key{Code: KeyRune, Rune: r})
+			data = data[size:]
+			continue
+		}
+		data = data[1:]
+	}
+	return keys
+}
+
+// parseEscape parses the rest of an escape sequence, returning the key and
+// the number of bytes it used.
+func parseEscape(data []byte) (KeyCode, int) {
+	for seq, code := range escapeSequences {
+		if len(data) >= len(seq) && string(data[:len(seq)]) == seq {
+			return code, len(seq)
+		}
+	}
+	return KeyEscape, 0
+}
+
+// Runes returns the key presses for typing a string, for scripting input.
+func Runes(s string) (keys []Key) {
+	for _, r := range s {
+		keys = append(keys, Key{Code: KeyRune, Rune: r})
+	}
+	return keys
+}
@@ -0,0 +1,308 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jberlinsky/go-toggl"
+)
+
+// ANSI escape sequences
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+	ansiGreen   = "\x1b[32m"
+	ansiClear   = "\x1b[H\x1b[2J"
+)
+
+// Lines used above and below the list
+const (
+	headerLines = 3
+	footerLines = 2
+)
+
+var helpLines = []string{
+	"Keys",
+	"",
+	"  s, n        start a timer, in quick entry syntax",
+	"  x           stop the running timer",
+	"  c, enter    continue the selected entry",
+	"  e           edit the selected entry",
+	"  p           set the selected entry's project",
+	"  t           add or remove a tag on the selected entry",
+	"  d           delete the selected entry",
+	"  j, k, ↑, ↓  select an entry",
+	"  r           reload entries",
+	"  q, ctrl-c   quit",
+	"",
+	"In pickers, type to search and press enter to choose; escape cancels.",
+	"",
+	"Press any key to return.",
+}
+
+// line is a line of the screen: plain text, padded or truncated to the
+// screen width before the style is applied.
+type line struct {
+	text  string
+	style string
+}
+
+// draw redraws the screen.
+func (ui *UI) draw() {
+	fmt.Fprint(ui.Out, ansiClear+ui.Render())
+}
+
+// Render returns the contents of the screen, with lines separated by CRLF as
+// terminals in raw mode expect.
+func (ui *UI) Render() string {
+	width, height := ui.size()
+
+	lines := ui.header(width)
+	body := ui.body(width)
+	for len(body) < ui.listHeight() {
+		body = append(body, line{})
+	}
+	lines = append(lines, body[:ui.listHeight()]...)
+	lines = append(lines, ui.footer()...)
+
+	out := make([]string, 0, height)
+	for _, l := range lines[:height] {
+		text := fit(l.text, width)
+		if l.style != "" {
+			text = l.style + text + ansiReset
+		}
+		out = append(out, strings.TrimRight(text, " "))
+	}
+	return strings.Join(out, "\r\n")
+}
+
+func (ui *UI) size() (width, height int) {
+	width, height = ui.Width, ui.Height
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= headerLines+footerLines {
+		height = DefaultHeight
+	}
+	return width, height
+}
+
+func (ui *UI) listHeight() int {
+	_, height := ui.size()
+	return height - headerLines - footerLines
+}
+
+// header shows the running timer and the totals for today and this week.
+func (ui *UI) header(width int) []line {
+	now := ui.now()
+
+	timer := "No timer running"
+	clock := ""
+	if c := ui.current; c != nil {
+		timer = "● " + ui.describe(*c)
+		clock = toggl.FormatDuration(ui.duration(*c, now), toggl.DurationClockSeconds)
+	}
+	title := " toggl  " + timer
+	title = fit(title, width-utf8.RuneCountInString(clock)-1) + " " + clock
+
+	var today, week time.Duration
+	for _, e := range ui.entries {
+		d := ui.duration(e, now)
+		week += d
+		if ui.calendar.SameDay(*e.Start, now) {
+			today += d
+		}
+	}
+	totals := fmt.Sprintf(" Today %s   Week %s",
+		toggl.FormatDuration(today, toggl.DurationClockSeconds),
+		toggl.FormatDuration(week, toggl.DurationClockSeconds))
+
+	style := ansiBold
+	if ui.current != nil {
+		style += ansiGreen
+	}
+	return []line{
+		{text: title, style: style},
+		{text: totals},
+		{text: strings.Repeat("─", width), style: ansiDim},
+	}
+}
+
+// body shows the entries, a picker or help, depending on the mode.
+func (ui *UI) body(width int) []line {
+	switch ui.mode {
+	case modeHelp:
+		var lines []line
+		for _, h := range helpLines {
+			lines = append(lines, line{text: " " + h})
+		}
+		return lines
+	case modePicker:
+		return ui.pickerLines()
+	}
+	return ui.entryLines(width)
+}
+
+// entryLines lists this week's entries grouped by day, scrolled so that the
+// selected entry is visible.
+func (ui *UI) entryLines(width int) []line {
+	if len(ui.entries) == 0 {
+		return []line{{text: " No entries this week. Press s to start a timer."}}
+	}
+
+	now := ui.now()
+	var lines []line
+	selectedLine := 0
+	for i, e := range ui.entries {
+		if i == 0 || !ui.calendar.SameDay(*e.Start, *ui.entries[i-1].Start) {
+			lines = append(lines, ui.dayLine(*e.Start, now, width))
+		}
+
+		start := e.Start.In(ui.location())
+		times := start.Format("15:04") + "-"
+		if !e.IsRunning() && e.Stop != nil {
+			times += e.Stop.In(ui.location()).Format("15:04")
+		} else {
+			times += "     "
+		}
+		text := fmt.Sprintf("   %s  %8s  %s", times,
+			toggl.FormatDuration(ui.duration(e, now), toggl.DurationClockSeconds), ui.describe(e))
+
+		l := line{text: text}
+		if i == ui.selected {
+			l.text = " >" + text[2:]
+			l.style = ansiReverse
+			selectedLine = len(lines)
+		}
+		lines = append(lines, l)
+	}
+
+	// Keep the selected entry, and the day header above it, on screen
+	height := ui.listHeight()
+	if selectedLine-1 < ui.offset {
+		ui.offset = selectedLine - 1
+	}
+	if selectedLine >= ui.offset+height {
+		ui.offset = selectedLine - height + 1
+	}
+	if ui.offset > len(lines)-height {
+		ui.offset = len(lines) - height
+	}
+	if ui.offset < 0 {
+		ui.offset = 0
+	}
+	return lines[ui.offset:]
+}
+
+// dayLine is the header for a day's entries, with the day's total.
+func (ui *UI) dayLine(day, now time.Time, width int) line {
+	var total time.Duration
+	for _, e := range ui.entries {
+		if ui.calendar.SameDay(*e.Start, day) {
+			total += ui.duration(e, now)
+		}
+	}
+
+	name := day.In(ui.location()).Format("Monday 2006-01-02")
+	switch {
+	case ui.calendar.SameDay(day, now):
+		name = "Today"
+	case ui.calendar.SameDay(day, now.AddDate(0, 0, -1)):
+		name = "Yesterday"
+	}
+	sum := toggl.FormatDuration(total, toggl.DurationClockSeconds)
+	return line{text: fit(" "+name, width-utf8.RuneCountInString(sum)-1) + " " + sum, style: ansiBold}
+}
+
+// pickerLines shows the search query and the matching items.
+func (ui *UI) pickerLines() []line {
+	p := ui.picker
+	lines := []line{{text: fmt.Sprintf(" %s: %s█", p.title, string(p.query)), style: ansiBold}}
+	if len(p.matches) == 0 {
+		return append(lines, line{text: "   No matches"})
+	}
+
+	height := ui.listHeight() - 1
+	first := 0
+	if p.selected >= height {
+		first = p.selected - height + 1
+	}
+	for i := first; i < len(p.matches) && i < first+height; i++ {
+		l := line{text: "   " + p.items[p.matches[i]]}
+		if i == p.selected {
+			l.text = " > " + p.items[p.matches[i]]
+			l.style = ansiReverse
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// footer shows the status line, or the prompt being answered, and the keys.
+func (ui *UI) footer() []line {
+	status := line{text: " " + ui.status}
+	switch ui.mode {
+	case modeInput:
+		status = line{text: fmt.Sprintf(" %s: %s█", ui.input.prompt, string(ui.input.text)), style: ansiBold}
+	case modeConfirm:
+		status = line{text: " " + ui.confirm.prompt, style: ansiBold}
+	}
+
+	keys := " s start  x stop  c continue  e edit  p project  t tag  d delete  ? help  q quit"
+	switch ui.mode {
+	case modeInput:
+		keys = " enter save  esc cancel  ctrl-u clear"
+	case modePicker:
+		keys = " ↑↓ select  enter choose  esc cancel"
+	}
+	return []line{status, {text: keys, style: ansiDim}}
+}
+
+// describe summarizes an entry: its project, description and tags.
+func (ui *UI) describe(e toggl.TimeEntry) string {
+	var parts []string
+	if e.Pid != 0 {
+		parts = append(parts, "@"+ui.projectName(e.Pid))
+	}
+	if e.Description != "" {
+		parts = append(parts, e.Description)
+	} else {
+		parts = append(parts, "(no description)")
+	}
+	for _, t := range e.Tags {
+		parts = append(parts, "#"+t)
+	}
+	return strings.Join(parts, " ")
+}
+
+// duration returns the time tracked by an entry as of now.
+func (ui *UI) duration(e toggl.TimeEntry, now time.Time) time.Duration {
+	if e.IsRunning() && e.Start != nil {
+		return now.Sub(*e.Start).Truncate(time.Second)
+	}
+	return time.Duration(e.Duration) * time.Second
+}
+
+// fit pads or truncates s to width characters.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// location returns the time zone entries are shown in.
+func (ui *UI) location() *time.Location {
+	if ui.calendar.Location != nil {
+		return ui.calendar.Location
+	}
+	return time.Local
+}
@@ -0,0 +1,572 @@
+// Package tui implements an interactive terminal interface for Toggl: it
+// shows the running timer with a live clock and this week's entries, and
+// starts, stops, continues, edits and deletes entries with single keys.
+//
+// The UI reads key presses from an io.Reader and draws to an io.Writer with
+// ANSI escape sequences. Putting the terminal into raw mode is left to the
+// caller, so the UI can also be driven with scripted input, with Handle and
+// Render, against a fake Backend.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jberlinsky/go-toggl"
+)
+
+// Default screen size and refresh interval
+const (
+	DefaultWidth           = 80
+	DefaultHeight          = 24
+	DefaultRefreshInterval = time.Minute
+)
+
+// mode is what the keyboard is currently driving.
+type mode int
+
+const (
+	modeList mode = iota
+	modeInput
+	modePicker
+	modeConfirm
+	modeHelp
+)
+
+// UI is an interactive terminal interface.
+type UI struct {
+	Backend Backend
+	// In is read for key presses and Out is drawn on.
+	In  io.Reader
+	Out io.Writer
+	// Width and Height are the size of the screen. Zero values mean
+	// DefaultWidth and DefaultHeight.
+	Width, Height int
+	// Now returns the current time. A nil Now means time.Now.
+	Now func() time.Time
+	// RefreshInterval is how often the running timer and entries are
+	// reloaded from the backend. Zero means DefaultRefreshInterval.
+	RefreshInterval time.Duration
+
+	account  toggl.Account
+	calendar toggl.Calendar
+	current  *toggl.TimeEntry
+	entries  []toggl.TimeEntry
+	selected int
+	offset   int
+	status   string
+	mode     mode
+	input    *input
+	picker   *picker
+	confirm  *confirm
+	quit     bool
+}
+
+// input is a line of text being typed.
+type input struct {
+	prompt string
+	text   []rune
+	submit func(text string)
+}
+
+// picker chooses an item from a list filtered by fuzzy search.
+type picker struct {
+	title    string
+	items    []string
+	query    []rune
+	matches  []int
+	selected int
+	choose   func(index int)
+}
+
+// confirm asks a yes or no question.
+type confirm struct {
+	prompt string
+	yes    func()
+}
+
+// New returns a UI that uses a backend, reading keys from in and drawing on
+// out.
+func New(backend Backend, in io.Reader, out io.Writer) *UI {
+	return &UI{Backend: backend, In: in, Out: out}
+}
+
+// Load retrieves the account, the running timer and this week's entries from
+// the backend. Run calls it before drawing the first screen.
+func (ui *UI) Load() error {
+	account, err := ui.Backend.Account()
+	if err != nil {
+		return err
+	}
+	calendar, err := account.Calendar()
+	if err != nil {
+		return err
+	}
+	ui.account, ui.calendar = account, calendar
+	return ui.refresh()
+}
+
+// Run runs the UI until the user quits, the input ends or the context is
+// cancelled. The screen is redrawn every second so the timer's clock stays
+// live.
+func (ui *UI) Run(ctx context.Context) error {
+	if err := ui.Load(); err != nil {
+		return err
+	}
+
+	keys := make(chan Key)
+	errs := make(chan error, 1)
+	go ui.readKeys(keys, errs)
+
+	interval := ui.RefreshInterval
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	clock := time.NewTicker(time.Second)
+	defer clock.Stop()
+	reload := time.NewTicker(interval)
+	defer reload.Stop()
+
+	// Switch to the alternate screen and hide the cursor while running
+	fmt.Fprint(ui.Out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(ui.Out, "\x1b[?25h\x1b[?1049l")
+
+	for !ui.quit {
+		ui.draw()
+		select {
+		case <-ctx.Done():
+			return nil
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			ui.Handle(key)
+		case err := <-errs:
+			return err
+		case <-clock.C:
+		case <-reload.C:
+			ui.setError(ui.refresh())
+		}
+	}
+	return nil
+}
+
+// Quit returns true once the user has asked to quit.
+func (ui *UI) Quit() bool {
+	return ui.quit
+}
+
+// readKeys sends the keys read from In until it ends.
+func (ui *UI) readKeys(keys chan<- Key, errs chan<- error) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := ui.In.Read(buf)
+		for _, key := range ParseKeys(buf[:n]) {
+			keys <- key
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			errs <- err
+			return
+		}
+	}
+}
+
+func (ui *UI) now() time.Time {
+	if ui.Now != nil {
+		return ui.Now()
+	}
+	return time.Now()
+}
+
+// refresh reloads the running timer and this week's entries.
+func (ui *UI) refresh() error {
+	current, err := ui.Backend.Current()
+	if err != nil {
+		return err
+	}
+
+	now := ui.now()
+	entries, err := ui.Backend.Entries(ui.calendar.WeekStart(now), ui.calendar.NextDay(now))
+	if err != nil {
+		return err
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.ServerDeletedAt == nil && e.Start != nil {
+			kept = append(kept, e)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Start.After(*kept[j].Start)
+	})
+
+	ui.current, ui.entries = current, kept
+	if ui.selected >= len(ui.entries) {
+		ui.selected = len(ui.entries) - 1
+	}
+	if ui.selected < 0 {
+		ui.selected = 0
+	}
+	return nil
+}
+
+// Handle processes a key press.
+func (ui *UI) Handle(key Key) {
+	switch ui.mode {
+	case modeInput:
+		ui.handleInput(key)
+	case modePicker:
+		ui.handlePicker(key)
+	case modeConfirm:
+		ui.handleConfirm(key)
+	case modeHelp:
+		ui.mode = modeList
+	default:
+		ui.handleList(key)
+	}
+}
+
+func (ui *UI) handleList(key Key) {
+	ui.status = ""
+
+	switch key.Code {
+	case KeyCtrlC:
+		ui.quit = true
+	case KeyUp:
+		ui.move(-1)
+	case KeyDown:
+		ui.move(1)
+	case KeyPageUp:
+		ui.move(-ui.listHeight())
+	case KeyPageDown:
+		ui.move(ui.listHeight())
+	case KeyHome:
+		ui.move(-len(ui.entries))
+	case KeyEnd:
+		ui.move(len(ui.entries))
+	case KeyEnter:
+		ui.continueSelected()
+	case KeyRune:
+		switch key.Rune {
+		case 'q':
+			ui.quit = true
+		case 'k':
+			ui.move(-1)
+		case 'j':
+			ui.move(1)
+		case 's', 'n':
+			ui.startNew()
+		case 'x':
+			ui.stop()
+		case 'c':
+			ui.continueSelected()
+		case 'e':
+			ui.editSelected()
+		case 'p':
+			ui.pickProject()
+		case 't':
+			ui.pickTag()
+		case 'd':
+			ui.deleteSelected()
+		case 'r':
+			if ui.setError(ui.refresh()) {
+				ui.status = "Refreshed"
+			}
+		case '?', 'h':
+			ui.mode = modeHelp
+		}
+	}
+}
+
+func (ui *UI) handleInput(key Key) {
+	in := ui.input
+	switch key.Code {
+	case KeyEscape, KeyCtrlC:
+		ui.closeOverlay()
+	case KeyEnter:
+		ui.closeOverlay()
+		in.submit(strings.TrimSpace(string(in.text)))
+	case KeyBackspace:
+		if len(in.text) > 0 {
+			in.text = in.text[:len(in.text)-1]
+		}
+	case KeyCtrlU:
+		in.text = nil
+	case KeyRune:
+		in.text = append(in.text, key.Rune)
+	}
+}
+
+func (ui *UI) handlePicker(key Key) {
+	p := ui.picker
+	switch key.Code {
+	case KeyEscape, KeyCtrlC:
+		ui.closeOverlay()
+	case KeyEnter:
+		ui.closeOverlay()
+		if p.selected < len(p.matches) {
+			p.choose(p.matches[p.selected])
+		}
+	case KeyUp:
+		if p.selected > 0 {
+			p.selected--
+		}
+	case KeyDown, KeyTab:
+		if p.selected < len(p.matches)-1 {
+			p.selected++
+		}
+	case KeyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case KeyCtrlU:
+		p.query = nil
+		p.filter()
+	case KeyRune:
+		p.query = append(p.query, key.Rune)
+		p.filter()
+	}
+}
+
+func (ui *UI) handleConfirm(key Key) {
+	c := ui.confirm
+	ui.closeOverlay()
+	if key.Code == KeyRune && (key.Rune == 'y' || key.Rune == 'Y') {
+		c.yes()
+	} else {
+		ui.status = "Cancelled"
+	}
+}
+
+// filter updates the items matching the query, best matches first.
+func (p *picker) filter() {
+	p.matches = toggl.FuzzyRank(string(p.query), p.items)
+	p.selected = 0
+}
+
+func (ui *UI) closeOverlay() {
+	ui.mode = modeList
+	ui.input, ui.picker, ui.confirm = nil, nil, nil
+}
+
+func (ui *UI) prompt(prompt, text string, submit func(string)) {
+	ui.mode = modeInput
+	ui.input = &input{prompt: prompt, text: []rune(text), submit: submit}
+}
+
+func (ui *UI) pick(title string, items []string, choose func(int)) {
+	ui.mode = modePicker
+	ui.picker = &picker{title: title, items: items, choose: choose}
+	ui.picker.filter()
+}
+
+func (ui *UI) ask(prompt string, yes func()) {
+	ui.mode = modeConfirm
+	ui.confirm = &confirm{prompt: prompt, yes: yes}
+}
+
+func (ui *UI) move(delta int) {
+	ui.selected += delta
+	if ui.selected >= len(ui.entries) {
+		ui.selected = len(ui.entries) - 1
+	}
+	if ui.selected < 0 {
+		ui.selected = 0
+	}
+}
+
+// selectedEntry returns the selected entry, or nil if there are no entries.
+func (ui *UI) selectedEntry() *toggl.TimeEntry {
+	if ui.selected < len(ui.entries) {
+		return &ui.entries[ui.selected]
+	}
+	ui.status = "No entry selected"
+	return nil
+}
+
+// setError shows an error in the status line. It returns true if there was
+// no error.
+func (ui *UI) setError(err error) bool {
+	if err != nil {
+		ui.status = "Error: " + err.Error()
+		return false
+	}
+	return true
+}
+
+// done refreshes the entries after a change and shows a message.
+func (ui *UI) done(format string, args ...interface{}) {
+	if ui.setError(ui.refresh()) {
+		ui.status = fmt.Sprintf(format, args...)
+	}
+}
+
+// startNew starts an entry typed in quick entry syntax. An entry with a
+// stop time is recorded as it is; otherwise it replaces the running timer.
+func (ui *UI) startNew() {
+	ui.prompt("Start", "", func(text string) {
+		if text == "" {
+			return
+		}
+		entry, err := ui.account.ParseEntry(text, ui.now())
+		if !ui.setError(err) {
+			return
+		}
+		if entry.IsRunning() {
+			entry, err = ui.Backend.Start(entry)
+		} else {
+			entry, err = ui.Backend.Create(entry)
+		}
+		if ui.setError(err) {
+			ui.selected = 0
+			ui.done("Started %q", entry.Description)
+		}
+	})
+}
+
+func (ui *UI) stop() {
+	entry, err := ui.Backend.Stop()
+	if err == toggl.ErrNoRunningTimer {
+		ui.status = "No timer is running"
+		return
+	}
+	if ui.setError(err) {
+		ui.done("Stopped %q after %s", entry.Description, toggl.FormatDuration(time.Duration(entry.Duration)*time.Second, toggl.DurationClockSeconds))
+	}
+}
+
+func (ui *UI) continueSelected() {
+	selected := ui.selectedEntry()
+	if selected == nil {
+		return
+	}
+	entry, err := ui.Backend.Start(*selected)
+	if ui.setError(err) {
+		ui.selected = 0
+		ui.done("Continued %q", entry.Description)
+	}
+}
+
+// editSelected edits the selected entry in quick entry syntax, so that its
+// description, project, tags and times can all be changed.
+func (ui *UI) editSelected() {
+	selected := ui.selectedEntry()
+	if selected == nil {
+		return
+	}
+	original := selected.Copy()
+	ui.prompt("Edit", ui.account.FormatEntry(original, ui.now()), func(text string) {
+		if text == "" {
+			return
+		}
+		entry, err := ui.account.ParseEntry(text, ui.now())
+		if !ui.setError(err) {
+			return
+		}
+		entry.ID = original.ID
+		if entry.Wid == 0 {
+			entry.Wid = original.Wid
+		}
+		_, err = ui.Backend.Update(entry)
+		if ui.setError(err) {
+			ui.done("Updated %q", entry.Description)
+		}
+	})
+}
+
+// pickProject sets the project of the selected entry.
+func (ui *UI) pickProject() {
+	selected := ui.selectedEntry()
+	if selected == nil {
+		return
+	}
+	original := selected.Copy()
+
+	projects := []toggl.Project{{}}
+	names := []string{"(no project)"}
+	for _, p := range ui.account.Data.Projects {
+		if p.Active && p.ServerDeletedAt == nil && (original.Wid == 0 || p.Wid == original.Wid) {
+			projects = append(projects, p)
+			names = append(names, ui.projectName(p.ID))
+		}
+	}
+
+	ui.pick("Project", names, func(i int) {
+		entry := original
+		entry.Pid = projects[i].ID
+		if entry.Pid != 0 {
+			entry.Wid = projects[i].Wid
+		}
+		_, err := ui.Backend.Update(entry)
+		if ui.setError(err) {
+			ui.done("Moved %q to %s", entry.Description, names[i])
+		}
+	})
+}
+
+// pickTag adds a tag to the selected entry, or removes it if the entry
+// already has it.
+func (ui *UI) pickTag() {
+	selected := ui.selectedEntry()
+	if selected == nil {
+		return
+	}
+	original := selected.Copy()
+
+	var names []string
+	for _, t := range ui.account.Data.Tags {
+		if t.ServerDeletedAt == nil && (original.Wid == 0 || t.Wid == original.Wid) {
+			names = append(names, t.Name)
+		}
+	}
+
+	ui.pick("Tag", names, func(i int) {
+		entry := original
+		tag := names[i]
+		if entry.HasTag(tag) {
+			entry.RemoveTag(tag)
+			_, err := ui.Backend.Update(entry)
+			if ui.setError(err) {
+				ui.done("Removed #%s from %q", tag, entry.Description)
+			}
+			return
+		}
+		entry.AddTag(tag)
+		_, err := ui.Backend.Update(entry)
+		if ui.setError(err) {
+			ui.done("Added #%s to %q", tag, entry.Description)
+		}
+	})
+}
+
+func (ui *UI) deleteSelected() {
+	selected := ui.selectedEntry()
+	if selected == nil {
+		return
+	}
+	entry := selected.Copy()
+	ui.ask(fmt.Sprintf("Delete %q? (y/n)", entry.Description), func() {
+		if ui.setError(ui.Backend.Delete(entry)) {
+			ui.done("Deleted %q", entry.Description)
+		}
+	})
+}
+
+// projectName returns a project's name, prefixed with its client's name.
+func (ui *UI) projectName(pid int) string {
+	for _, p := range ui.account.Data.Projects {
+		if p.ID == pid {
+			return ui.account.ProjectTitle(p)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,251 @@
+package tui_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jberlinsky/go-toggl"
+	"github.com/Jberlinsky/go-toggl/toggl/tui"
+)
+
+var now = time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+// fakeBackend keeps entries in memory and records the calls made to it.
+type fakeBackend struct {
+	account toggl.Account
+	entries []toggl.TimeEntry
+	nextID  int
+	calls   []string
+}
+
+func newFakeBackend() *fakeBackend {
+	b := &fakeBackend{nextID: 100}
+	b.account.Data.Timezone = "UTC"
+	b.account.Data.Workspaces = []toggl.Workspace{{ID: 1, Name: "Work"}}
+	b.account.Data.Clients = []toggl.Client{{ID: 3, Wid: 1, Name: "Acme"}}
+	b.account.Data.Projects = []toggl.Project{
+		{ID: 10, Wid: 1, Cid: 3, Name: "Website", Active: true},
+		{ID: 11, Wid: 1, Name: "Internal", Active: true},
+	}
+	b.account.Data.Tags = []toggl.Tag{{ID: 1, Wid: 1, Name: "dev"}}
+
+	start := now.Add(-3 * time.Hour)
+	stop := start.Add(time.Hour)
+	b.entries = []toggl.TimeEntry{{ID: 1, Wid: 1, Pid: 11, Description: "Planning", Start: &start, Stop: &stop, Duration: 3600}}
+	return b
+}
+
+func (b *fakeBackend) running() *toggl.TimeEntry {
+	for i := range b.entries {
+		if b.entries[i].IsRunning() {
+			return &b.entries[i]
+		}
+	}
+	return nil
+}
+
+func (b *fakeBackend) Account() (toggl.Account, error) { return b.account, nil }
+
+func (b *fakeBackend) Current() (*toggl.TimeEntry, error) {
+	if e := b.running(); e != nil {
+		copy := *e
+		return &copy, nil
+	}
+	return nil, nil
+}
+
+func (b *fakeBackend) Entries(start, end time.Time) ([]toggl.TimeEntry, error) {
+	return append([]toggl.TimeEntry(nil), b.entries...), nil
+}
+
+func (b *fakeBackend) Start(entry toggl.TimeEntry) (toggl.TimeEntry, error) {
+	b.calls = append(b.calls, "start "+entry.Description)
+	if e := b.running(); e != nil {
+		b.stopEntry(e)
+	}
+	entry = entry.Copy()
+	b.nextID++
+	start := now
+	entry.ID, entry.Start, entry.Stop, entry.Duration = b.nextID, &start, nil, -now.Unix()
+	b.entries = append(b.entries, entry)
+	return entry, nil
+}
+
+func (b *fakeBackend) Create(entry toggl.TimeEntry) (toggl.TimeEntry, error) {
+	b.calls = append(b.calls, "create "+entry.Description)
+	b.nextID++
+	entry.ID = b.nextID
+	b.entries = append(b.entries, entry)
+	return entry, nil
+}
+
+func (b *fakeBackend) Stop() (toggl.TimeEntry, error) {
+	b.calls = append(b.calls, "stop")
+	e := b.running()
+	if e == nil {
+		return toggl.TimeEntry{}, toggl.ErrNoRunningTimer
+	}
+	b.stopEntry(e)
+	return *e, nil
+}
+
+func (b *fakeBackend) stopEntry(e *toggl.TimeEntry) {
+	stop := now
+	e.Stop, e.Duration = &stop, int64(now.Sub(*e.Start)/time.Second)
+}
+
+func (b *fakeBackend) Update(entry toggl.TimeEntry) (toggl.TimeEntry, error) {
+	b.calls = append(b.calls, fmt.Sprintf("update %d", entry.ID))
+	for i := range b.entries {
+		if b.entries[i].ID == entry.ID {
+			b.entries[i] = entry
+			return entry, nil
+		}
+	}
+	return entry, fmt.Errorf("No entry %d", entry.ID)
+}
+
+func (b *fakeBackend) Delete(entry toggl.TimeEntry) error {
+	b.calls = append(b.calls, fmt.Sprintf("delete %d", entry.ID))
+	for i := range b.entries {
+		if b.entries[i].ID == entry.ID {
+			b.entries = append(b.entries[:i], b.entries[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("No entry %d", entry.ID)
+}
+
+func newUI(t *testing.T, b *fakeBackend) *tui.UI {
+	ui := tui.New(b, nil, nil)
+	ui.Now = func() time.Time { return now }
+	if err := ui.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return ui
+}
+
+// press handles keys: strings are typed and Keys are pressed.
+func press(ui *tui.UI, keys ...interface{}) {
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			for _, key := range tui.Runes(k) {
+				ui.Handle(key)
+			}
+		case tui.KeyCode:
+			ui.Handle(tui.Key{Code: k})
+		}
+	}
+}
+
+func checkCalls(t *testing.T, b *fakeBackend, want ...string) {
+	t.Helper()
+	if strings.Join(b.calls, "; ") != strings.Join(want, "; ") {
+		t.Errorf("calls = %q, want %q", b.calls, want)
+	}
+}
+
+func checkScreen(t *testing.T, ui *tui.UI, want ...string) {
+	t.Helper()
+	screen := ui.Render()
+	for _, w := range want {
+		if !strings.Contains(screen, w) {
+			t.Errorf("screen doesn't contain %q:\n%s", w, strings.Replace(screen, "\r\n", "\n", -1))
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	b := newFakeBackend()
+	ui := newUI(t, b)
+	press(ui, "s", "Write tests @Website #dev", tui.KeyEnter)
+
+	checkCalls(t, b, "start Write tests")
+	e := b.running()
+	if e == nil || e.Pid != 10 || !e.HasTag("dev") {
+		t.Fatalf("running entry = %+v", e)
+	}
+	checkScreen(t, ui, `Started "Write tests"`, "@Acme/Website Write tests #dev")
+}
+
+func TestStartCompleted(t *testing.T) {
+	b := newFakeBackend()
+	ui := newUI(t, b)
+	press(ui, "s", "today 10:00-11:00 Review", tui.KeyEnter)
+	checkCalls(t, b, "create Review")
+	checkScreen(t, ui, "Review")
+}
+
+func TestStop(t *testing.T) {
+	b := newFakeBackend()
+	ui := newUI(t, b)
+	press(ui, "x")
+	checkCalls(t, b, "stop")
+	checkScreen(t, ui, "No timer is running")
+
+	b.calls = nil
+	press(ui, "s", "Write tests", tui.KeyEnter, "x")
+	checkCalls(t, b, "start Write tests", "stop")
+	checkScreen(t, ui, `Stopped "Write tests"`)
+}
+
+func TestEdit(t *testing.T) {
+	b := newFakeBackend()
+	ui := newUI(t, b)
+	press(ui, "e", tui.KeyCtrlU, "today 08:00-10:30 Sprint planning @Website", tui.KeyEnter)
+
+	checkCalls(t, b, "update 1")
+	e := b.entries[0]
+	if e.Description != "Sprint planning" || e.Pid != 10 || e.Duration != 9000 {
+		t.Errorf("entry = %+v", e)
+	}
+	checkScreen(t, ui, `Updated "Sprint planning"`, "@Acme/Website Sprint planning")
+}
+
+func TestEditCancel(t *testing.T) {
+	b := newFakeBackend()
+	ui := newUI(t, b)
+	press(ui, "e", "more", tui.KeyEscape)
+	checkCalls(t, b)
+}
+
+func TestPickProject(t *testing.T) {
+	b := newFakeBackend()
+	ui := newUI(t, b)
+	press(ui, "p", "web", tui.KeyEnter)
+	checkCalls(t, b, "update 1")
+	if b.entries[0].Pid != 10 {
+		t.Errorf("project = %d, want 10", b.entries[0].Pid)
+	}
+	checkScreen(t, ui, `Moved "Planning" to Acme/Website`)
+}
+
+func TestDelete(t *testing.T) {
+	b := newFakeBackend()
+	ui := newUI(t, b)
+	press(ui, "d", "n")
+	checkCalls(t, b)
+	checkScreen(t, ui, "Cancelled", "Planning")
+
+	press(ui, "d")
+	checkScreen(t, ui, `Delete "Planning"? (y/n)`)
+	press(ui, "y")
+	checkCalls(t, b, "delete 1")
+	if len(b.entries) != 0 {
+		t.Errorf("entries = %+v", b.entries)
+	}
+	checkScreen(t, ui, `Deleted "Planning"`)
+}
+
+func TestContinue(t *testing.T) {
+	b := newFakeBackend()
+	ui := newUI(t, b)
+	press(ui, tui.KeyEnter)
+	checkCalls(t, b, "start Planning")
+	if e := b.running(); e == nil || e.Pid != 11 {
+		t.Errorf("running entry = %+v", e)
+	}
+}
@@ -0,0 +1,262 @@
+package toggl
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoRunningTimer is returned by Tracker operations that need a running
+// timer when none is running.
+var ErrNoRunningTimer = errors.New("No timer is running")
+
+// ErrNotPaused is returned by Tracker.Resume when no timer was paused.
+var ErrNotPaused = errors.New("No timer is paused")
+
+// TimerRunningError is returned when a timer can't be started because another
+// timer is already running.
+type TimerRunningError struct {
+	Entry TimeEntry
+}
+
+// Error describes the running timer.
+func (e *TimerRunningError) Error() string {
+	return fmt.Sprintf("Timer %q is already running", e.Entry.Description)
+}
+
+// Tracker manages the lifecycle of the user's running timer. Unlike
+// StartTimeEntry, which silently replaces a running timer, a Tracker checks
+// the current timer before every change and reports what it did through
+// OnEvent.
+type Tracker struct {
+	Session *Session
+	// OnEvent, if set, is called for every change the tracker makes to the
+	// running timer.
+	OnEvent func(TimerEvent)
+
+	mu     sync.Mutex
+	paused *TimeEntry
+}
+
+// NewTracker creates a tracker for a session.
+func NewTracker(session *Session) *Tracker {
+	return &Tracker{Session: session}
+}
+
+// Current returns the running timer, or nil if no timer is running.
+func (t *Tracker) Current() (*TimeEntry, error) {
+	entry, err := t.Session.GetCurrentTimeEntry()
+	if err != nil {
+		return nil, err
+	}
+	if entry.ID == 0 || !entry.IsRunning() {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Start starts a timer with the description, project, task, tags and billable
+// flag of the given entry. It returns a *TimerRunningError if a timer is
+// already running.
+func (t *Tracker) Start(entry TimeEntry) (TimeEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, err := t.Current()
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	if current != nil {
+		return TimeEntry{}, &TimerRunningError{Entry: *current}
+	}
+
+	started, err := t.start(entry)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	t.paused = nil
+	t.emit(TimerEvent{Kind: TimerStarted, Entry: started})
+	return started, nil
+}
+
+// Stop stops the running timer and returns it. It returns ErrNoRunningTimer
+// if no timer is running.
+func (t *Tracker) Stop() (TimeEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stopped, err := t.stop()
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	t.paused = nil
+	t.emit(TimerEvent{Kind: TimerStopped, Entry: stopped, Previous: stopped})
+	return stopped, nil
+}
+
+// Switch stops the running timer, if any, and starts a new one. If the new
+// timer can't be started, the stopped timer is restarted with its original
+// start time, so that the user is never left without the timer they had.
+func (t *Tracker) Switch(entry TimeEntry) (TimeEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stopped, err := t.stop()
+	if err == ErrNoRunningTimer {
+		started, err := t.start(entry)
+		if err != nil {
+			return TimeEntry{}, err
+		}
+		t.paused = nil
+		t.emit(TimerEvent{Kind: TimerStarted, Entry: started})
+		return started, nil
+	} else if err != nil {
+		return TimeEntry{}, err
+	}
+
+	started, err := t.start(entry)
+	if err != nil {
+		if _, rollbackErr := t.Session.UnstopTimeEntry(stopped); rollbackErr != nil {
+			t.emit(TimerEvent{Kind: TimerStopped, Entry: stopped, Previous: stopped})
+			return TimeEntry{}, fmt.Errorf("Unable to start timer (%v) or restart %q (%v)", err, stopped.Description, rollbackErr)
+		}
+		return TimeEntry{}, fmt.Errorf("Unable to start timer: %v", err)
+	}
+
+	t.paused = nil
+	t.emit(TimerEvent{Kind: TimerSwitched, Entry: started, Previous: stopped})
+	return started, nil
+}
+
+// Pause stops the running timer and remembers it, so that Resume can start a
+// copy of it later.
+func (t *Tracker) Pause() (TimeEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stopped, err := t.stop()
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	t.paused = &stopped
+	t.emit(TimerEvent{Kind: TimerStopped, Entry: stopped, Previous: stopped})
+	return stopped, nil
+}
+
+// Resume starts a new timer that's a copy of the paused one. It returns
+// ErrNotPaused if no timer was paused, and a *TimerRunningError if another
+// timer was started in the meantime.
+func (t *Tracker) Resume() (TimeEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused == nil {
+		return TimeEntry{}, ErrNotPaused
+	}
+
+	current, err := t.Current()
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	if current != nil {
+		return TimeEntry{}, &TimerRunningError{Entry: *current}
+	}
+
+	started, err := t.start(*t.paused)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	t.paused = nil
+	t.emit(TimerEvent{Kind: TimerStarted, Entry: started})
+	return started, nil
+}
+
+// Paused returns the paused timer, or nil if no timer is paused.
+func (t *Tracker) Paused() *TimeEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused == nil {
+		return nil
+	}
+	entry := t.paused.Copy()
+	return &entry
+}
+
+// ContinueLast starts a new timer that's a copy of the most recent time entry.
+// Unlike ContinueTimeEntry it always creates a new entry. It returns a
+// *TimerRunningError if the most recent entry is still running.
+func (t *Tracker) ContinueLast() (TimeEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	entries, err := t.Session.GetTimeEntries(now.AddDate(0, 0, -9), now)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	var last *TimeEntry
+	for i := range entries {
+		e := &entries[i]
+		if e.ServerDeletedAt != nil || e.Start == nil {
+			continue
+		}
+		if last == nil || e.Start.After(*last.Start) {
+			last = e
+		}
+	}
+	if last == nil {
+		return TimeEntry{}, fmt.Errorf("No recent time entry to continue")
+	}
+	if last.IsRunning() {
+		return TimeEntry{}, &TimerRunningError{Entry: *last}
+	}
+
+	started, err := t.start(*last)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	t.paused = nil
+	t.emit(TimerEvent{Kind: TimerStarted, Entry: started})
+	return started, nil
+}
+
+// support /////////////////////////////////////////////////////////////
+
+// start creates a running entry with the details of the given one.
+func (t *Tracker) start(entry TimeEntry) (TimeEntry, error) {
+	now := time.Now().Truncate(time.Second)
+	entry = entry.Copy()
+	entry.ID = 0
+	entry.Start = &now
+	entry.Stop = nil
+	entry.Duration = -now.Unix()
+	entry.DurOnly = false
+	entry.At = nil
+	entry.ServerDeletedAt = nil
+	return t.Session.CreateTimeEntry(entry)
+}
+
+// stop stops the running timer.
+func (t *Tracker) stop() (TimeEntry, error) {
+	current, err := t.Current()
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	if current == nil {
+		return TimeEntry{}, ErrNoRunningTimer
+	}
+	return t.Session.StopTimeEntry(*current)
+}
+
+func (t *Tracker) emit(event TimerEvent) {
+	if t.OnEvent == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	t.OnEvent(event)
+}
@@ -0,0 +1,198 @@
+package toggl
+
+import (
+	"context"
+	"time"
+)
+
+// TimerEventKind identifies the kind of change described by a TimerEvent.
+type TimerEventKind int
+
+// Kinds of TimerEvent.
+const (
+	// TimerStarted is sent when a timer starts running while none was.
+	TimerStarted TimerEventKind = iota
+	// TimerStopped is sent when the running timer stops and no other starts.
+	TimerStopped
+	// TimerSwitched is sent when a different timer replaces the running one.
+	TimerSwitched
+	// TimerDescriptionChanged is sent when the running timer's description
+	// changes.
+	TimerDescriptionChanged
+	// TimerProjectChanged is sent when the running timer's project changes.
+	TimerProjectChanged
+	// TimerTagsChanged is sent when the running timer's tags change.
+	TimerTagsChanged
+	// TimerError is sent when the current timer can't be retrieved.
+	TimerError
+)
+
+var timerEventNames = map[TimerEventKind]string{
+	TimerStarted:            "started",
+	TimerStopped:            "stopped",
+	TimerSwitched:           "switched",
+	TimerDescriptionChanged: "description changed",
+	TimerProjectChanged:     "project changed",
+	TimerTagsChanged:        "tags changed",
+	TimerError:              "error",
+}
+
+func (k TimerEventKind) String() string {
+	if name, ok := timerEventNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// TimerEvent describes a change to a user's running timer.
+type TimerEvent struct {
+	Kind TimerEventKind
+	// Entry is the running timer after the change. For TimerStopped it's the
+	// timer that was stopped.
+	Entry TimeEntry
+	// Previous is the running timer before the change, if there was one.
+	Previous TimeEntry
+	// Err is set for TimerError events.
+	Err  error
+	Time time.Time
+}
+
+// DefaultWatchInterval is the polling interval of WatchCurrentTimeEntry when
+// the given one isn't positive.
+const DefaultWatchInterval = 30 * time.Second
+
+// Polling limits used by WatchCurrentTimeEntry.
+const (
+	// watchIdleFactor is how many times the base interval polling slows down
+	// to while nothing changes.
+	watchIdleFactor = 4
+	// watchIdlePolls is the number of unchanged polls after which polling
+	// starts slowing down.
+	watchIdlePolls = 5
+	// watchMaxBackoff is the longest delay between polls after errors.
+	watchMaxBackoff = 5 * time.Minute
+)
+
+// WatchCurrentTimeEntry polls the user's running timer and sends an event on
+// the returned channel for every change between successive polls. If a timer
+// is running when watching starts, a TimerStarted event is sent for it.
+//
+// Polling happens every interval while the timer changes, slows down to a few
+// times the interval while it doesn't, and backs off exponentially while
+// requests fail. An interval of zero means DefaultWatchInterval. The channel
+// is closed when ctx is done.
+func (session *Session) WatchCurrentTimeEntry(ctx context.Context, interval time.Duration) <-chan TimerEvent {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	events := make(chan TimerEvent)
+
+	go func() {
+		defer close(events)
+
+		var current TimeEntry
+		delay := interval
+		unchanged := 0
+
+		send := func(event TimerEvent) bool {
+			event.Time = time.Now()
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			entry, err := session.GetCurrentTimeEntry()
+			if err != nil {
+				if !send(TimerEvent{Kind: TimerError, Err: err, Previous: current}) {
+					return
+				}
+				if delay < interval {
+					delay = interval
+				}
+				delay *= 2
+				if delay > watchMaxBackoff {
+					delay = watchMaxBackoff
+				}
+			} else {
+				changes := session.diffTimers(current, entry)
+				for _, event := range changes {
+					if !send(event) {
+						return
+					}
+				}
+				current = entry
+
+				if len(changes) > 0 {
+					unchanged = 0
+				} else {
+					unchanged++
+				}
+				delay = interval
+				if unchanged > watchIdlePolls {
+					factor := unchanged - watchIdlePolls + 1
+					if factor > watchIdleFactor {
+						factor = watchIdleFactor
+					}
+					delay = interval * time.Duration(factor)
+				}
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// diffTimers returns the events describing the change from one poll of the
+// running timer to the next. An entry with a zero ID means no timer was
+// running.
+func (session *Session) diffTimers(previous, current TimeEntry) (events []TimerEvent) {
+	switch {
+	case previous.ID == 0 && current.ID == 0:
+		return nil
+	case previous.ID == 0:
+		return []TimerEvent{{Kind: TimerStarted, Entry: current}}
+	case current.ID == 0:
+		// Report the stopped timer as the server recorded it, if possible
+		stopped := previous
+		if entry, err := session.GetTimeEntry(previous.ID); err == nil && entry.ID != 0 {
+			stopped = entry
+		}
+		return []TimerEvent{{Kind: TimerStopped, Entry: stopped, Previous: previous}}
+	case previous.ID != current.ID:
+		return []TimerEvent{{Kind: TimerSwitched, Entry: current, Previous: previous}}
+	}
+
+	if previous.Description != current.Description {
+		events = append(events, TimerEvent{Kind: TimerDescriptionChanged, Entry: current, Previous: previous})
+	}
+	if previous.Pid != current.Pid {
+		events = append(events, TimerEvent{Kind: TimerProjectChanged, Entry: current, Previous: previous})
+	}
+	if !sameTags(previous.Tags, current.Tags) {
+		events = append(events, TimerEvent{Kind: TimerTagsChanged, Entry: current, Previous: previous})
+	}
+	return events
+}
+
+// sameTags returns true if two tag lists contain the same tags, in any order.
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, tag := range a {
+		if indexOfTag(tag, b) == -1 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchZeroInterval(t *testing.T) {
+	var requests int32
+	saved := client
+	defer func() { client = saved }()
+	client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		body := []byte(`{"data":null}`)
+		return &http.Response{StatusCode: 200, Status: "200 OK", Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+	})}
+
+	DisableLog()
+	session := OpenSession("token")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	for event := range session.WatchCurrentTimeEntry(ctx, 0) {
+		t.Errorf("unexpected %s event", event.Kind)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("%d requests in 100ms with a zero interval, want 1", n)
+	}
+}
+
+func TestDiffTimers(t *testing.T) {
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	stop := start.Add(time.Hour)
+	running := func(id int, description string, pid int, tags ...string) TimeEntry {
+		return TimeEntry{ID: id, Description: description, Pid: pid, Tags: tags, Start: &start, Duration: -start.Unix()}
+	}
+
+	// The server has entry 1 as stopped, and lost entry 9
+	saved := client
+	defer func() { client = saved }()
+	client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := []byte(`{"data":null}`)
+		if req.URL.Path == "/api/v8/time_entries/1" {
+			body = []byte(`{"data":{"id":1,"description":"Design","start":"2026-10-14T09:00:00Z","stop":"2026-10-14T10:00:00Z","duration":3600}}`)
+		}
+		return &http.Response{StatusCode: 200, Status: "200 OK", Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+	})}
+	DisableLog()
+	session := OpenSession("token")
+
+	design := running(1, "Design", 100, "ui", "review")
+	stopped := TimeEntry{ID: 1, Description: "Design", Start: &start, Stop: &stop, Duration: 3600}
+	tests := []struct {
+		name              string
+		previous, current TimeEntry
+		kinds             []TimerEventKind
+		entry             TimeEntry
+	}{
+		{"idle", TimeEntry{}, TimeEntry{}, nil, TimeEntry{}},
+		{"unchanged", design, running(1, "Design", 100, "review", "ui"), nil, TimeEntry{}},
+		{"start", TimeEntry{}, design, []TimerEventKind{TimerStarted}, design},
+		{"stop", design, TimeEntry{}, []TimerEventKind{TimerStopped}, stopped},
+		{"stop unknown", running(9, "Lost", 0), TimeEntry{}, []TimerEventKind{TimerStopped}, running(9, "Lost", 0)},
+		{"switch", design, running(2, "Design", 100, "ui", "review"), []TimerEventKind{TimerSwitched}, running(2, "Design", 100, "ui", "review")},
+		{"description", design, running(1, "Design review", 100, "ui", "review"), []TimerEventKind{TimerDescriptionChanged}, running(1, "Design review", 100, "ui", "review")},
+		{"project", design, running(1, "Design", 101, "ui", "review"), []TimerEventKind{TimerProjectChanged}, running(1, "Design", 101, "ui", "review")},
+		{"tags", design, running(1, "Design", 100, "ui"), []TimerEventKind{TimerTagsChanged}, running(1, "Design", 100, "ui")},
+		{"every field", design, running(1, "Copy", 0), []TimerEventKind{TimerDescriptionChanged, TimerProjectChanged, TimerTagsChanged}, running(1, "Copy", 0)},
+	}
+
+	for _, test := range tests {
+		events := session.diffTimers(test.previous, test.current)
+		var kinds []TimerEventKind
+		for _, event := range events {
+			kinds = append(kinds, event.Kind)
+			if event.Entry.ID != test.entry.ID || !reflect.DeepEqual(event.Entry.Stop, test.entry.Stop) ||
+				event.Entry.Description != test.entry.Description {
+				t.Errorf("%s: %s event with %+v, want %+v", test.name, event.Kind, event.Entry, test.entry)
+			}
+			if event.Kind != TimerStarted && event.Previous.ID != test.previous.ID {
+				t.Errorf("%s: %s event after %d, want %d", test.name, event.Kind, event.Previous.ID, test.previous.ID)
+			}
+		}
+		if !reflect.DeepEqual(kinds, test.kinds) {
+			t.Errorf("%s: events %v, want %v", test.name, kinds, test.kinds)
+		}
+	}
+}
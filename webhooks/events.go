@@ -0,0 +1,180 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// ID is a numeric identifier that Toggl may encode as either a JSON number or
+// a string.
+type ID int
+
+// UnmarshalJSON decodes an ID from a JSON number or string.
+func (id *ID) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	if s == "" {
+		*id = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*id = ID(n)
+	return nil
+}
+
+// Metadata describes the change that caused an event.
+type Metadata struct {
+	Action      string `json:"action"`
+	Model       string `json:"model"`
+	Path        string `json:"path"`
+	RequestType string `json:"request_type"`
+	EventUserID ID     `json:"event_user_id"`
+	WorkspaceID ID     `json:"workspace_id"`
+}
+
+// Event is a webhook delivery. Payload holds the raw entity the event is about;
+// Handler decodes it into the typed entity for the event's model.
+type Event struct {
+	EventID           int64           `json:"event_id"`
+	CreatedAt         time.Time       `json:"created_at"`
+	CreatorID         int             `json:"creator_id"`
+	Metadata          Metadata        `json:"metadata"`
+	Payload           json.RawMessage `json:"payload"`
+	SubscriptionID    int             `json:"subscription_id"`
+	Timestamp         time.Time       `json:"timestamp"`
+	URLCallback       string          `json:"url_callback"`
+	ValidationCode    string          `json:"validation_code,omitempty"`
+	ValidationCodeURL string          `json:"validation_code_url,omitempty"`
+}
+
+// IsPing returns true if the event is a test event sent by Client.Ping or when
+// a subscription is created.
+func (e *Event) IsPing() bool {
+	return string(e.Payload) == `"PING"`
+}
+
+// TimeEntryPayload is the payload of a time entry event.
+type TimeEntryPayload struct {
+	ID              int        `json:"id"`
+	WorkspaceID     int        `json:"workspace_id"`
+	ProjectID       int        `json:"project_id"`
+	TaskID          int        `json:"task_id"`
+	UserID          int        `json:"user_id"`
+	Description     string     `json:"description"`
+	Start           *time.Time `json:"start"`
+	Stop            *time.Time `json:"stop"`
+	Duration        int64      `json:"duration"`
+	Billable        bool       `json:"billable"`
+	Duronly         bool       `json:"duronly"`
+	Tags            []string   `json:"tags"`
+	TagIDs          []int      `json:"tag_ids"`
+	At              *time.Time `json:"at"`
+	ServerDeletedAt *time.Time `json:"server_deleted_at"`
+}
+
+// TimeEntry converts the payload into a toggl.TimeEntry.
+func (e *TimeEntryPayload) TimeEntry() toggl.TimeEntry {
+	entry := toggl.TimeEntry{
+		Wid:             e.WorkspaceID,
+		ID:              e.ID,
+		Pid:             e.ProjectID,
+		Tid:             e.TaskID,
+		Description:     e.Description,
+		Start:           e.Start,
+		Stop:            e.Stop,
+		Tags:            e.Tags,
+		Duration:        e.Duration,
+		DurOnly:         e.Duronly,
+		At:              e.At,
+		ServerDeletedAt: e.ServerDeletedAt,
+	}
+	if e.Billable {
+		entry.Billable = 1
+	}
+	return entry
+}
+
+// ProjectPayload is the payload of a project event.
+type ProjectPayload struct {
+	ID              int        `json:"id"`
+	WorkspaceID     int        `json:"workspace_id"`
+	ClientID        int        `json:"client_id"`
+	Name            string     `json:"name"`
+	Active          bool       `json:"active"`
+	IsPrivate       bool       `json:"is_private"`
+	Billable        bool       `json:"billable"`
+	Color           string     `json:"color"`
+	Rate            float64    `json:"rate"`
+	Currency        string     `json:"currency"`
+	At              *time.Time `json:"at"`
+	CreatedAt       *time.Time `json:"created_at"`
+	ServerDeletedAt *time.Time `json:"server_deleted_at"`
+}
+
+// Project converts the payload into a toggl.Project.
+func (p *ProjectPayload) Project() toggl.Project {
+	project := toggl.Project{
+		Wid:             p.WorkspaceID,
+		ID:              p.ID,
+		Cid:             p.ClientID,
+		Name:            p.Name,
+		Active:          p.Active,
+		ServerDeletedAt: p.ServerDeletedAt,
+	}
+	if p.Billable {
+		project.Billable = 1
+	}
+	return project
+}
+
+// ClientPayload is the payload of a client event.
+type ClientPayload struct {
+	ID              int        `json:"id"`
+	WorkspaceID     int        `json:"wid"`
+	Name            string     `json:"name"`
+	Notes           string     `json:"notes"`
+	Archived        bool       `json:"archived"`
+	At              *time.Time `json:"at"`
+	ServerDeletedAt *time.Time `json:"server_deleted_at"`
+}
+
+// Client converts the payload into a toggl.Client.
+func (c *ClientPayload) Client() toggl.Client {
+	return toggl.Client{
+		Wid:             c.WorkspaceID,
+		ID:              c.ID,
+		Name:            c.Name,
+		Notes:           c.Notes,
+		ServerDeletedAt: c.ServerDeletedAt,
+	}
+}
+
+// TagPayload is the payload of a tag event.
+type TagPayload struct {
+	ID          int        `json:"id"`
+	WorkspaceID int        `json:"workspace_id"`
+	Name        string     `json:"name"`
+	At          *time.Time `json:"at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+// Tag converts the payload into a toggl.Tag.
+func (t *TagPayload) Tag() toggl.Tag {
+	return toggl.Tag{
+		Wid:             t.WorkspaceID,
+		ID:              t.ID,
+		Name:            t.Name,
+		ServerDeletedAt: t.DeletedAt,
+	}
+}
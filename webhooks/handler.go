@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// SignatureHeader is the header Toggl signs webhook deliveries with.
+const SignatureHeader = "X-Webhook-Signature-256"
+
+// maxBodySize limits the size of deliveries Handler accepts.
+const maxBodySize = 1 << 20
+
+// Handler is an http.Handler that receives webhook deliveries, verifies their
+// signatures and dispatches them to callbacks by entity type. Validation
+// requests are answered automatically, and ping events are acknowledged
+// without invoking any callback.
+//
+// If a callback returns an error, the delivery is answered with a server error
+// so that Toggl retries it later. Events for entities without a callback are
+// passed to OnEvent, if it's set, and acknowledged otherwise.
+type Handler struct {
+	// Secret is the subscription's secret. Deliveries with a missing or
+	// invalid signature are rejected, and so are all deliveries if it's
+	// empty, so that a handler without a secret can't be sent forged events.
+	Secret string
+
+	OnTimeEntry func(event Event, entry TimeEntryPayload) error
+	OnProject   func(event Event, project ProjectPayload) error
+	OnClient    func(event Event, client ClientPayload) error
+	OnTag       func(event Event, tag TagPayload) error
+	OnEvent     func(event Event) error
+}
+
+// Sign returns the signature header value for a delivery body signed with the
+// given secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if signature is a valid signature of body for the given
+// secret.
+func Verify(secret string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// ServeHTTP handles a webhook delivery.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "unable to read body", http.StatusBadRequest)
+		return
+	}
+
+	if h.Secret == "" {
+		http.Error(w, "no webhook secret configured", http.StatusUnauthorized)
+		return
+	}
+	if !Verify(h.Secret, body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var event Event
+	if err = json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	if event.ValidationCode != "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"validation_code": event.ValidationCode})
+		return
+	}
+
+	if event.IsPing() {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err = h.dispatch(event); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// dispatch decodes an event's payload and passes it to the matching callback.
+func (h *Handler) dispatch(event Event) error {
+	switch {
+	case event.Metadata.Model == EntityTimeEntry && h.OnTimeEntry != nil:
+		var entry TimeEntryPayload
+		if err := json.Unmarshal(event.Payload, &entry); err != nil {
+			return fmt.Errorf("invalid time entry payload: %v", err)
+		}
+		return h.OnTimeEntry(event, entry)
+	case event.Metadata.Model == EntityProject && h.OnProject != nil:
+		var project ProjectPayload
+		if err := json.Unmarshal(event.Payload, &project); err != nil {
+			return fmt.Errorf("invalid project payload: %v", err)
+		}
+		return h.OnProject(event, project)
+	case event.Metadata.Model == EntityClient && h.OnClient != nil:
+		var client ClientPayload
+		if err := json.Unmarshal(event.Payload, &client); err != nil {
+			return fmt.Errorf("invalid client payload: %v", err)
+		}
+		return h.OnClient(event, client)
+	case event.Metadata.Model == EntityTag && h.OnTag != nil:
+		var tag TagPayload
+		if err := json.Unmarshal(event.Payload, &tag); err != nil {
+			return fmt.Errorf("invalid tag payload: %v", err)
+		}
+		return h.OnTag(event, tag)
+	case h.OnEvent != nil:
+		return h.OnEvent(event)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSecret = "s3cret"
+
+const timeEntryEvent = `{
+	"event_id": 1,
+	"metadata": {"action": "created", "model": "time_entry", "workspace_id": "7"},
+	"payload": {"id": 42, "workspace_id": 7, "description": "Fix login bug", "billable": true, "tags": ["bug"]}
+}`
+
+func deliver(h *Handler, body, signature string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	if signature != "" {
+		r.Header.Set(SignatureHeader, signature)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerSignatures(t *testing.T) {
+	signed := Sign(testSecret, []byte(timeEntryEvent))
+	tests := []struct {
+		name      string
+		secret    string
+		body      string
+		signature string
+		status    int
+		delivered bool
+	}{
+		{"valid", testSecret, timeEntryEvent, signed, http.StatusOK, true},
+		{"tampered body", testSecret, strings.Replace(timeEntryEvent, "42", "43", 1), signed, http.StatusUnauthorized, false},
+		{"wrong secret", testSecret, timeEntryEvent, Sign("other", []byte(timeEntryEvent)), http.StatusUnauthorized, false},
+		{"missing signature", testSecret, timeEntryEvent, "", http.StatusUnauthorized, false},
+		{"signature without prefix", testSecret, timeEntryEvent, strings.TrimPrefix(signed, "sha256="), http.StatusUnauthorized, false},
+		{"no secret", "", timeEntryEvent, Sign("", []byte(timeEntryEvent)), http.StatusUnauthorized, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delivered := false
+			h := &Handler{
+				Secret: test.secret,
+				OnTimeEntry: func(event Event, entry TimeEntryPayload) error {
+					delivered = true
+					if entry.ID != 42 || entry.Description != "Fix login bug" || !entry.Billable {
+						t.Errorf("entry = %+v", entry)
+					}
+					if event.Metadata.WorkspaceID != 7 {
+						t.Errorf("workspace = %d, want 7", event.Metadata.WorkspaceID)
+					}
+					return nil
+				},
+			}
+			w := deliver(h, test.body, test.signature)
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d", w.Code, test.status)
+			}
+			if delivered != test.delivered {
+				t.Errorf("delivered = %v, want %v", delivered, test.delivered)
+			}
+		})
+	}
+}
+
+func TestHandlerValidationAndPing(t *testing.T) {
+	h := &Handler{
+		Secret: testSecret,
+		OnEvent: func(Event) error {
+			t.Error("callback called")
+			return nil
+		},
+	}
+
+	body := `{"validation_code": "abc123"}`
+	w := deliver(h, body, Sign(testSecret, []byte(body)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"validation_code":"abc123"`) {
+		t.Errorf("validation: %d %s", w.Code, w.Body)
+	}
+
+	body = `{"payload": "PING"}`
+	if w := deliver(h, body, Sign(testSecret, []byte(body))); w.Code != http.StatusOK {
+		t.Errorf("ping: status %d", w.Code)
+	}
+}
+
+func TestHandlerCallbackError(t *testing.T) {
+	h := &Handler{
+		Secret:      testSecret,
+		OnTimeEntry: func(Event, TimeEntryPayload) error { return errors.New("database down") },
+	}
+	w := deliver(h, timeEntryEvent, Sign(testSecret, []byte(timeEntryEvent)))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d so that Toggl retries", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestHandlerMethod(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/webhook", nil)
+	w := httptest.NewRecorder()
+	(&Handler{Secret: testSecret}).ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
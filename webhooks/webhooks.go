@@ -0,0 +1,200 @@
+/*
+Package webhooks provides a client for Toggl Track's webhooks API and an
+http.Handler for receiving signed webhook deliveries.
+
+See https://developers.track.toggl.com/docs/webhooks_start for more information
+on Toggl's webhooks API.
+*/
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	toggl "github.com/Jberlinsky/go-toggl"
+)
+
+// WebhooksAPI is the base URL of Toggl's webhooks API.
+const WebhooksAPI = "https://api.track.toggl.com/webhooks/api/v1"
+
+// Entities and actions used in event filters. A filter with the wildcard for
+// both matches every event.
+const (
+	EntityTimeEntry = "time_entry"
+	EntityProject   = "project"
+	EntityClient    = "client"
+	EntityTag       = "tag"
+
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+
+	Wildcard = "*"
+)
+
+var client = &http.Client{}
+
+// structures ///////////////////////////
+
+// Client provides access to the webhooks API of a user's workspaces.
+type Client struct {
+	APIToken string
+}
+
+// EventFilter selects the events delivered to a subscription.
+type EventFilter struct {
+	Entity string `json:"entity"`
+	Action string `json:"action"`
+}
+
+// Subscription represents a webhook subscription.
+type Subscription struct {
+	SubscriptionID   int           `json:"subscription_id,omitempty"`
+	WorkspaceID      int           `json:"workspace_id,omitempty"`
+	UserID           int           `json:"user_id,omitempty"`
+	Enabled          bool          `json:"enabled"`
+	Description      string        `json:"description"`
+	EventFilters     []EventFilter `json:"event_filters"`
+	URLCallback      string        `json:"url_callback"`
+	Secret           string        `json:"secret,omitempty"`
+	ValidatedAt      *time.Time    `json:"validated_at,omitempty"`
+	HasPendingEvents bool          `json:"has_pending_events,omitempty"`
+	CreatedAt        *time.Time    `json:"created_at,omitempty"`
+	UpdatedAt        *time.Time    `json:"updated_at,omitempty"`
+	DeletedAt        *time.Time    `json:"deleted_at,omitempty"`
+}
+
+// functions ////////////////////////////
+
+// NewClient creates a webhooks client for the user of the given session.
+func NewClient(session toggl.Session) *Client {
+	return &Client{APIToken: session.APIToken}
+}
+
+// GetSubscriptions returns the webhook subscriptions of a workspace.
+func (c *Client) GetSubscriptions(wid int) (subscriptions []Subscription, err error) {
+	path := fmt.Sprintf("/subscriptions/%d", wid)
+	data, err := c.request("GET", path, nil)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &subscriptions)
+	return
+}
+
+// CreateSubscription creates a subscription in the subscription's workspace.
+// New subscriptions must be validated before events are delivered to them.
+func (c *Client) CreateSubscription(subscription Subscription) (Subscription, error) {
+	path := fmt.Sprintf("/subscriptions/%d", subscription.WorkspaceID)
+	return c.subscriptionRequest("POST", path, subscriptionBody(subscription))
+}
+
+// UpdateSubscription changes an existing subscription.
+func (c *Client) UpdateSubscription(subscription Subscription) (Subscription, error) {
+	path := fmt.Sprintf("/subscriptions/%d/%d", subscription.WorkspaceID, subscription.SubscriptionID)
+	return c.subscriptionRequest("PUT", path, subscriptionBody(subscription))
+}
+
+// EnableSubscription enables or disables a subscription.
+func (c *Client) EnableSubscription(wid, sid int, enabled bool) (Subscription, error) {
+	path := fmt.Sprintf("/subscriptions/%d/%d", wid, sid)
+	return c.subscriptionRequest("PATCH", path, map[string]interface{}{"enabled": enabled})
+}
+
+// DeleteSubscription deletes a subscription.
+func (c *Client) DeleteSubscription(wid, sid int) (Subscription, error) {
+	path := fmt.Sprintf("/subscriptions/%d/%d", wid, sid)
+	return c.subscriptionRequest("DELETE", path, nil)
+}
+
+// GetEventFilters returns the actions that can be subscribed to, by entity.
+func (c *Client) GetEventFilters() (filters map[string][]string, err error) {
+	data, err := c.request("GET", "/event_filters", nil)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &filters)
+	return
+}
+
+// Ping asks Toggl to send a test event to a subscription's callback URL.
+func (c *Client) Ping(wid, sid int) error {
+	path := fmt.Sprintf("/ping/%d/%d", wid, sid)
+	_, err := c.request("POST", path, nil)
+	return err
+}
+
+// Validate validates a subscription using the validation code Toggl sent to
+// its callback URL. This is only needed for endpoints that don't echo the
+// code back when receiving it; Handler does that automatically.
+func (c *Client) Validate(wid, sid int, code string) error {
+	path := fmt.Sprintf("/validate/%d/%d/%s", wid, sid, code)
+	_, err := c.request("GET", path, nil)
+	return err
+}
+
+// support /////////////////////////////////////////////////////////////
+
+// subscriptionBody returns the fields of a subscription that can be set by
+// users.
+func subscriptionBody(subscription Subscription) map[string]interface{} {
+	body := map[string]interface{}{
+		"url_callback":  subscription.URLCallback,
+		"event_filters": subscription.EventFilters,
+		"enabled":       subscription.Enabled,
+		"description":   subscription.Description,
+	}
+	if subscription.Secret != "" {
+		body["secret"] = subscription.Secret
+	}
+	return body
+}
+
+func (c *Client) subscriptionRequest(method, path string, body interface{}) (subscription Subscription, err error) {
+	data, err := c.request(method, path, body)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &subscription)
+	return
+}
+
+func (c *Client) request(method, path string, data interface{}) ([]byte, error) {
+	var body io.Reader
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, WebhooksAPI+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.APIToken, "api_token")
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return content, &toggl.APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: content}
+	}
+
+	return content, nil
+}